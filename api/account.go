@@ -1,15 +1,14 @@
 package api
 
 import (
-	"database/sql"
-	"errors"
 
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/joekings2k/gobank/db/sqlc"
 	"github.com/joekings2k/gobank/token"
-	"github.com/lib/pq"
+	"github.com/joekings2k/gobank/util"
 )
 
 type createAccountRequest struct{
@@ -19,7 +18,7 @@ type createAccountRequest struct{
 func(server *Server) createAccount(ctx *gin.Context){
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err!= nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
@@ -31,26 +30,12 @@ func(server *Server) createAccount(ctx *gin.Context){
 
 	account,err  := server.store.CreateAccount(ctx,arg)
 	if err != nil{
-		if pqErr, ok :=err.(*pq.Error);ok {
-			switch pqErr.Code.Name(){
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden,errorResponse(err))
-		return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 	ctx.JSON(http.StatusOK,account)
 }
 
-func (server *Server) checkHealth(ctx *gin.Context){
-	ctx.JSON(http.StatusOK,gin.H{
-		"status":"ok",
-		"message":"server is healthy",
-	})
-}
-
 type getAccountRequest struct{
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
@@ -58,22 +43,17 @@ type getAccountRequest struct{
 func(server *Server) getAccount(ctx *gin.Context){
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req);err!=nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 	account,err :=server.store.GetAccount(ctx,req.ID)
 	if err !=nil{
-		if err == sql.ErrNoRows{
-			ctx.JSON(http.StatusNotFound,errorResponse(err))
-		return
-		}
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn`t belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized,errorResponse(err))
+	if account.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx,newAPIError(notOwnerCode,"account doesn`t belong to the authenticated user"))
 		return
 	}
 
@@ -81,30 +61,70 @@ func(server *Server) getAccount(ctx *gin.Context){
 }
 
 type ListAccountsRequest struct{
-	PageID int32 `form:"page_id" binding:"required,min=1"`
+	Owner string `form:"owner" binding:"omitempty,alphanum"`
+	// PageID selects the legacy offset form, without it the listing is keyset paginated by Cursor
+	PageID int32 `form:"page_id" binding:"omitempty,min=1"`
 	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor string `form:"cursor"`
+}
 
+type listAccountsResponse struct {
+	Accounts []db.Account `json:"accounts"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 func(server *Server) ListAccounts(ctx *gin.Context){
 	var req ListAccountsRequest
 	if err := ctx.ShouldBindQuery(&req);err!=nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.ListAccountsParams{
-		Owner: authPayload.Username,
-		Limit: req.PageSize,
-		Offset: (req.PageID - 1) *req.PageSize,
+	owner := authPayload.Username
+	if req.Owner != "" && req.Owner != authPayload.Username {
+		if !util.IsPrivilegedRole(authPayload.Role) {
+			writeError(ctx,newAPIError(notOwnerCode,"only bankers can list other users' accounts"))
+			return
+		}
+		owner = req.Owner
+	}
+	page, ok := server.bindPagination(ctx, "accounts:"+owner, req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
 	}
-	accounts,err :=server.store.ListAccounts(ctx,arg)
+	if page.offsetForm {
+		accounts,err :=server.store.ListAccounts(ctx,db.ListAccountsParams{
+			Owner: owner,
+			Limit: page.limit(),
+			Offset: page.offset(),
+		})
+		if err !=nil{
+			writeError(ctx,err)
+			return
+		}
+		ctx.JSON(http.StatusOK,accounts)
+		return
+	}
+
+	accounts,err :=server.store.ListAccountsAfter(ctx,db.ListAccountsAfterParams{
+		Owner: owner,
+		AfterCreatedAt: page.cursorTime(),
+		AfterID: page.cursorID(),
+		Limit: page.limit(),
+	})
 	if err !=nil{
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK,accounts)
+	accounts, next, err := keysetPage(server, page, accounts, func(account db.Account) (time.Time, int64) {
+		return account.CreatedAt, account.ID
+	})
+	if err != nil {
+		writeError(ctx,err)
+		return
+	}
+	ctx.JSON(http.StatusOK,listAccountsResponse{Accounts: accounts, NextCursor: next})
 }
 
 
@@ -114,32 +134,64 @@ type updateAccountUri struct{
 }
 
 type updateAccountBody struct {
-	Balance int64 `json:"balance" binding:"required"`
+	// Amount is added to the balance, negative amounts debit the account
+	Amount int64 `json:"amount" binding:"required"`
 }
+
+// updateAccount adjusts an account balance through a ledger entry, it is only routed for bankers and admins
 func(server *Server) updateAccount(ctx *gin.Context){
 	var uri updateAccountUri
 	if err := ctx.ShouldBindUri(&uri);err!=nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 	var body updateAccountBody
 	if err := ctx.ShouldBindJSON(&body); err != nil {
-      ctx.JSON(http.StatusBadRequest, errorResponse(err))
-      return
-  }
+		writeError(ctx,invalidRequest(err))
+		return
+	}
 
-	arg := db.UpdateAccountParams{
-		ID: uri.ID,
-		Balance:body.Balance ,
+	arg := db.AdjustBalanceTxParams{
+		AccountID: uri.ID,
+		Amount: body.Amount,
 	}
-	
-	accounts,err :=server.store.UpdateAccount(ctx,arg)
+
+	result,err :=server.store.AdjustBalanceTx(ctx,arg)
 	if err !=nil{
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK,accounts)
+	ctx.JSON(http.StatusOK,result)
+}
+
+type updateOverdraftLimitBody struct {
+	OverdraftLimit int64 `json:"overdraft_limit" binding:"min=0"`
+}
+
+// updateOverdraftLimit sets how far below zero an account may go, it is only routed for bankers and admins
+func(server *Server) updateOverdraftLimit(ctx *gin.Context){
+	var uri updateAccountUri
+	if err := ctx.ShouldBindUri(&uri);err!=nil{
+		writeError(ctx,invalidRequest(err))
+		return
+	}
+	var body updateOverdraftLimitBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		writeError(ctx,invalidRequest(err))
+		return
+	}
+
+	account,err :=server.store.UpdateAccountOverdraftLimit(ctx,db.UpdateAccountOverdraftLimitParams{
+		ID: uri.ID,
+		OverdraftLimit: body.OverdraftLimit,
+	})
+	if err !=nil{
+		writeError(ctx,err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK,account)
 }
 
 type deleteAccountUri struct{
@@ -149,17 +201,28 @@ type deleteAccountUri struct{
 func(server *Server) deleteAccount(ctx *gin.Context){
 	var uri deleteAccountUri
 	if err := ctx.ShouldBindUri(&uri);err!=nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 
-	err :=server.store.DeleteAccount(ctx,uri.ID)
+	account,err :=server.store.GetAccount(ctx,uri.ID)
 	if err !=nil{
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx,newAPIError(notOwnerCode,"account doesn`t belong to the authenticated user"))
+		return
+	}
+
+	err =server.store.DeleteAccountTx(ctx,uri.ID)
+	if err !=nil{
+		writeError(ctx,err)
 		return
 	}
 	ctx.JSON(http.StatusOK,gin.H{
 		"status":"ok",
 		"message":"Account has been deleted",
 	})
-}
\ No newline at end of file
+}
@@ -37,7 +37,7 @@ func TestGetAcccount(t *testing.T){
 			name: "OK",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -55,7 +55,7 @@ func TestGetAcccount(t *testing.T){
 			name: "UnauthorizedUser",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unathorized_user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unathorized_user", util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -64,7 +64,24 @@ func TestGetAcccount(t *testing.T){
 				Return(account, nil)
 			},
 			checkResponse:func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusUnauthorized,recorder.Code)
+				require.Equal(t, http.StatusForbidden,recorder.Code)
+			},
+		},
+		{
+			name: "BankerCanViewAnyAccount",
+			accountID: account.ID,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+				GetAccount(gomock.Any(),gomock.Eq(account.ID)).
+				Times(1).
+				Return(account, nil)
+			},
+			checkResponse:func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK,recorder.Code)
+				requireBodyMatchAccount(t,recorder.Body,account)
 			},
 		},
 		{
@@ -86,7 +103,7 @@ func TestGetAcccount(t *testing.T){
 			name: "NotFound",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, time.Minute)
+								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -104,7 +121,7 @@ func TestGetAcccount(t *testing.T){
 			name: "InternalError",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, time.Minute)
+								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -121,7 +138,7 @@ func TestGetAcccount(t *testing.T){
 			name: "InvalidID",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, time.Minute)
+								addAuthorization(t, request, tokenMaker, authorizationTypeBearer,user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -141,6 +158,7 @@ func TestGetAcccount(t *testing.T){
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 			
 			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -156,22 +174,6 @@ func TestGetAcccount(t *testing.T){
 	}
 }
 
-func TestCheckHealth (t *testing.T){
-	ctrl :=gomock.NewController(t)
-	defer ctrl.Finish()
-	store := mockdb.NewMockStore(ctrl)
-
-	server := newTestServer(t, store)
-	recorder := httptest.NewRecorder()
-	url := "/"
-	request,err := http.NewRequest(http.MethodGet,url,nil)
-	require.NoError(t,err)
-	server.router.ServeHTTP(recorder,request)
-	require.Equal(t,http.StatusOK, recorder.Code)
-	
-}
-
-
 func TestCreateAccount(t *testing.T){
 	user, _ := ramdomUser(t)
 	account := randomAccount(user.Username)
@@ -190,7 +192,7 @@ func TestCreateAccount(t *testing.T){
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -212,7 +214,7 @@ func TestCreateAccount(t *testing.T){
 				"currency": account.Currency,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -234,7 +236,7 @@ func TestCreateAccount(t *testing.T){
 				"currency": "",
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
@@ -256,6 +258,7 @@ func TestCreateAccount(t *testing.T){
 
 			store:=mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 
 			server:= newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -293,7 +296,7 @@ func TestListAccounts(t *testing.T){
 				PageSize: 5,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker,authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().ListAccounts(gomock.Any(),gomock.Eq(db.ListAccountsParams{
@@ -314,7 +317,7 @@ func TestListAccounts(t *testing.T){
 				PageSize: 5,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().ListAccounts(gomock.Any(),gomock.Any()).Times(0)
@@ -330,7 +333,7 @@ func TestListAccounts(t *testing.T){
 				PageSize: 5,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().ListAccounts(gomock.Any(),gomock.Eq(db.ListAccountsParams{
@@ -352,6 +355,7 @@ func TestListAccounts(t *testing.T){
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 
 			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -370,11 +374,11 @@ func TestUpdateAccount(t *testing.T){
 	user, _ := ramdomUser(t)
 	account := randomAccount(user.Username)
 	updatedAccount := account
-	updatedAccount.Balance = 100 
+	updatedAccount.Balance += 100
 	testCases := []struct{
 		name string
 		accountID int64
-		body updateAccountBody
+		body gin.H
 		setupAuth 		func (t *testing.T, request *http.Request, tokenMaker token.Maker)
 		buildStubs 		func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T,recorder *httptest.ResponseRecorder)
@@ -382,70 +386,97 @@ func TestUpdateAccount(t *testing.T){
 		{
 			name: "OK",
 			accountID: account.ID,
-			body: updateAccountBody{
-				Balance: 100,
+			body: gin.H{
+				"amount": 100,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateAccount(gomock.Any(),gomock.Eq(db.UpdateAccountParams{
-					ID: account.ID,
-					Balance: 100,
-				})).Times(1).Return(updatedAccount,nil)
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Eq(db.AdjustBalanceTxParams{
+					AccountID: account.ID,
+					Amount: 100,
+				})).Times(1).Return(db.AdjustBalanceTxResult{Account: updatedAccount},nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t,http.StatusOK,recorder.Code)
-				requireBodyMatchAccount(t,recorder.Body,updatedAccount)
 			},
-			
+		},
+		{
+			name: "DepositorNotAllowed",
+			accountID: account.ID,
+			body: gin.H{
+				"amount": 100,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusForbidden,recorder.Code)
+			},
 		},
 		{
 			name: "InvalidID",
 			accountID: 0,
-			body: updateAccountBody{
-				Balance: 100,
+			body: gin.H{
+				"amount": 100,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateAccount(gomock.Any(),gomock.Any()).Times(0)
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t,http.StatusBadRequest,recorder.Code)
-				
 			},
 		},
 		{
-			name: "InvalidBalance",
+			name: "ZeroAmount",
 			accountID: account.ID,
-			body: updateAccountBody{},
+			body: gin.H{
+				"amount": 0,
+			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateAccount(gomock.Any(),gomock.Any()).Times(0)
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t,http.StatusBadRequest,recorder.Code)
-				
+			},
+		},
+		{
+			name: "NotFound",
+			accountID: account.ID,
+			body: gin.H{
+				"amount": 100,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Any()).Times(1).Return(db.AdjustBalanceTxResult{},sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusNotFound,recorder.Code)
 			},
 		},
 		{
 			name: "InternalError",
 			accountID: account.ID,
-			body: updateAccountBody{
-				Balance: 100,
+			body: gin.H{
+				"amount": 100,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "banker", util.BankerRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().UpdateAccount(gomock.Any(),gomock.Eq(db.UpdateAccountParams{
-					ID: account.ID,
-					Balance: 100,
-				})).Times(1).Return(db.Account{},sql.ErrConnDone)
+				store.EXPECT().AdjustBalanceTx(gomock.Any(),gomock.Any()).Times(1).Return(db.AdjustBalanceTxResult{},sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t,http.StatusInternalServerError,recorder.Code)
@@ -460,6 +491,7 @@ func TestUpdateAccount(t *testing.T){
 
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 
 			server := newTestServer(t, store)
 			recorder := httptest.NewRecorder()
@@ -482,6 +514,7 @@ func TestUpdateAccount(t *testing.T){
 func TestDeleteAccount(t *testing.T) {
 	user, _ := ramdomUser(t)
 	account := randomAccount(user.Username)
+	account.Balance = 0
 	testCases := []struct{
 		name string
 		accountID int64
@@ -493,25 +526,71 @@ func TestDeleteAccount(t *testing.T) {
 			name : "OK",
 			accountID: account.ID,
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
 				store.EXPECT().
-				DeleteAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(nil)
+				DeleteAccountTx(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(nil)
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK,recorder.Code)
 			},
 		},
+		{
+			name : "UnauthorizedUser",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
+				store.EXPECT().
+				DeleteAccountTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unathorized_user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden,recorder.Code)
+			},
+		},
+		{
+			name : "AccountNotEmpty",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
+				store.EXPECT().
+				DeleteAccountTx(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(db.ErrAccountNotEmpty)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity,recorder.Code)
+			},
+		},
+		{
+			name : "NotFound",
+			accountID: account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(db.Account{},sql.ErrNoRows)
+				store.EXPECT().
+				DeleteAccountTx(gomock.Any(),gomock.Any()).Times(0)
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound,recorder.Code)
+			},
+		},
 		{
 			name : "InvalidID",
 			accountID: 0,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().
-				DeleteAccount(gomock.Any(),gomock.Any()).Times(0)
+				DeleteAccountTx(gomock.Any(),gomock.Any()).Times(0)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest,recorder.Code)
@@ -521,11 +600,12 @@ func TestDeleteAccount(t *testing.T) {
 			name : "InternalError",
 			accountID: account.ID,
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(account,nil)
 				store.EXPECT().
-				DeleteAccount(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(sql.ErrConnDone)
+				DeleteAccountTx(gomock.Any(),gomock.Eq(account.ID)).Times(1).Return(sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError,recorder.Code)
@@ -542,6 +622,7 @@ func TestDeleteAccount(t *testing.T) {
 			//create store with controller
 			store := mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 
 			// create server
 			server := newTestServer(t,store)
@@ -587,4 +668,66 @@ func requireBodyMatchAccounts(t *testing.T, body *bytes.Buffer, accounts []db.Ac
 	err = json.Unmarshal(data, &gotAccounts)
 	require.NoError(t, err)
 	require.Equal(t, accounts, gotAccounts)
-}
\ No newline at end of file
+}
+func TestListAccountsKeyset(t *testing.T) {
+	user, _ := ramdomUser(t)
+	createdAt := time.Now().UTC().Truncate(time.Microsecond)
+	accounts := make([]db.Account, 6)
+	for i := range accounts {
+		accounts[i] = randomAccount(user.Username)
+		accounts[i].CreatedAt = createdAt.Add(time.Duration(i) * time.Second)
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+	server := newTestServer(t, store)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, "/accounts?"+query, nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// first page: one extra row is fetched to find out there is a next page
+	store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Eq(db.ListAccountsAfterParams{
+		Owner: user.Username,
+		Limit: 6,
+	})).Times(1).Return(accounts, nil)
+
+	recorder := list("page_size=5")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var firstPage listAccountsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &firstPage))
+	require.Equal(t, accounts[:5], firstPage.Accounts)
+	require.NotEmpty(t, firstPage.NextCursor)
+
+	// second page continues after the last account of the first
+	store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.ListAccountsAfterParams) ([]db.Account, error) {
+			require.True(t, arg.AfterCreatedAt.Valid)
+			require.True(t, accounts[4].CreatedAt.Equal(arg.AfterCreatedAt.Time))
+			require.Equal(t, sql.NullInt64{Int64: accounts[4].ID, Valid: true}, arg.AfterID)
+			return accounts[5:], nil
+		})
+
+	recorder = list("page_size=5&cursor=" + firstPage.NextCursor)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var secondPage listAccountsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &secondPage))
+	require.Equal(t, accounts[5:], secondPage.Accounts)
+	require.Empty(t, secondPage.NextCursor)
+
+	store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(0)
+
+	recorder = list("page_size=5&cursor=not-a-cursor")
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = list("page_id=1&page_size=5&cursor=" + firstPage.NextCursor)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
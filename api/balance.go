@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+type getAccountBalanceRequest struct {
+	// AsOf defaults to now
+	AsOf time.Time `form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type accountBalanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	AsOf      time.Time `json:"as_of"`
+	Balance   int64     `json:"balance"`
+	// SnapshotAt is the end-of-day snapshot the balance was rolled forward from, if any
+	SnapshotAt *time.Time `json:"snapshot_at,omitempty"`
+}
+
+// getAccountBalance returns the ledger balance at as_of, from the nearest end-of-day snapshot
+// and the entries created after it
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var uri accountHistoryUri
+	var req getAccountBalanceRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	now := time.Now()
+	if req.AsOf.IsZero() {
+		req.AsOf = now
+	}
+	if req.AsOf.After(now) {
+		writeError(ctx, invalidField("as_of", "future", "must not be in the future"))
+		return
+	}
+
+	account, valid := server.accessibleAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	balance, err := server.store.GetAccountBalanceAsOf(ctx, db.GetAccountBalanceAsOfParams{
+		AsOf:      req.AsOf,
+		AccountID: account.ID,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := accountBalanceResponse{
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		AsOf:      req.AsOf,
+		Balance:   balance.Balance,
+	}
+	if balance.SnapshotAt.Valid {
+		rsp.SnapshotAt = &balance.SnapshotAt.Time
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountBalance(t *testing.T) {
+	user, _ := ramdomUser(t)
+	account := randomAccount(user.Username)
+	asOf := time.Now().Add(-36 * time.Hour).UTC().Truncate(time.Second)
+	snapshotAt := asOf.Truncate(24 * time.Hour)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		asOf          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     util.DepositorRole,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.GetAccountBalanceAsOfParams{AsOf: asOf, AccountID: account.ID}
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.GetAccountBalanceAsOfRow{SnapshotAt: sql.NullTime{Time: snapshotAt, Valid: true}, Balance: 42}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountBalanceResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.ID, got.AccountID)
+				require.Equal(t, account.Currency, got.Currency)
+				require.Equal(t, int64(42), got.Balance)
+				require.True(t, asOf.Equal(got.AsOf))
+				require.NotNil(t, got.SnapshotAt)
+				require.True(t, snapshotAt.Equal(*got.SnapshotAt))
+			},
+		},
+		{
+			name:     "DefaultsToNow",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.GetAccountBalanceAsOfParams) (db.GetAccountBalanceAsOfRow, error) {
+						require.WithinDuration(t, time.Now(), arg.AsOf, time.Second)
+						return db.GetAccountBalanceAsOfRow{Balance: account.Balance}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got accountBalanceResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, account.Balance, got.Balance)
+				require.Nil(t, got.SnapshotAt)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountBalanceAsOfRow{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			role:     util.DepositorRole,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "InFuture",
+			username: user.Username,
+			role:     util.DepositorRole,
+			asOf:     time.Now().Add(time.Hour).Format(time.RFC3339),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidAsOf",
+			username: user.Username,
+			role:     util.DepositorRole,
+			asOf:     "yesterday",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			role:     util.DepositorRole,
+			asOf:     asOf.Format(time.RFC3339),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/balance", account.ID)
+			if tc.asOf != "" {
+				path += "?as_of=" + url.QueryEscape(tc.asOf)
+			}
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor marks the last row of a page. Scope ties it to the listing it came from
+type pageCursor struct {
+	Scope     string    `json:"s"`
+	CreatedAt time.Time `json:"t"`
+	ID        int64     `json:"i"`
+}
+
+// encodeCursor signs the cursor so clients can't forge positions, the token is opaque to them
+func (server *Server) encodeCursor(cursor pageCursor) (string, error) {
+	payload, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(server.signCursor(payload)), nil
+}
+
+func (server *Server) decodeCursor(token string, scope string) (pageCursor, error) {
+	var cursor pageCursor
+	encodedPayload, encodedSignature, ok := bytes.Cut([]byte(token), []byte("."))
+	if !ok {
+		return cursor, errInvalidCursor
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(string(encodedPayload))
+	if err != nil {
+		return cursor, errInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(string(encodedSignature))
+	if err != nil || !hmac.Equal(signature, server.signCursor(payload)) {
+		return cursor, errInvalidCursor
+	}
+	if err := json.Unmarshal(payload, &cursor); err != nil || cursor.Scope != scope {
+		return pageCursor{}, errInvalidCursor
+	}
+	return cursor, nil
+}
+
+func (server *Server) signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(server.config.TokenSymmeticKey))
+	mac.Write([]byte("cursor:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// pagination is either the legacy page_id/page_size form, or keyset pagination
+// continuing after an optional cursor
+type pagination struct {
+	offsetForm bool
+	pageID     int32
+	pageSize   int32
+	scope      string
+	after      *pageCursor
+}
+
+// bindPagination picks the pagination form from the query, writing a 400 response when it is invalid.
+// page_id selects the offset form, otherwise the listing is keyset paginated
+func (server *Server) bindPagination(ctx *gin.Context, scope string, pageID int32, pageSize int32, cursor string) (pagination, bool) {
+	page := pagination{pageID: pageID, pageSize: pageSize, scope: scope}
+	if _, ok := ctx.GetQuery("page_id"); ok {
+		if pageID < 1 {
+			writeError(ctx, invalidField("page_id", "min", "must be at least 1"))
+			return page, false
+		}
+		if cursor != "" {
+			writeError(ctx, invalidField("cursor", "excluded_with", "can't be used together with page_id"))
+			return page, false
+		}
+		page.offsetForm = true
+		return page, true
+	}
+	if cursor != "" {
+		after, err := server.decodeCursor(cursor, scope)
+		if err != nil {
+			writeError(ctx, newAPIError(invalidCursorCode, err.Error()))
+			return page, false
+		}
+		page.after = &after
+	}
+	return page, true
+}
+
+// limit fetches one extra row in the keyset form to tell whether there is a next page
+func (page pagination) limit() int32 {
+	if page.offsetForm {
+		return page.pageSize
+	}
+	return page.pageSize + 1
+}
+
+func (page pagination) offset() int32 {
+	if page.offsetForm {
+		return (page.pageID - 1) * page.pageSize
+	}
+	return 0
+}
+
+func (page pagination) cursorTime() sql.NullTime {
+	if page.after == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: page.after.CreatedAt, Valid: true}
+}
+
+func (page pagination) cursorID() sql.NullInt64 {
+	if page.after == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: page.after.ID, Valid: true}
+}
+
+// keysetPage trims the extra row fetched by limit and returns the cursor for the next page,
+// empty on the last page
+func keysetPage[T any](server *Server, page pagination, items []T, key func(T) (time.Time, int64)) ([]T, string, error) {
+	if len(items) <= int(page.pageSize) {
+		return items, "", nil
+	}
+	items = items[:page.pageSize]
+	createdAt, id := key(items[len(items)-1])
+	next, err := server.encodeCursor(pageCursor{Scope: page.scope, CreatedAt: createdAt, ID: id})
+	return items, next, err
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageCursor(t *testing.T) {
+	server := newTestServer(t, nil)
+	cursor := pageCursor{
+		Scope:     "accounts:alice",
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		ID:        42,
+	}
+
+	token, err := server.encodeCursor(cursor)
+	require.NoError(t, err)
+
+	decoded, err := server.decodeCursor(token, cursor.Scope)
+	require.NoError(t, err)
+	require.Equal(t, cursor.ID, decoded.ID)
+	require.True(t, cursor.CreatedAt.Equal(decoded.CreatedAt))
+
+	// a cursor from one listing can't be replayed against another
+	_, err = server.decodeCursor(token, "accounts:bob")
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	// nor signed with a different key
+	otherServer := newTestServer(t, nil)
+	_, err = otherServer.decodeCursor(token, cursor.Scope)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	forged, err := otherServer.encodeCursor(pageCursor{Scope: cursor.Scope, CreatedAt: cursor.CreatedAt, ID: 1})
+	require.NoError(t, err)
+	_, err = server.decodeCursor(forged, cursor.Scope)
+	require.ErrorIs(t, err, errInvalidCursor)
+
+	for _, token := range []string{"", "garbage", "a.b", token + "x"} {
+		_, err = server.decodeCursor(token, cursor.Scope)
+		require.ErrorIs(t, err, errInvalidCursor)
+	}
+}
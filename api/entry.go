@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+type listAccountEntriesResponse struct {
+	Entries    []db.Entry `json:"entries"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// listAccountEntries lists an account's ledger entries, newest first.
+// direction "in" keeps credits and "out" keeps debits, amounts are compared by magnitude
+func (server *Server) listAccountEntries(ctx *gin.Context) {
+	uri, filter, ok := bindHistoryRequest(ctx)
+	if !ok {
+		return
+	}
+	if _, valid := server.accessibleAccount(ctx, uri.ID); !valid {
+		return
+	}
+	page, ok := server.bindPagination(ctx, filter.scope("entries", uri.ID), filter.PageID, filter.PageSize, filter.Cursor)
+	if !ok {
+		return
+	}
+
+	arg := db.ListAccountEntriesParams{
+		AccountID:       uri.ID,
+		Credits:         filter.Direction != directionOut,
+		Debits:          filter.Direction != directionIn,
+		StartTime:       filter.startTime(),
+		EndTime:         filter.endTime(),
+		MinAmount:       filter.minAmount(),
+		MaxAmount:       filter.maxAmount(),
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	}
+	entries, err := server.store.ListAccountEntries(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, entries)
+		return
+	}
+
+	entries, next, err := keysetPage(server, page, entries, func(entry db.Entry) (time.Time, int64) {
+		return entry.CreatedAt, entry.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listAccountEntriesResponse{Entries: entries, NextCursor: next})
+}
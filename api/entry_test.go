@@ -0,0 +1,214 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountEntries(t *testing.T) {
+	user, _ := ramdomUser(t)
+	account := randomAccount(user.Username)
+	entries := []db.Entry{randomEntry(account, util.RandomMoney()), randomEntry(account, -util.RandomMoney())}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					Credits:   true,
+					Debits:    true,
+					Limit:     5,
+					Offset:    0,
+				}
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.Entry
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, entries, got)
+			},
+		},
+		{
+			name:     "CreditsOnly",
+			username: user.Username,
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}, "direction": {"in"}, "min_amount": {"10"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountEntriesParams{
+					AccountID: account.ID,
+					Credits:   true,
+					Debits:    false,
+					MinAmount: sql.NullInt64{Int64: 10, Valid: true},
+					Limit:     5,
+					Offset:    0,
+				}
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Eq(arg)).Times(1).Return(entries[:1], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(entries, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidPageSize",
+			username: user.Username,
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"1000"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidStartTime",
+			username: user.Username,
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}, "start_time": {"yesterday"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			role:     util.DepositorRole,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/entries?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountEntriesCursorFilters(t *testing.T) {
+	user, _ := ramdomUser(t)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	expectTokenNotRevoked(store)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).AnyTimes().Return(account, nil)
+	server := newTestServer(t, store)
+
+	list := func(query url.Values) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d/entries?%s", account.ID, query.Encode()), nil)
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	filter := historyFilter{Direction: directionIn, MinAmount: 10}
+	cursor, err := server.encodeCursor(pageCursor{Scope: filter.scope("entries", account.ID), CreatedAt: time.Now(), ID: 42})
+	require.NoError(t, err)
+
+	// the cursor is accepted with the filters it was issued for
+	store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{}, nil)
+	recorder := list(url.Values{"page_size": {"5"}, "direction": {"in"}, "min_amount": {"10"}, "cursor": {cursor}})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// replaying it with other filters would skip or repeat rows
+	store.EXPECT().ListAccountEntries(gomock.Any(), gomock.Any()).Times(0)
+	for _, query := range []url.Values{
+		{"page_size": {"5"}, "direction": {"out"}, "min_amount": {"10"}, "cursor": {cursor}},
+		{"page_size": {"5"}, "direction": {"in"}, "cursor": {cursor}},
+		{"page_size": {"5"}, "direction": {"in"}, "min_amount": {"10"}, "start_time": {"2024-01-01T00:00:00Z"}, "cursor": {cursor}},
+	} {
+		recorder = list(query)
+		require.Equal(t, http.StatusBadRequest, recorder.Code)
+		require.Equal(t, invalidCursorCode, requireErrorBody(t, recorder.Body).Code)
+	}
+}
+
+func randomEntry(account db.Account, amount int64) db.Entry {
+	return db.Entry{
+		ID:        util.RandomInt(1, 1000),
+		AccountID: account.ID,
+		Amount:    amount,
+	}
+}
@@ -0,0 +1,351 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+)
+
+// error codes clients can branch on, errorStatus gives the HTTP status of each
+const (
+	invalidRequestCode       = "invalid_request"
+	invalidCursorCode        = "invalid_cursor"
+	unauthenticatedCode      = "unauthenticated"
+	invalidCredentialsCode   = "invalid_credentials"
+	invalidSessionCode       = "invalid_session"
+	notOwnerCode             = "not_owner"
+	roleNotAllowedCode       = "role_not_allowed"
+	notFoundCode             = "not_found"
+	idempotencyKeyInUseCode  = "idempotency_key_in_use"
+	idempotencyKeyReusedCode = "idempotency_key_reused"
+	payloadTooLargeCode      = "payload_too_large"
+	unsupportedMediaTypeCode = "unsupported_media_type"
+	internalCode             = "internal"
+	unavailableCode          = "unavailable"
+
+	// business rules
+	insufficientFundsCode       = "insufficient_funds"
+	noExchangeRateCode          = "no_exchange_rate"
+	amountNotConvertibleCode    = "amount_not_convertible"
+	reversalExceedsTransferCode = "reversal_exceeds_transfer"
+	transferIsReversalCode      = "transfer_is_reversal"
+	transferNotSettledCode      = "transfer_not_settled"
+	transferNotAuthorizedCode   = "transfer_not_authorized"
+	holdExpiredCode             = "hold_expired"
+	accountNotEmptyCode         = "account_not_empty"
+	accountHasActivityCode      = "account_has_activity"
+	notPendingCode              = "not_pending"
+	notActiveCode               = "not_active"
+
+	// constraints of the database
+	alreadyExistsCode       = "already_exists"
+	usernameTakenCode       = "username_taken"
+	emailTakenCode          = "email_taken"
+	accountExistsCode       = "account_exists"
+	exchangeRateExistsCode  = "exchange_rate_exists"
+	duplicateMessageIDCode  = "duplicate_message_id"
+	invalidReferenceCode    = "invalid_reference"
+	constraintViolationCode = "constraint_violation"
+)
+
+// errorStatus is the catalogue of error codes
+var errorStatus = map[string]int{
+	invalidRequestCode:       http.StatusBadRequest,
+	invalidCursorCode:        http.StatusBadRequest,
+	unauthenticatedCode:      http.StatusUnauthorized,
+	invalidCredentialsCode:   http.StatusUnauthorized,
+	invalidSessionCode:       http.StatusUnauthorized,
+	notOwnerCode:             http.StatusForbidden,
+	roleNotAllowedCode:       http.StatusForbidden,
+	notFoundCode:             http.StatusNotFound,
+	idempotencyKeyInUseCode:  http.StatusConflict,
+	idempotencyKeyReusedCode: http.StatusUnprocessableEntity,
+	payloadTooLargeCode:      http.StatusRequestEntityTooLarge,
+	unsupportedMediaTypeCode: http.StatusUnsupportedMediaType,
+	internalCode:             http.StatusInternalServerError,
+	unavailableCode:          http.StatusServiceUnavailable,
+
+	insufficientFundsCode:       http.StatusUnprocessableEntity,
+	noExchangeRateCode:          http.StatusUnprocessableEntity,
+	amountNotConvertibleCode:    http.StatusUnprocessableEntity,
+	reversalExceedsTransferCode: http.StatusUnprocessableEntity,
+	transferIsReversalCode:      http.StatusUnprocessableEntity,
+	transferNotSettledCode:      http.StatusUnprocessableEntity,
+	transferNotAuthorizedCode:   http.StatusUnprocessableEntity,
+	holdExpiredCode:             http.StatusUnprocessableEntity,
+	accountNotEmptyCode:         http.StatusUnprocessableEntity,
+	accountHasActivityCode:      http.StatusUnprocessableEntity,
+	notPendingCode:              http.StatusUnprocessableEntity,
+	notActiveCode:               http.StatusUnprocessableEntity,
+
+	alreadyExistsCode:       http.StatusConflict,
+	usernameTakenCode:       http.StatusConflict,
+	emailTakenCode:          http.StatusConflict,
+	accountExistsCode:       http.StatusConflict,
+	exchangeRateExistsCode:  http.StatusConflict,
+	duplicateMessageIDCode:  http.StatusConflict,
+	invalidReferenceCode:    http.StatusUnprocessableEntity,
+	constraintViolationCode: http.StatusUnprocessableEntity,
+}
+
+// storeErrors maps the errors of the store to the catalogue, their messages are safe to show
+var storeErrors = []struct {
+	err  error
+	code string
+}{
+	{sql.ErrNoRows, notFoundCode},
+	{db.ErrIdempotencyKeyInUse, idempotencyKeyInUseCode},
+	{db.ErrInsufficientFunds, insufficientFundsCode},
+	{db.ErrNoExchangeRate, noExchangeRateCode},
+	{db.ErrAmountNotConvertible, amountNotConvertibleCode},
+	{db.ErrReversalExceedsTransfer, reversalExceedsTransferCode},
+	{db.ErrTransferIsReversal, transferIsReversalCode},
+	{db.ErrTransferNotSettled, transferNotSettledCode},
+	{db.ErrTransferNotAuthorized, transferNotAuthorizedCode},
+	{db.ErrHoldExpired, holdExpiredCode},
+	{db.ErrAccountNotEmpty, accountNotEmptyCode},
+	{db.ErrAccountHasActivity, accountHasActivityCode},
+}
+
+// constraintErrors describes the violations of named Postgres constraints
+var constraintErrors = map[string]struct {
+	code    string
+	message string
+}{
+	"users_pkey":                   {usernameTakenCode, "username is already taken"},
+	"users_email_key":              {emailTakenCode, "email is already registered"},
+	"owner_currency_key":           {accountExistsCode, "the user already has an account in this currency"},
+	"accounts_owner_fkey":          {invalidReferenceCode, "the account owner doesn't exist"},
+	"overdraft_limit_non_negative": {constraintViolationCode, "overdraft limit can't be negative"},
+	"rate_positive":                {constraintViolationCode, "rate must be greater than zero"},
+	"exchange_rates_from_currency_to_currency_effective_from_idx": {exchangeRateExistsCode, "a rate for this currency pair is already effective from that time"},
+	"payment_batches_owner_message_id_idx":                        {duplicateMessageIDCode, "the message id was already uploaded"},
+}
+
+// pqErrors describes the Postgres errors of constraints not listed in constraintErrors
+var pqErrors = map[string]struct {
+	code    string
+	message string
+}{
+	"unique_violation":           {alreadyExistsCode, "the resource already exists"},
+	"foreign_key_violation":      {invalidReferenceCode, "a referenced resource doesn't exist"},
+	"check_violation":            {constraintViolationCode, "a value is out of its allowed range"},
+	"numeric_value_out_of_range": {constraintViolationCode, "a value is out of its allowed range"},
+}
+
+// apiError is an error the API answers with, its code is one of the catalogue
+type apiError struct {
+	Code    string
+	Message string
+	Details []fieldError
+}
+
+func (err *apiError) Error() string {
+	return err.Message
+}
+
+// status is the HTTP status of the error code
+func (err *apiError) status() int {
+	if status, ok := errorStatus[err.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// fieldError explains why one field of the request is invalid
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorBody is the body of every error response
+type errorBody struct {
+	Code    string       `json:"code"`
+	Message string       `json:"message"`
+	Details []fieldError `json:"details,omitempty"`
+	// RequestID is the X-Request-ID of the request, to quote when reporting the error
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func newAPIError(code string, message string) *apiError {
+	return &apiError{Code: code, Message: message}
+}
+
+func apiErrorf(code string, format string, args ...interface{}) *apiError {
+	return newAPIError(code, fmt.Sprintf(format, args...))
+}
+
+// invalidField describes a field the handler rejected after binding, code names the rule it broke
+func invalidField(field string, code string, message string) *apiError {
+	return &apiError{
+		Code:    invalidRequestCode,
+		Message: "the request has invalid fields",
+		Details: []fieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+// writeError answers with the catalogue entry for err and aborts the request. The error itself is kept
+// on the context for logging, the client only sees its message when it is part of the catalogue
+func writeError(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	apiErr := toAPIError(err)
+	ctx.AbortWithStatusJSON(apiErr.status(), errorBody{
+		Code:      apiErr.Code,
+		Message:   apiErr.Message,
+		Details:   apiErr.Details,
+		RequestID: ctx.GetString(requestIDKey),
+	})
+}
+
+// toAPIError finds the catalogue entry for an error, anything unknown is an internal error
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, storeErr := range storeErrors {
+		if errors.Is(err, storeErr.err) {
+			if storeErr.err == sql.ErrNoRows {
+				return newAPIError(notFoundCode, "resource not found")
+			}
+			return newAPIError(storeErr.code, storeErr.err.Error())
+		}
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if constraint, ok := constraintErrors[pqErr.Constraint]; ok {
+			return newAPIError(constraint.code, constraint.message)
+		}
+		if pqError, ok := pqErrors[pqErr.Code.Name()]; ok {
+			return newAPIError(pqError.code, pqError.message)
+		}
+	}
+	return newAPIError(internalCode, "internal server error")
+}
+
+// invalidRequest describes an error binding the request, with the invalid fields when the validator found them
+func invalidRequest(err error) *apiError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		apiErr := newAPIError(invalidRequestCode, "the request has invalid fields")
+		for _, fieldErr := range validationErrs {
+			apiErr.Details = append(apiErr.Details, fieldError{
+				Field:   fieldPath(fieldErr),
+				Code:    fieldErr.Tag(),
+				Message: validationMessage(fieldErr),
+			})
+		}
+		return apiErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		apiErr := newAPIError(invalidRequestCode, "the request has invalid fields")
+		apiErr.Details = []fieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: fmt.Sprintf("must be a %s", jsonTypeName(typeErr.Type)),
+		}}
+		return apiErr
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return newAPIError(invalidRequestCode, "the request body is not valid JSON")
+	}
+	return apiErrorf(invalidRequestCode, "invalid request: %s", err)
+}
+
+// fieldPath drops the struct name from the namespace of a field error, leaving rates[0].to_currency
+func fieldPath(fieldErr validator.FieldError) string {
+	_, path, found := strings.Cut(fieldErr.Namespace(), ".")
+	if !found {
+		return fieldErr.Field()
+	}
+	return path
+}
+
+// validationMessage explains a failed binding tag, in the words of the openapi constraints
+func validationMessage(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	kind := fieldErr.Kind()
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min", "max":
+		bound := "at least"
+		if fieldErr.Tag() == "max" {
+			bound = "at most"
+		}
+		switch kind {
+		case reflect.String:
+			return fmt.Sprintf("must be %s %s characters long", bound, param)
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return fmt.Sprintf("must have %s %s items", bound, param)
+		}
+		return fmt.Sprintf("must be %s %s", bound, param)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of %s", strings.Join(strings.Fields(param), ", "))
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must only contain letters and digits"
+	case "nefield":
+		return fmt.Sprintf("must differ from %s", param)
+	case "currency":
+		return fmt.Sprintf("must be one of %s", strings.Join([]string{util.USD, util.CAD, util.EUR}, ", "))
+	case "role":
+		return fmt.Sprintf("must be one of %s", strings.Join([]string{util.DepositorRole, util.BankerRole, util.AdminRole}, ", "))
+	case "recurrence":
+		return "must be a recurrence rule like FREQ=MONTHLY;INTERVAL=1"
+	}
+	return "is invalid"
+}
+
+// jsonTypeName names a Go type the way a JSON client would
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		if t == timeType {
+			return "RFC 3339 timestamp string"
+		}
+		return "object"
+	}
+	return "string"
+}
+
+// bindingFieldName names validation errors after the json, form or uri key of the field rather than the Go name
+func bindingFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToAPIError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		code    string
+		status  int
+		message string
+	}{
+		{
+			name:    "NoRows",
+			err:     sql.ErrNoRows,
+			code:    notFoundCode,
+			status:  http.StatusNotFound,
+			message: "resource not found",
+		},
+		{
+			name:    "WrappedStoreError",
+			err:     fmt.Errorf("transfer tx: %w", db.ErrInsufficientFunds),
+			code:    insufficientFundsCode,
+			status:  http.StatusUnprocessableEntity,
+			message: db.ErrInsufficientFunds.Error(),
+		},
+		{
+			name:    "AmountNotConvertible",
+			err:     fmt.Errorf("%w: %w", db.ErrAmountNotConvertible, util.ErrAmountTooSmall),
+			code:    amountNotConvertibleCode,
+			status:  http.StatusUnprocessableEntity,
+			message: db.ErrAmountNotConvertible.Error(),
+		},
+		{
+			name:    "NamedConstraint",
+			err:     &pq.Error{Code: "23505", Constraint: "users_email_key"},
+			code:    emailTakenCode,
+			status:  http.StatusConflict,
+			message: "email is already registered",
+		},
+		{
+			name:    "UnnamedConstraint",
+			err:     &pq.Error{Code: "23503", Constraint: "some_fkey"},
+			code:    invalidReferenceCode,
+			status:  http.StatusUnprocessableEntity,
+			message: "a referenced resource doesn't exist",
+		},
+		{
+			name:    "CheckViolation",
+			err:     &pq.Error{Code: "23514", Constraint: "some_check"},
+			code:    constraintViolationCode,
+			status:  http.StatusUnprocessableEntity,
+			message: "a value is out of its allowed range",
+		},
+		{
+			name:    "NotOwner",
+			err:     apiErrorf(notOwnerCode, "account doesn't belong to the authenticated user"),
+			code:    notOwnerCode,
+			status:  http.StatusForbidden,
+			message: "account doesn't belong to the authenticated user",
+		},
+		{
+			name:    "APIError",
+			err:     apiErrorf(notPendingCode, "scheduled transfer [%d] is no longer pending", 1),
+			code:    notPendingCode,
+			status:  http.StatusUnprocessableEntity,
+			message: "scheduled transfer [1] is no longer pending",
+		},
+		{
+			name:    "Unknown",
+			err:     errors.New("pq: password authentication failed for user root"),
+			code:    internalCode,
+			status:  http.StatusInternalServerError,
+			message: "internal server error",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			apiErr := toAPIError(tc.err)
+			require.Equal(t, tc.code, apiErr.Code)
+			require.Equal(t, tc.status, apiErr.status())
+			require.Equal(t, tc.message, apiErr.Message)
+		})
+	}
+}
+
+func TestErrorCodesHaveStatus(t *testing.T) {
+	for _, storeErr := range storeErrors {
+		require.Contains(t, errorStatus, storeErr.code)
+	}
+	for _, constraint := range constraintErrors {
+		require.Contains(t, errorStatus, constraint.code)
+	}
+	for _, pqError := range pqErrors {
+		require.Contains(t, errorStatus, pqError.code)
+	}
+}
+
+func TestErrorBody(t *testing.T) {
+	testCases := []struct {
+		name          string
+		body          string
+		requestID     string
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "FieldDetails",
+			body:      `{"username":"user-1","password":"123","full_name":"User"}`,
+			requestID: "req-1",
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, "req-1", recorder.Header().Get(requestIDHeader))
+
+				body := requireErrorBody(t, recorder.Body)
+				require.Equal(t, invalidRequestCode, body.Code)
+				require.Equal(t, "req-1", body.RequestID)
+				require.ElementsMatch(t, []fieldError{
+					{Field: "username", Code: "alphanum", Message: "must only contain letters and digits"},
+					{Field: "password", Code: "min", Message: "must be at least 6 characters long"},
+					{Field: "email", Code: "required", Message: "is required"},
+				}, body.Details)
+			},
+		},
+		{
+			name: "TypeMismatch",
+			body: `{"username":1}`,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				body := requireErrorBody(t, recorder.Body)
+				require.Equal(t, invalidRequestCode, body.Code)
+				require.Equal(t, []fieldError{{Field: "username", Code: "type", Message: "must be a string"}}, body.Details)
+				require.NotEmpty(t, body.RequestID)
+				require.Equal(t, body.RequestID, recorder.Header().Get(requestIDHeader))
+			},
+		},
+		{
+			name: "MalformedJSON",
+			body: `{"username":`,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				body := requireErrorBody(t, recorder.Body)
+				require.Equal(t, invalidRequestCode, body.Code)
+				require.Equal(t, "the request body is not valid JSON", body.Message)
+				require.Empty(t, body.Details)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(requestIDHeader, tc.requestID)
+			}
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireErrorBody(t *testing.T, body io.Reader) errorBody {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotBody errorBody
+	err = json.Unmarshal(data, &gotBody)
+	require.NoError(t, err)
+	return gotBody
+}
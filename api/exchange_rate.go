@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+)
+
+type exchangeRateRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+	// Rate is a decimal string, units of to_currency per unit of from_currency
+	Rate string `json:"rate" binding:"required"`
+	// EffectiveFrom defaults to now
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+type createExchangeRatesRequest struct {
+	Rates []exchangeRateRequest `json:"rates" binding:"required,min=1,max=1000,dive"`
+}
+
+// createExchangeRates loads a batch of exchange rates, it is only routed for admins
+func (server *Server) createExchangeRates(ctx *gin.Context) {
+	var req createExchangeRatesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	now := time.Now()
+	arg := make([]db.CreateExchangeRateParams, len(req.Rates))
+	for i, rate := range req.Rates {
+		if _, err := util.ParseRate(rate.Rate); err != nil {
+			writeError(ctx, invalidField(fmt.Sprintf("rates[%d].rate", i), "rate", err.Error()))
+			return
+		}
+		effectiveFrom := rate.EffectiveFrom
+		if effectiveFrom.IsZero() {
+			effectiveFrom = now
+		}
+		arg[i] = db.CreateExchangeRateParams{
+			FromCurrency:  rate.FromCurrency,
+			ToCurrency:    rate.ToCurrency,
+			Rate:          rate.Rate,
+			EffectiveFrom: effectiveFrom,
+		}
+	}
+
+	rates, err := server.store.CreateExchangeRatesTx(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, rates)
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateExchangeRates(t *testing.T) {
+	effectiveFrom := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	rate := db.ExchangeRate{
+		ID:            util.RandomInt(1, 1000),
+		FromCurrency:  util.USD,
+		ToCurrency:    util.EUR,
+		Rate:          "0.9250000000",
+		EffectiveFrom: effectiveFrom,
+	}
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency":  util.USD,
+				"to_currency":    util.EUR,
+				"rate":           "0.925",
+				"effective_from": effectiveFrom,
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := []db.CreateExchangeRateParams{{
+					FromCurrency:  util.USD,
+					ToCurrency:    util.EUR,
+					Rate:          "0.925",
+					EffectiveFrom: effectiveFrom,
+				}}
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return([]db.ExchangeRate{rate}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var rates []db.ExchangeRate
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rates))
+				require.Len(t, rates, 1)
+				require.Equal(t, rate.ID, rates[0].ID)
+			},
+		},
+		{
+			name: "DefaultEffectiveFrom",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.925",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg []db.CreateExchangeRateParams) ([]db.ExchangeRate, error) {
+						require.Len(t, arg, 1)
+						require.WithinDuration(t, time.Now(), arg[0].EffectiveFrom, time.Second)
+						return []db.ExchangeRate{rate}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Forbidden",
+			role: util.BankerRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.925",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "SameCurrency",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.USD,
+				"rate":          "1",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRate",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "-0.925",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "FractionRate",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "1/3",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorBody(t, recorder.Body)
+				require.Len(t, body.Details, 1)
+				require.Equal(t, "rates[0].rate", body.Details[0].Field)
+			},
+		},
+		{
+			name: "ExponentRate",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "9.25e-1",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorBody(t, recorder.Body)
+				require.Len(t, body.Details, 1)
+				require.Equal(t, "rates[0].rate", body.Details[0].Field)
+			},
+		},
+		{
+			name: "TooPreciseRate",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.92500000001",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				body := requireErrorBody(t, recorder.Body)
+				require.Len(t, body.Details, 1)
+				require.Equal(t, "rates[0].rate", body.Details[0].Field)
+			},
+		},
+		{
+			name: "EmptyBatch",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DuplicateRate",
+			role: util.AdminRole,
+			body: gin.H{"rates": []gin.H{{
+				"from_currency": util.USD,
+				"to_currency":   util.EUR,
+				"rate":          "0.925",
+			}}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateExchangeRatesTx(gomock.Any(), gomock.Any()).Times(1).Return(nil, &pq.Error{Code: "23505"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/admin/exchange_rates", bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", tc.role, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
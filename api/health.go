@@ -0,0 +1,50 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readinessResponse is the body of /readyz when the server can take requests
+type readinessResponse struct {
+	Status string `json:"status"`
+	// MigrationVersion is the version of the last migration applied to the database
+	MigrationVersion int64 `json:"migration_version"`
+}
+
+// checkLiveness answers as long as the process serves requests, a failure means it should be restarted
+func (server *Server) checkLiveness(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, statusResponse{
+		Status:  "ok",
+		Message: "server is alive",
+	})
+}
+
+// checkReadiness answers whether the server should get traffic: the database must be reachable and
+// migrated cleanly, and the server must not be shutting down
+func (server *Server) checkReadiness(ctx *gin.Context) {
+	if server.shuttingDown.Load() {
+		writeError(ctx, newAPIError(unavailableCode, "server is shutting down"))
+		return
+	}
+	if err := server.store.Ping(ctx); err != nil {
+		_ = ctx.Error(err)
+		writeError(ctx, newAPIError(unavailableCode, "database is unreachable"))
+		return
+	}
+	version, err := server.store.GetMigrationVersion(ctx)
+	if err != nil {
+		_ = ctx.Error(err)
+		writeError(ctx, newAPIError(unavailableCode, "cannot read the migration version"))
+		return
+	}
+	if version.Dirty {
+		writeError(ctx, apiErrorf(unavailableCode, "migration %d failed halfway", version.Version))
+		return
+	}
+	ctx.JSON(http.StatusOK, readinessResponse{
+		Status:           "ok",
+		MigrationVersion: version.Version,
+	})
+}
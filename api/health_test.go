@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLiveness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	// liveness doesn't depend on the database
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/livez", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestCheckReadiness(t *testing.T) {
+	testCases := []struct {
+		name          string
+		shuttingDown  bool
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetMigrationVersion(gomock.Any()).Times(1).Return(db.MigrationVersion{Version: 18}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got readinessResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, readinessResponse{Status: "ok", MigrationVersion: 18}, got)
+			},
+		},
+		{
+			name: "DatabaseUnreachable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+				store.EXPECT().GetMigrationVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				body := requireErrorBody(t, recorder.Body)
+				require.Equal(t, unavailableCode, body.Code)
+				require.NotContains(t, body.Message, "connection refused")
+			},
+		},
+		{
+			name: "DirtyMigration",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetMigrationVersion(gomock.Any()).Times(1).Return(db.MigrationVersion{Version: 18, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, unavailableCode, requireErrorBody(t, recorder.Body).Code)
+			},
+		},
+		{
+			name:         "ShuttingDown",
+			shuttingDown: true,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, unavailableCode, requireErrorBody(t, recorder.Body).Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.shuttingDown.Store(tc.shuttingDown)
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestStartDrainsRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server.router.GET("/slow", func(ctx *gin.Context) {
+		close(started)
+		<-release
+		ctx.Status(http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Start(ctx, address)
+	}()
+
+	require.Eventually(t, func() bool {
+		response, err := http.Get("http://" + address + "/livez")
+		if err != nil {
+			return false
+		}
+		response.Body.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	responses := make(chan int, 1)
+	go func() {
+		response, err := http.Get("http://" + address + "/slow")
+		if err != nil {
+			responses <- 0
+			return
+		}
+		response.Body.Close()
+		responses <- response.StatusCode
+	}()
+
+	<-started
+	stop()
+	// the request in flight holds the server open
+	select {
+	case err := <-stopped:
+		t.Fatalf("server stopped before the request finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.True(t, server.shuttingDown.Load())
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-responses)
+	require.NoError(t, <-stopped)
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+)
+
+const (
+	directionIn  = "in"
+	directionOut = "out"
+)
+
+type accountHistoryUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// historyFilter holds the query parameters shared by the account transfer and entry listings.
+// Zero values mean no filter, the time range is half open: [start_time, end_time).
+// page_id selects the legacy offset form, without it the listing is keyset paginated by cursor
+type historyFilter struct {
+	PageID    int32     `form:"page_id" binding:"omitempty,min=1"`
+	PageSize  int32     `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor    string    `form:"cursor"`
+	StartTime time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	Direction string    `form:"direction" binding:"omitempty,oneof=in out"`
+	MinAmount int64     `form:"min_amount" binding:"omitempty,min=1"`
+	MaxAmount int64     `form:"max_amount" binding:"omitempty,min=1"`
+}
+
+func (filter historyFilter) validate() error {
+	if !filter.StartTime.IsZero() && !filter.EndTime.IsZero() && !filter.EndTime.After(filter.StartTime) {
+		return invalidField("end_time", "gtfield", "must be after start_time")
+	}
+	if filter.MinAmount != 0 && filter.MaxAmount != 0 && filter.MaxAmount < filter.MinAmount {
+		return invalidField("max_amount", "gtefield", "must not be less than min_amount")
+	}
+	return nil
+}
+
+func (filter historyFilter) startTime() sql.NullTime {
+	return sql.NullTime{Time: filter.StartTime, Valid: !filter.StartTime.IsZero()}
+}
+
+func (filter historyFilter) endTime() sql.NullTime {
+	return sql.NullTime{Time: filter.EndTime, Valid: !filter.EndTime.IsZero()}
+}
+
+func (filter historyFilter) minAmount() sql.NullInt64 {
+	return sql.NullInt64{Int64: filter.MinAmount, Valid: filter.MinAmount != 0}
+}
+
+func (filter historyFilter) maxAmount() sql.NullInt64 {
+	return sql.NullInt64{Int64: filter.MaxAmount, Valid: filter.MaxAmount != 0}
+}
+
+// scope ties a cursor to the account listing and the filters it was issued for,
+// so it can't be replayed with different filters to skip or repeat rows
+func (filter historyFilter) scope(listing string, accountID int64) string {
+	return fmt.Sprintf("%s:%d?start_time=%s&end_time=%s&direction=%s&min_amount=%d&max_amount=%d",
+		listing, accountID,
+		filter.StartTime.UTC().Format(time.RFC3339Nano), filter.EndTime.UTC().Format(time.RFC3339Nano),
+		filter.Direction, filter.MinAmount, filter.MaxAmount)
+}
+
+// bindHistoryRequest binds the account id and filter, writing a 400 response when they are invalid
+func bindHistoryRequest(ctx *gin.Context) (accountHistoryUri, historyFilter, bool) {
+	var uri accountHistoryUri
+	var filter historyFilter
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return uri, filter, false
+	}
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return uri, filter, false
+	}
+	if err := filter.validate(); err != nil {
+		writeError(ctx, err)
+		return uri, filter, false
+	}
+	return uri, filter, true
+}
+
+// accessibleAccount gets an account the authenticated user owns, or any account for bankers and admins
+func (server *Server) accessibleAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, valid := server.loadAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx, newAPIError(notOwnerCode, "account doesn`t belong to the authenticated user"))
+		return account, false
+	}
+	return account, true
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// idempotencyKey reads the Idempotency-Key header of the request.
+// It replays the stored response and returns false when the key was already used,
+// otherwise it returns the params to hand to the store (nil when no key was sent).
+func (server *Server) idempotencyKey(ctx *gin.Context, req interface{}) (*db.IdempotencyKeyParams, bool) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return nil, true
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		writeError(ctx, invalidField(idempotencyKeyHeader, "max", fmt.Sprintf("must be at most %d characters long", maxIdempotencyKeyLength)))
+		return nil, false
+	}
+
+	hash, err := requestHash(req)
+	if err != nil {
+		writeError(ctx, err)
+		return nil, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	params := &db.IdempotencyKeyParams{
+		Username:    authPayload.Username,
+		Key:         key,
+		RequestHash: hash,
+		TTL:         server.config.IdempotencyKeyTTL,
+	}
+
+	if server.replayIdempotentResponse(ctx, params) {
+		return nil, false
+	}
+	return params, true
+}
+
+// replayIdempotentResponse writes the response stored for the key, if any, and reports whether it did.
+// Reusing a key with a different request body is rejected.
+func (server *Server) replayIdempotentResponse(ctx *gin.Context, params *db.IdempotencyKeyParams) bool {
+	stored, err := server.store.GetIdempotencyKey(ctx, db.GetIdempotencyKeyParams{
+		Username: params.Username,
+		Key:      params.Key,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false
+		}
+		writeError(ctx, err)
+		return true
+	}
+
+	if stored.RequestHash != params.RequestHash {
+		writeError(ctx, newAPIError(idempotencyKeyReusedCode, "idempotency key was already used with a different request"))
+		return true
+	}
+	if stored.ResponseStatus == 0 {
+		writeError(ctx, newAPIError(idempotencyKeyInUseCode, "a request with this idempotency key is still in progress"))
+		return true
+	}
+
+	ctx.Header(idempotentReplayedHeader, "true")
+	ctx.Data(int(stored.ResponseStatus), "application/json; charset=utf-8", stored.ResponseBody)
+	return true
+}
+
+// requestHash fingerprints a bound request so a reused key can be matched against its original body
+func requestHash(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"log/slog"
 	"os"
 	"testing"
 	"time"
@@ -16,9 +17,13 @@ import (
 func newTestServer (t *testing.T, store db.Store) *Server{
 	config := util.Config{
 		TokenSymmeticKey: util.RandomString(32),
+		ReportSigningKey: util.RandomString(32),
 		AccessTokenDuration: time.Minute,
+		RefreshTokenDuration: time.Hour,
+		IdempotencyKeyTTL: time.Hour,
+		HoldDuration: 24*time.Hour,
 	}
-	server, err := NewServer(config,store)
+	server, err := NewServer(config,store,slog.New(slog.DiscardHandler))
 	require.NoError(t,err)
 	return server
 }
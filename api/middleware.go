@@ -1,50 +1,187 @@
 package api
 
 import (
-	"errors"
-	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/logging"
+	"github.com/joekings2k/gobank/metrics"
 	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	authorizationHeaderKey 	= "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	requestIDHeader         = "X-Request-ID"
+	requestIDKey            = "request_id"
+	maxRequestIDLength      = 128
+	tracerName              = "github.com/joekings2k/gobank/api"
 )
 
+// requestIDMiddleware tags the request with the caller's X-Request-ID, or a fresh one,
+// and echoes it back so error bodies and logs can be matched to the request
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeader, requestID)
+		ctx.Next()
+	}
+}
+
+// tracingMiddleware opens the span of the request, continuing the trace of the caller's traceparent header.
+// Handlers pass ctx on to the store, which finds the span through the request context
+func tracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
+	return func(ctx *gin.Context) {
+		reqCtx := otel.GetTextMapPropagator().Extract(ctx.Request.Context(), propagation.HeaderCarrier(ctx.Request.Header))
+		name := ctx.Request.Method
+		attributes := []attribute.KeyValue{
+			semconv.HTTPRequestMethodKey.String(ctx.Request.Method),
+			semconv.URLPath(ctx.Request.URL.Path),
+			attribute.String("gobank.request_id", ctx.GetString(requestIDKey)),
+		}
+		if route := ctx.FullPath(); route != "" {
+			name += " " + route
+			attributes = append(attributes, semconv.HTTPRoute(route))
+		}
+		reqCtx, span := tracer.Start(reqCtx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+		defer span.End()
+
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+			if err := ctx.Errors.Last(); err != nil {
+				span.RecordError(err.Err)
+			}
+		}
+	}
+}
+
+// loggingMiddleware puts a logger tagged with the request and trace ids in the request context,
+// then logs the request once answered with who made it, its status and latency
+func loggingMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		reqLogger := logger.With(slog.String("request_id", ctx.GetString(requestIDKey)))
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			reqLogger = reqLogger.With(slog.String("trace_id", spanContext.TraceID().String()))
+		}
+		ctx.Request = ctx.Request.WithContext(logging.WithLogger(ctx.Request.Context(), reqLogger))
+
+		ctx.Next()
 
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+		status := ctx.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", ctx.Request.Method),
+			slog.String("route", ctx.FullPath()),
+			slog.String("path", ctx.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", logging.Milliseconds(time.Since(start))),
+			slog.Int("response_bytes", ctx.Writer.Size()),
+			slog.String("client_ip", ctx.ClientIP()),
+		}
+		if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+			attrs = append(attrs, slog.String("username", payload.(*token.Payload).Username))
+		}
+		if err := ctx.Errors.Last(); err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		reqLogger.LogAttrs(ctx.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// metricsMiddleware records the latency and status of every request under the route it matched
+func metricsMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+		metrics.ObserveHTTPRequest(ctx.Request.Method, ctx.FullPath(), ctx.Writer.Status(), time.Since(start))
+	}
+}
+
+
+// authMiddleware authenticates the request and, when accessibleRoles is not empty,
+// only lets through tokens carrying one of those roles
+func authMiddleware(tokenMaker token.Maker, store db.Store, accessibleRoles ...string) gin.HandlerFunc {
+	tracer := otel.Tracer(tracerName)
 	return func(ctx *gin.Context) {
+		// the span only covers authentication, gin runs the handler once the middleware returns
+		reqCtx, span := tracer.Start(ctx.Request.Context(), "authMiddleware")
+		defer func() {
+			if err := ctx.Errors.Last(); ctx.IsAborted() && err != nil {
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			writeError(ctx, newAPIError(unauthenticatedCode, "authorization header is not provided"))
 			return 
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
-			err := errors.New("invalid authorization format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			writeError(ctx, newAPIError(unauthenticatedCode, "invalid authorization format"))
 			return 
 		}
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			writeError(ctx, apiErrorf(unauthenticatedCode, "unsupported authorization type %s", authorizationType))
 			return 
 		}
 		accessToken  := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken)
+		payload, err := tokenMaker.VerifyToken(accessToken, token.AccessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			writeError(ctx, newAPIError(unauthenticatedCode, err.Error()))
 			return 
 		}
+		revoked, err := store.IsTokenRevoked(reqCtx, db.IsTokenRevokedParams{
+			ID: payload.ID,
+			Username: payload.Username,
+			IssuedAt: payload.IssuedAt,
+		})
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		if revoked {
+			writeError(ctx, newAPIError(unauthenticatedCode, token.ErrRevokedToken.Error()))
+			return
+		}
+		if len(accessibleRoles) > 0 && !util.HasRole(payload.Role, accessibleRoles...) {
+			writeError(ctx, apiErrorf(roleNotAllowedCode, "role %s is not allowed to access this resource", payload.Role))
+			return
+		}
 		ctx.Set(authorizationPayloadKey,payload)
-		ctx.Next()
 	}
-}
\ No newline at end of file
+}
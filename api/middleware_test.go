@@ -1,6 +1,10 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,8 +12,19 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/logging"
 	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
 )
 func addAuthorization (
 	t *testing.T,
@@ -17,28 +32,94 @@ func addAuthorization (
 	tokenMaker token.Maker,
 	authorizationType string,
 	username string,
+	role string,
 	duration time.Duration){
-		token,err := tokenMaker.CreateToken(username,duration)
+		token, payload, err := tokenMaker.CreateToken(username,role,token.AccessToken,duration)
 		require.NoError(t,err)
+		require.NotEmpty(t,payload)
 
 		authorizationHeader := fmt.Sprintf("%s %s", authorizationType, token)
 		request.Header.Set(authorizationHeaderKey, authorizationHeader)
 	}
+
+// expectTokenNotRevoked stubs the revocation lookup done by authMiddleware
+func expectTokenNotRevoked(store *mockdb.MockStore) {
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	testCases := []struct {
 		name string
+		accessibleRoles []string
 		setupAuth 		func (t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs 		func(store *mockdb.MockStore)
 		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
 	}{
 		{
 			name:"Ok",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name:"AllowedRole",
+			accessibleRoles: []string{util.BankerRole, util.AdminRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:"ForbiddenRole",
+			accessibleRoles: []string{util.AdminRole},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:"RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "user", util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:"RefreshToken",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				refreshToken, _, err := tokenMaker.CreateToken("user", util.DepositorRole, token.RefreshToken, time.Minute)
+				require.NoError(t, err)
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, refreshToken))
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, token.ErrWrongTokenType.Error(), requireErrorBody(t, recorder.Body).Message)
+			},
+		},
 		{
 			name:"NoAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
@@ -50,7 +131,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:"UnsupportedAuthorization",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "unsupported", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "unsupported", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -59,7 +140,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:"InvalidAuthorizationFormat",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "user", time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -68,7 +149,7 @@ func TestAuthMiddleware(t *testing.T) {
 		{
 			name:"ExpiredToken",
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, "", "user", -time.Minute)
+				addAuthorization(t, request, tokenMaker, "", "user", util.DepositorRole, -time.Minute)
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
@@ -79,11 +160,18 @@ func TestAuthMiddleware(t *testing.T) {
 	for i := range testCases {
 		tc := testCases[i]
 		t.Run(tc.name, func(t *testing.T) {
-			server := newTestServer(t, nil)
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+			server := newTestServer(t, store)
 
 			authPath  := "/auth"
 			server.router.GET(
-				authPath,authMiddleware(server.tokenMaker),
+				authPath,authMiddleware(server.tokenMaker, server.store, tc.accessibleRoles...),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -99,4 +187,115 @@ func TestAuthMiddleware(t *testing.T) {
 
 		})
 	}
-}
\ No newline at end of file
+}
+func TestMetricsMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	// ids in the path are recorded under the route pattern
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/accounts/42", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `gobank_http_request_duration_seconds_count{method="GET",route="/accounts/:id",status="401"}`)
+	require.NotContains(t, recorder.Body.String(), `route="/accounts/42"`)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	defer otel.SetTracerProvider(previous)
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previousPropagator)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	account := randomAccount(util.RandomOwner())
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(false, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			// the store is called within the trace of the request
+			require.Equal(t, traceID, trace.SpanContextFromContext(ctx).TraceID())
+			return account, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, util.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	spans := spanRecorder.Ended()
+	require.Len(t, spans, 2)
+	auth, req := spans[0], spans[1]
+	require.Equal(t, "authMiddleware", auth.Name())
+	require.Equal(t, "GET /accounts/:id", req.Name())
+	require.Equal(t, traceID, req.SpanContext().TraceID())
+	require.Equal(t, "00f067aa0ba902b7", req.Parent().SpanID().String())
+	require.Equal(t, req.SpanContext().SpanID(), auth.Parent().SpanID())
+	require.Contains(t, req.Attributes(), semconv.HTTPResponseStatusCode(http.StatusOK))
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := randomAccount(util.RandomOwner())
+	store := mockdb.NewMockStore(ctrl)
+	expectTokenNotRevoked(store)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrConnDone)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info")
+	require.NoError(t, err)
+	config := util.Config{
+		TokenSymmeticKey:    util.RandomString(32),
+		AccessTokenDuration: time.Minute,
+	}
+	server, err := NewServer(config, store, logger)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeader, "req-1")
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, account.Owner, util.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+	// the client only gets the catalogue message, the log gets the error
+	require.NotContains(t, recorder.Body.String(), sql.ErrConnDone.Error())
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	require.Equal(t, "ERROR", line["level"])
+	require.Equal(t, "request", line["msg"])
+	require.Equal(t, "req-1", line["request_id"])
+	require.Equal(t, account.Owner, line["username"])
+	require.Equal(t, http.MethodGet, line["method"])
+	require.Equal(t, "/accounts/:id", line["route"])
+	require.Equal(t, float64(http.StatusInternalServerError), line["status"])
+	require.Equal(t, sql.ErrConnDone.Error(), line["error"])
+	require.Contains(t, line, "latency_ms")
+	require.NotContains(t, buf.String(), request.Header.Get(authorizationHeaderKey))
+}
@@ -0,0 +1,584 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/joekings2k/gobank/util"
+	swaggerFiles "github.com/swaggo/files/v2"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	// bearerAuth names the security scheme of routes behind authMiddleware
+	bearerAuth = "bearerAuth"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Tags       []openAPITag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPITag struct {
+	Name string `json:"name"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+	// Roles lists the roles authMiddleware lets through, when the route is restricted
+	Roles []string `json:"x-roles,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref              string                    `json:"$ref,omitempty"`
+	Type             string                    `json:"type,omitempty"`
+	Format           string                    `json:"format,omitempty"`
+	Description      string                    `json:"description,omitempty"`
+	Nullable         bool                      `json:"nullable,omitempty"`
+	Enum             []string                  `json:"enum,omitempty"`
+	Pattern          string                    `json:"pattern,omitempty"`
+	Minimum          *float64                  `json:"minimum,omitempty"`
+	ExclusiveMinimum bool                      `json:"exclusiveMinimum,omitempty"`
+	Maximum          *float64                  `json:"maximum,omitempty"`
+	MinLength        *int64                    `json:"minLength,omitempty"`
+	MaxLength        *int64                    `json:"maxLength,omitempty"`
+	MinItems         *int64                    `json:"minItems,omitempty"`
+	MaxItems         *int64                    `json:"maxItems,omitempty"`
+	Items            *openAPISchema            `json:"items,omitempty"`
+	Properties       map[string]*openAPISchema `json:"properties,omitempty"`
+	// AdditionalProperties describes the values of a map
+	AdditionalProperties *openAPISchema   `json:"additionalProperties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	AllOf                []*openAPISchema `json:"allOf,omitempty"`
+	OneOf                []*openAPISchema `json:"oneOf,omitempty"`
+}
+
+// statusResponse documents the body of routes that answer with a plain status message
+type statusResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+type revokeUserTokensResponse struct {
+	statusResponse
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+}
+
+var (
+	pathParamRegexp = regexp.MustCompile(`:([A-Za-z_]+)`)
+	timeType        = reflect.TypeOf(time.Time{})
+	textMarshaler   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	rawMessageType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// openAPIPath turns a gin route path into an OpenAPI one, /accounts/:id becomes /accounts/{id}
+func openAPIPath(path string) string {
+	return pathParamRegexp.ReplaceAllString(path, "{$1}")
+}
+
+// newOpenAPIDocument describes the operations, reflecting request and response shapes from their Go types.
+// It fails on binding constraints it doesn't know how to describe, so the spec can't silently lose them
+func newOpenAPIDocument(operations []apiOperation) (*openAPIDocument, error) {
+	builder := &openAPIBuilder{
+		schemas: map[string]*openAPISchema{},
+		names:   map[reflect.Type]string{},
+	}
+	doc := &openAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:       "GoBank API",
+			Description: "Accounts, transfers and payments of the simple bank. Amounts are integers in the minor unit of the currency.",
+			Version:     "1.0",
+		},
+		Paths: map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			Schemas: builder.schemas,
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				bearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "PASETO",
+					Description:  "Access token from /users/login or /tokens/renew_access",
+				},
+			},
+		},
+	}
+
+	tags := map[string]bool{}
+	for _, operation := range operations {
+		op, err := builder.operation(operation)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", operation.Method, operation.Path, err)
+		}
+		path := openAPIPath(operation.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*openAPIOperation{}
+		}
+		method := strings.ToLower(operation.Method)
+		if doc.Paths[path][method] != nil {
+			return nil, fmt.Errorf("%s %s is documented twice", operation.Method, operation.Path)
+		}
+		doc.Paths[path][method] = op
+		if !tags[operation.Tag] {
+			tags[operation.Tag] = true
+			doc.Tags = append(doc.Tags, openAPITag{Name: operation.Tag})
+		}
+	}
+	return doc, nil
+}
+
+type openAPIBuilder struct {
+	schemas map[string]*openAPISchema
+	names   map[reflect.Type]string
+}
+
+func (builder *openAPIBuilder) operation(operation apiOperation) (*openAPIOperation, error) {
+	op := &openAPIOperation{
+		OperationID: operation.OperationID,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Tags:        []string{operation.Tag},
+		Responses:   map[string]*openAPIResponse{},
+	}
+
+	params, err := builder.parameters("path", "uri", operation.URI)
+	if err != nil {
+		return nil, err
+	}
+	declared := map[string]bool{}
+	for _, param := range params {
+		declared[param.Name] = true
+	}
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(operation.Path, -1) {
+		if !declared[match[1]] {
+			return nil, fmt.Errorf("path parameter %q has no uri binding", match[1])
+		}
+		delete(declared, match[1])
+	}
+	if len(declared) > 0 {
+		return nil, fmt.Errorf("uri bindings %v are not in the path", declared)
+	}
+	op.Parameters = append(op.Parameters, params...)
+
+	params, err = builder.parameters("query", "form", operation.Query)
+	if err != nil {
+		return nil, err
+	}
+	op.Parameters = append(op.Parameters, params...)
+	for _, header := range operation.Headers {
+		op.Parameters = append(op.Parameters, &openAPIParameter{
+			Name:        header.Name,
+			In:          "header",
+			Description: header.Description,
+			Schema:      &openAPISchema{Type: "string", MaxLength: header.MaxLength},
+		})
+	}
+
+	if operation.Body != nil {
+		schema, err := builder.schema(reflect.TypeOf(operation.Body))
+		if err != nil {
+			return nil, err
+		}
+		op.RequestBody = &openAPIRequestBody{
+			Required: !operation.BodyOptional,
+			Content:  map[string]*openAPIMediaType{"application/json": {Schema: schema}},
+		}
+	}
+	if len(operation.Uploads) > 0 {
+		op.RequestBody = &openAPIRequestBody{Required: true, Content: map[string]*openAPIMediaType{}}
+		for _, mediaType := range operation.Uploads {
+			op.RequestBody.Content[mediaType] = &openAPIMediaType{Schema: &openAPISchema{Type: "string"}}
+		}
+	}
+
+	status := operation.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &openAPIResponse{Description: http.StatusText(status), Content: map[string]*openAPIMediaType{}}
+	if operation.Response != nil {
+		schema, err := builder.schema(reflect.TypeOf(operation.Response))
+		if err != nil {
+			return nil, err
+		}
+		if operation.OffsetResponse != nil {
+			offsetSchema, err := builder.schema(reflect.TypeOf(operation.OffsetResponse))
+			if err != nil {
+				return nil, err
+			}
+			schema = &openAPISchema{
+				Description: "A page and the cursor of the next one, or a bare array when page_id selects the offset form",
+				OneOf:       []*openAPISchema{schema, offsetSchema},
+			}
+		}
+		success.Content["application/json"] = &openAPIMediaType{Schema: schema}
+	}
+	for _, mediaType := range operation.Downloads {
+		success.Content[mediaType] = &openAPIMediaType{Schema: &openAPISchema{Type: "string", Format: "binary"}}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	errorCodes := append([]int{}, operation.Errors...)
+	if !operation.Public {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+		errorCodes = append(errorCodes, http.StatusUnauthorized, http.StatusInternalServerError)
+	}
+	if len(operation.Roles) > 0 {
+		op.Roles = operation.Roles
+		errorCodes = append(errorCodes, http.StatusForbidden)
+		desc := fmt.Sprintf("Only for the %s roles.", strings.Join(operation.Roles, " and "))
+		if op.Description != "" {
+			desc = op.Description + "\n\n" + desc
+		}
+		op.Description = desc
+	}
+	errorSchema, err := builder.schema(reflect.TypeOf(errorBody{}))
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range errorCodes {
+		op.Responses[strconv.Itoa(code)] = &openAPIResponse{
+			Description: http.StatusText(code),
+			Content:     map[string]*openAPIMediaType{"application/json": {Schema: errorSchema}},
+		}
+	}
+	return op, nil
+}
+
+// parameters describes the fields of a uri or query binding struct
+func (builder *openAPIBuilder) parameters(in string, tagKey string, binding interface{}) ([]*openAPIParameter, error) {
+	if binding == nil {
+		return nil, nil
+	}
+	var params []*openAPIParameter
+	for _, field := range structFields(reflect.TypeOf(binding)) {
+		name := strings.Split(field.Tag.Get(tagKey), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		schema, err := builder.schema(field.Type)
+		if err != nil {
+			return nil, err
+		}
+		required, err := applyBinding(schema, field.Tag.Get("binding"))
+		if err != nil {
+			return nil, fmt.Errorf("%s parameter %s: %w", in, name, err)
+		}
+		params = append(params, &openAPIParameter{
+			Name:     name,
+			In:       in,
+			Required: required || in == "path",
+			Schema:   schema,
+		})
+	}
+	return params, nil
+}
+
+// schema describes a type the way encoding/json writes it, structs become components
+func (builder *openAPIBuilder) schema(t reflect.Type) (*openAPISchema, error) {
+	if t.Kind() == reflect.Ptr {
+		schema, err := builder.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if schema.Ref != "" {
+			return &openAPISchema{AllOf: []*openAPISchema{schema}, Nullable: true}, nil
+		}
+		schema.Nullable = true
+		return schema, nil
+	}
+
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}, nil
+	case t == rawMessageType:
+		return &openAPISchema{Description: "Any JSON value"}, nil
+	case t.String() == "uuid.UUID":
+		return &openAPISchema{Type: "string", Format: "uuid"}, nil
+	case t.Implements(textMarshaler):
+		return &openAPISchema{Type: "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}, nil
+	case reflect.Int32:
+		return &openAPISchema{Type: "integer", Format: "int32"}, nil
+	case reflect.Int64:
+		return &openAPISchema{Type: "integer", Format: "int64"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}, nil
+	case reflect.String:
+		return &openAPISchema{Type: "string"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}, nil
+		}
+		items, err := builder.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &openAPISchema{Type: "array", Items: items}, nil
+	case reflect.Interface:
+		return &openAPISchema{Description: "Any JSON value"}, nil
+	case reflect.Map:
+		values, err := builder.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &openAPISchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return builder.component(t)
+	}
+	return nil, fmt.Errorf("cannot describe type %s", t)
+}
+
+// component registers a struct under components/schemas and returns a reference to it
+func (builder *openAPIBuilder) component(t reflect.Type) (*openAPISchema, error) {
+	name, ok := builder.names[t]
+	if !ok {
+		name = componentName(t)
+		if _, taken := builder.schemas[name]; taken {
+			return nil, fmt.Errorf("component %s is used by two types", name)
+		}
+		builder.names[t] = name
+		schema := &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{}}
+		// registered first so self references resolve
+		builder.schemas[name] = schema
+		for _, field := range structFields(t) {
+			jsonName := strings.Split(field.Tag.Get("json"), ",")[0]
+			if jsonName == "-" {
+				continue
+			}
+			if jsonName == "" {
+				jsonName = field.Name
+			}
+			fieldSchema, err := builder.schema(field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+			}
+			required, err := applyBinding(fieldSchema, field.Tag.Get("binding"))
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+			}
+			if required {
+				schema.Required = append(schema.Required, jsonName)
+			}
+			schema.Properties[jsonName] = fieldSchema
+		}
+	}
+	return &openAPISchema{Ref: "#/components/schemas/" + name}, nil
+}
+
+// componentName keeps the bare name of api and db types, types of other packages keep their package
+func componentName(t reflect.Type) string {
+	pkg, name, _ := strings.Cut(t.String(), ".")
+	if pkg != "api" && pkg != "db" {
+		return t.String()
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// structFields lists the exported fields of a struct, with those of embedded structs promoted like encoding/json does
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			fields = append(fields, structFields(field.Type)...)
+			continue
+		}
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// applyBinding adds the validator constraints of a binding tag to the schema and reports whether the value is required
+func applyBinding(schema *openAPISchema, tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+	required := false
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = target == schema
+		case "omitempty":
+		case "dive":
+			if target.Items == nil {
+				return false, fmt.Errorf("dive on a %s", target.Type)
+			}
+			target = target.Items
+		case "min", "max", "gt":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid %s parameter %q", name, param)
+			}
+			if err := applyLimit(target, name, n); err != nil {
+				return false, err
+			}
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "email":
+			target.Format = "email"
+		case "alphanum":
+			target.Pattern = "^[a-zA-Z0-9]+$"
+		case "nefield":
+			target.Description = fmt.Sprintf("Must differ from %s", param)
+		case "currency":
+			target.Enum = []string{util.USD, util.CAD, util.EUR}
+		case "role":
+			target.Enum = []string{util.DepositorRole, util.BankerRole, util.AdminRole}
+		case "recurrence":
+			target.Description = "iCalendar RRULE with FREQ (DAILY, WEEKLY, MONTHLY or YEARLY) and an optional INTERVAL, like FREQ=MONTHLY;INTERVAL=1"
+		default:
+			return false, fmt.Errorf("binding %q is not described", rule)
+		}
+	}
+	return required, nil
+}
+
+// applyLimit maps min, max and gt onto the bound that fits the schema type
+func applyLimit(schema *openAPISchema, name string, n float64) error {
+	length := int64(n)
+	switch {
+	case schema.Type == "integer" || schema.Type == "number":
+		switch name {
+		case "min":
+			schema.Minimum = &n
+		case "max":
+			schema.Maximum = &n
+		case "gt":
+			schema.Minimum = &n
+			schema.ExclusiveMinimum = true
+		}
+	case schema.Type == "string" && name != "gt":
+		if name == "min" {
+			schema.MinLength = &length
+		} else {
+			schema.MaxLength = &length
+		}
+	case schema.Type == "array" && name != "gt":
+		if name == "min" {
+			schema.MinItems = &length
+		} else {
+			schema.MaxItems = &length
+		}
+	default:
+		return fmt.Errorf("%s on a %s", name, schema.Type)
+	}
+	return nil
+}
+
+// openAPISpec marshals the document of every route
+func openAPISpec() ([]byte, error) {
+	doc, err := newOpenAPIDocument(apiOperations)
+	if err != nil {
+		return nil, fmt.Errorf("cannot build openapi document: %w", err)
+	}
+	return json.Marshal(doc)
+}
+
+func (server *Server) getOpenAPISpec(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "application/json; charset=utf-8", server.openAPISpec)
+}
+
+// docsInitializer replaces the one bundled with Swagger UI, which loads the petstore example
+const docsInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+// serveDocs serves the bundled Swagger UI for /openapi.json
+func (server *Server) serveDocs(ctx *gin.Context) {
+	file := strings.TrimPrefix(ctx.Param("filepath"), "/")
+	switch file {
+	case "", "index.html":
+		// http.FileServer would redirect index.html back to the directory
+		index, err := fs.ReadFile(swaggerFiles.FS, "index.html")
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		ctx.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	case "swagger-initializer.js":
+		ctx.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(docsInitializer))
+	default:
+		ctx.FileFromFS(file, http.FS(swaggerFiles.FS))
+	}
+}
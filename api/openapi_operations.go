@@ -0,0 +1,358 @@
+package api
+
+import (
+	"net/http"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+)
+
+// apiOperation documents one route of setupRouter. Request and response shapes are given as values of
+// the types the handler binds and writes, so the spec follows their fields and binding tags
+type apiOperation struct {
+	Method string
+	// Path is the gin route path
+	Path        string
+	OperationID string
+	Tag         string
+	Summary     string
+	Description string
+	// Public routes are not behind authMiddleware
+	Public bool
+	// Roles restricts the route to some roles, any authenticated user may call it when empty
+	Roles []string
+	URI   interface{}
+	Query interface{}
+	Body  interface{}
+	// BodyOptional is set when the handler accepts a request without a body
+	BodyOptional bool
+	// Uploads lists the media types of a raw request body, instead of a JSON one
+	Uploads []string
+	Headers []apiHeader
+	// Status is the success status, 200 when zero
+	Status   int
+	Response interface{}
+	// OffsetResponse is what a paginated listing answers when page_id selects the offset form
+	OffsetResponse interface{}
+	// Downloads lists the media types of a raw response body
+	Downloads []string
+	// Errors lists the error statuses of the handler, those of authMiddleware are added for routes behind it
+	Errors []int
+}
+
+type apiHeader struct {
+	Name        string
+	Description string
+	MaxLength   *int64
+}
+
+var maxIdempotencyKeyLengthParam = int64(maxIdempotencyKeyLength)
+
+var privilegedRoles = []string{util.BankerRole, util.AdminRole}
+
+// apiOperations documents every route of setupRouter, TestOpenAPIRoutes fails when they drift apart
+var apiOperations = []apiOperation{
+	{
+		Method: http.MethodGet, Path: "/livez", OperationID: "checkLiveness", Tag: "health",
+		Summary: "Check the server is up", Public: true,
+		Response: statusResponse{},
+	},
+	{
+		Method: http.MethodGet, Path: "/readyz", OperationID: "checkReadiness", Tag: "health",
+		Summary:     "Check the server can take requests",
+		Description: "Fails while the database is unreachable or a migration is dirty, and once the server is shutting down.",
+		Public:      true, Response: readinessResponse{},
+		Errors: []int{http.StatusServiceUnavailable},
+	},
+	{
+		Method: http.MethodGet, Path: "/openapi.json", OperationID: "getOpenAPISpec", Tag: "health",
+		Summary: "This document", Public: true,
+		Response: map[string]interface{}{},
+	},
+
+	// users
+	{
+		Method: http.MethodPost, Path: "/users", OperationID: "createUser", Tag: "users",
+		Summary: "Sign up a depositor", Public: true,
+		Body: createUserRequest{}, Response: userResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/users/login", OperationID: "loginUser", Tag: "users",
+		Summary: "Log in for an access and a refresh token", Public: true,
+		Body: loginUserRequest{}, Response: loginUserResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/tokens/renew_access", OperationID: "renewAccessToken", Tag: "users",
+		Summary: "Trade a refresh token for a new access token", Public: true,
+		Body: renewAccessTokenRequest{}, Response: renewAccessTokenResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError},
+	},
+	{
+		Method: http.MethodPost, Path: "/users/logout", OperationID: "logoutUser", Tag: "users",
+		Summary:     "Revoke the access token",
+		Description: "Also blocks the session of the refresh token when one is sent.",
+		Body:        logoutUserRequest{}, BodyOptional: true, Response: statusResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden},
+	},
+
+	// accounts
+	{
+		Method: http.MethodPost, Path: "/accounts", OperationID: "createAccount", Tag: "accounts",
+		Summary: "Open an account for the authenticated user",
+		Body:    createAccountRequest{}, Response: db.Account{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts/:id", OperationID: "getAccount", Tag: "accounts",
+		Summary: "Get an account",
+		URI:     getAccountRequest{}, Response: db.Account{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts", OperationID: "ListAccounts", Tag: "accounts",
+		Summary:     "List accounts",
+		Description: "Lists the authenticated user's accounts, bankers and admins may list another owner's.",
+		Query:       ListAccountsRequest{}, Response: listAccountsResponse{}, OffsetResponse: []db.Account{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden},
+	},
+	{
+		Method: http.MethodDelete, Path: "/accounts/:id", OperationID: "deleteAccount", Tag: "accounts",
+		Summary:     "Close an account",
+		Description: "Only empty accounts without any activity can be deleted.",
+		URI:         deleteAccountUri{}, Response: statusResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts/:id/transfers", OperationID: "listAccountTransfers", Tag: "accounts",
+		Summary: "List the transfers of an account, newest first",
+		URI:     accountHistoryUri{}, Query: historyFilter{},
+		Response: listAccountTransfersResponse{}, OffsetResponse: []db.Transfer{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts/:id/entries", OperationID: "listAccountEntries", Tag: "accounts",
+		Summary: "List the ledger entries of an account, newest first",
+		URI:     accountHistoryUri{}, Query: historyFilter{},
+		Response: listAccountEntriesResponse{}, OffsetResponse: []db.Entry{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts/:id/balance", OperationID: "getAccountBalance", Tag: "accounts",
+		Summary: "Get the balance of an account, now or at a point in time",
+		URI:     accountHistoryUri{}, Query: getAccountBalanceRequest{}, Response: accountBalanceResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/accounts/:id/statement", OperationID: "getAccountStatement", Tag: "accounts",
+		Summary:     "Download a statement",
+		Description: "Streams the entries between from and to as CSV, OFX or camt.053.",
+		URI:         accountHistoryUri{}, Query: getStatementRequest{},
+		Downloads: []string{"text/csv", "application/x-ofx", "application/xml"},
+		Errors:    []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+
+	// transfers
+	{
+		Method: http.MethodPost, Path: "/transfers", OperationID: "createTransfer", Tag: "transfers",
+		Summary:     "Transfer money from one of the authenticated user's accounts",
+		Description: "The to account may hold another currency, the amount is converted at the current rate.",
+		Body:        transferRequest{}, Response: db.TransferTxResult{},
+		Headers: []apiHeader{{
+			Name:        idempotencyKeyHeader,
+			Description: "Retrying with the same key replays the first response instead of transferring again",
+			MaxLength:   &maxIdempotencyKeyLengthParam,
+		}},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodGet, Path: "/transfers/:id", OperationID: "getTransfer", Tag: "transfers",
+		Summary: "Get a transfer",
+		URI:     getTransferRequest{}, Response: db.Transfer{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/transfers/:id/refund", OperationID: "refundTransfer", Tag: "transfers",
+		Summary: "Send all or part of a received transfer back",
+		URI:     getTransferRequest{}, Body: refundTransferRequest{}, BodyOptional: true, Response: db.TransferTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodPost, Path: "/transfers/authorize", OperationID: "authorizeTransfer", Tag: "transfers",
+		Summary: "Hold funds for the recipient to capture or void",
+		Body:    authorizeTransferRequest{}, Response: db.TransferHoldTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodPost, Path: "/transfers/:id/capture", OperationID: "captureTransfer", Tag: "transfers",
+		Summary: "Settle an authorized transfer",
+		URI:     getTransferRequest{}, Response: db.TransferTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodPost, Path: "/transfers/:id/void", OperationID: "voidTransfer", Tag: "transfers",
+		Summary: "Cancel an authorized transfer",
+		URI:     getTransferRequest{}, Response: db.TransferHoldTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+
+	// scheduled transfers
+	{
+		Method: http.MethodPost, Path: "/scheduled-transfers", OperationID: "createScheduledTransfer", Tag: "scheduled transfers",
+		Summary: "Schedule a transfer",
+		Body:    createScheduledTransferRequest{}, Response: db.ScheduledTransfer{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/scheduled-transfers", OperationID: "listScheduledTransfers", Tag: "scheduled transfers",
+		Summary:  "List the authenticated user's scheduled transfers",
+		Query:    listScheduledTransfersRequest{},
+		Response: listScheduledTransfersResponse{}, OffsetResponse: []db.ScheduledTransfer{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/scheduled-transfers/:id", OperationID: "getScheduledTransfer", Tag: "scheduled transfers",
+		Summary: "Get a scheduled transfer",
+		URI:     scheduledTransferUri{}, Response: db.ScheduledTransfer{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/scheduled-transfers/:id/cancel", OperationID: "cancelScheduledTransfer", Tag: "scheduled transfers",
+		Summary: "Cancel a pending scheduled transfer",
+		URI:     scheduledTransferUri{}, Response: db.ScheduledTransfer{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+
+	// standing orders
+	{
+		Method: http.MethodPost, Path: "/standing-orders", OperationID: "createStandingOrder", Tag: "standing orders",
+		Summary: "Create a recurring transfer",
+		Body:    createStandingOrderRequest{}, Response: db.StandingOrder{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/standing-orders", OperationID: "listStandingOrders", Tag: "standing orders",
+		Summary:  "List the authenticated user's standing orders",
+		Query:    listStandingOrdersRequest{},
+		Response: listStandingOrdersResponse{}, OffsetResponse: []db.StandingOrder{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/standing-orders/:id", OperationID: "getStandingOrder", Tag: "standing orders",
+		Summary: "Get a standing order",
+		URI:     standingOrderUri{}, Response: db.StandingOrder{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/standing-orders/:id/cancel", OperationID: "cancelStandingOrder", Tag: "standing orders",
+		Summary: "Stop a standing order",
+		URI:     standingOrderUri{}, Response: db.StandingOrder{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodGet, Path: "/standing-orders/:id/executions", OperationID: "listStandingOrderExecutions", Tag: "standing orders",
+		Summary: "List the occurrences a standing order has run, newest first",
+		URI:     standingOrderUri{}, Query: listStandingOrdersRequest{},
+		Response: listStandingOrderExecutionsResponse{}, OffsetResponse: []db.StandingOrderExecution{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+
+	// bulk payments
+	{
+		Method: http.MethodPost, Path: "/payment-batches", OperationID: "createPaymentBatch", Tag: "bulk payments",
+		Summary: "Upload a batch of transfers",
+		Description: "Accepts a pain.001 document or a CSV file with the header " +
+			"from_account_id,to_account_id,amount,currency,end_to_end_id. Instructions that fail the transfer " +
+			"checks are stored as rejected, the rest are executed in the background.",
+		Uploads: []string{"application/xml", "text/xml", "text/csv"},
+		Status:  http.StatusAccepted, Response: paymentBatchResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType},
+	},
+	{
+		Method: http.MethodGet, Path: "/payment-batches", OperationID: "listPaymentBatches", Tag: "bulk payments",
+		Summary:  "List the authenticated user's payment batches",
+		Query:    listPaymentBatchesRequest{},
+		Response: listPaymentBatchesResponse{}, OffsetResponse: []db.PaymentBatch{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/payment-batches/:id", OperationID: "getPaymentBatch", Tag: "bulk payments",
+		Summary: "Get a payment batch with the count of items in each status",
+		URI:     paymentBatchUri{}, Response: paymentBatchResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/payment-batches/:id/items", OperationID: "listPaymentBatchItems", Tag: "bulk payments",
+		Summary: "List the items of a payment batch, in file order",
+		URI:     paymentBatchUri{}, Query: listPaymentBatchesRequest{},
+		Response: listPaymentBatchItemsResponse{}, OffsetResponse: []db.PaymentBatchItem{},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodGet, Path: "/payment-batches/:id/report", OperationID: "getPaymentBatchReport", Tag: "bulk payments",
+		Summary: "Download the pain.002 status report of a payment batch",
+		URI:     paymentBatchUri{}, Downloads: []string{"application/xml"},
+		Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+	},
+
+	// bankers and admins
+	{
+		Method: http.MethodPatch, Path: "/accounts/:id", OperationID: "updateAccount", Tag: "accounts",
+		Summary: "Adjust an account balance through a ledger entry", Roles: privilegedRoles,
+		URI: updateAccountUri{}, Body: updateAccountBody{}, Response: db.AdjustBalanceTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPut, Path: "/accounts/:id/overdraft_limit", OperationID: "updateOverdraftLimit", Tag: "accounts",
+		Summary: "Set how far below zero an account may go", Roles: privilegedRoles,
+		URI: updateAccountUri{}, Body: updateOverdraftLimitBody{}, Response: db.Account{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/transfers/:id/reverse", OperationID: "reverseTransfer", Tag: "transfers",
+		Summary: "Send back everything that is left of a transfer", Roles: privilegedRoles,
+		URI: getTransferRequest{}, Response: db.TransferTxResult{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity},
+	},
+
+	// admin
+	{
+		Method: http.MethodGet, Path: "/admin/users/:username", OperationID: "getUser", Tag: "admin",
+		Summary: "Get a user", Roles: []string{util.AdminRole},
+		URI: getUserRequest{}, Response: userResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPatch, Path: "/admin/users/:username/role", OperationID: "updateUserRole", Tag: "admin",
+		Summary:     "Change a user's role",
+		Description: "Revokes the user's tokens so the new role applies on next login.",
+		Roles:       []string{util.AdminRole},
+		URI:         getUserRequest{}, Body: updateUserRoleBody{}, Response: userResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/users/:username/revoke_tokens", OperationID: "revokeUserTokens", Tag: "admin",
+		Summary: "Invalidate every token issued to a user so far", Roles: []string{util.AdminRole},
+		URI: revokeUserTokensRequest{}, Response: revokeUserTokensResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/exchange_rates", OperationID: "createExchangeRates", Tag: "admin",
+		Summary: "Publish exchange rates", Roles: []string{util.AdminRole},
+		Body: createExchangeRatesRequest{}, Response: []db.ExchangeRate{},
+		Errors: []int{http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity},
+	},
+	{
+		Method: http.MethodPost, Path: "/admin/reconciliations", OperationID: "runReconciliation", Tag: "admin",
+		Summary: "Check every account and transfer against the ledger", Roles: []string{util.AdminRole},
+		Body: runReconciliationRequest{}, BodyOptional: true, Response: runReconciliationResponse{},
+		Errors: []int{http.StatusBadRequest},
+	},
+	{
+		Method: http.MethodGet, Path: "/admin/reconciliations/:id", OperationID: "getReconciliation", Tag: "admin",
+		Summary: "Get a saved reconciliation report and check its signature", Roles: []string{util.AdminRole},
+		URI: getReconciliationRequest{}, Response: getReconciliationResponse{},
+		Errors: []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+}
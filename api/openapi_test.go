@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// undocumentedRoutes are served by setupRouter but are not part of the API
+var undocumentedRoutes = map[string]bool{
+	"GET /docs/*filepath": true,
+	"GET /metrics":        true,
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	documented := map[string]apiOperation{}
+	for _, operation := range apiOperations {
+		documented[operation.Method+" "+operation.Path] = operation
+	}
+
+	for _, route := range server.router.Routes() {
+		key := route.Method + " " + route.Path
+		if undocumentedRoutes[key] {
+			continue
+		}
+		operation, ok := documented[key]
+		require.Truef(t, ok, "%s is routed but missing from apiOperations", key)
+		delete(documented, key)
+
+		// gin names method values like github.com/joekings2k/gobank/api.(*Server).createAccount-fm
+		handler := strings.TrimSuffix(route.Handler[strings.LastIndex(route.Handler, ".")+1:], "-fm")
+		require.Equalf(t, handler, operation.OperationID, "%s is handled by %s", key, handler)
+	}
+	for key := range documented {
+		t.Errorf("%s is documented but not routed", key)
+	}
+}
+
+// revocationStore only answers the revocation check of authMiddleware. Handlers reaching any other
+// method panic, which the recovery middleware turns into a 500
+type revocationStore struct {
+	db.Store
+}
+
+func (revocationStore) IsTokenRevoked(context.Context, db.IsTokenRevokedParams) (bool, error) {
+	return false, nil
+}
+
+// TestOpenAPIRoles checks each operation's Public and Roles against the route group setupRouter puts it in,
+// by calling the route with a token of every role
+func TestOpenAPIRoles(t *testing.T) {
+	server := newTestServer(t, revocationStore{})
+
+	for _, operation := range apiOperations {
+		path := operation.Path
+		for _, segment := range strings.Split(path, "/") {
+			if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+				path = strings.Replace(path, segment, "1", 1)
+			}
+		}
+		call := func(role string) errorBody {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(operation.Method, path, nil)
+			require.NoError(t, err)
+			if role != "" {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.RandomOwner(), role, time.Minute)
+			}
+			server.router.ServeHTTP(recorder, request)
+			if recorder.Code < http.StatusBadRequest {
+				return errorBody{}
+			}
+			return requireErrorBody(t, recorder.Body)
+		}
+		key := operation.Method + " " + operation.Path
+
+		if operation.Public {
+			require.NotEqualf(t, unauthenticatedCode, call("").Code, "%s is documented as public but needs a token", key)
+			continue
+		}
+		require.Equalf(t, unauthenticatedCode, call("").Code, "%s is documented as authenticated but is public", key)
+		for _, role := range []string{util.DepositorRole, util.BankerRole, util.AdminRole} {
+			allowed := len(operation.Roles) == 0 || util.HasRole(role, operation.Roles...)
+			rejected := call(role).Code == roleNotAllowedCode
+			require.Equalf(t, allowed, !rejected, "%s is documented with roles %v, routed for %s: %v", key, operation.Roles, role, !rejected)
+		}
+	}
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/openapi.json", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+	require.Equal(t, openAPIVersion, doc.OpenAPI)
+
+	// binding tags become constraints
+	transfer := doc.Components.Schemas["TransferRequest"]
+	require.NotNil(t, transfer)
+	require.ElementsMatch(t, []string{"from_account_id", "to_account_id", "amount", "currency"}, transfer.Required)
+	require.Equal(t, 1.0, *transfer.Properties["from_account_id"].Minimum)
+	require.Equal(t, 0.0, *transfer.Properties["amount"].Minimum)
+	require.True(t, transfer.Properties["amount"].ExclusiveMinimum)
+	require.Equal(t, []string{"USD", "CAD", "EUR"}, transfer.Properties["currency"].Enum)
+
+	listAccounts := doc.Paths["/accounts"]["get"]
+	require.NotNil(t, listAccounts)
+	require.Equal(t, []map[string][]string{{bearerAuth: {}}}, listAccounts.Security)
+	var pageSize *openAPIParameter
+	for _, param := range listAccounts.Parameters {
+		if param.Name == "page_size" {
+			pageSize = param
+		}
+	}
+	require.NotNil(t, pageSize)
+	require.Equal(t, "query", pageSize.In)
+	require.True(t, pageSize.Required)
+	require.Equal(t, 5.0, *pageSize.Schema.Minimum)
+	require.Equal(t, 10.0, *pageSize.Schema.Maximum)
+	require.Len(t, listAccounts.Responses["200"].Content["application/json"].Schema.OneOf, 2)
+	require.Contains(t, listAccounts.Responses, "401")
+
+	getUser := doc.Paths["/admin/users/{username}"]["get"]
+	require.NotNil(t, getUser)
+	require.Equal(t, []string{"admin"}, getUser.Roles)
+	require.Contains(t, getUser.Responses, "403")
+	require.True(t, getUser.Parameters[0].Required)
+
+	createUser := doc.Paths["/users"]["post"]
+	require.NotNil(t, createUser)
+	require.Empty(t, createUser.Security)
+
+	// every reference resolves
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &raw))
+	requireRefsResolve(t, raw, doc.Components.Schemas)
+}
+
+func requireRefsResolve(t *testing.T, node interface{}, schemas map[string]*openAPISchema) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		for key, value := range node {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				name := strings.TrimPrefix(ref, "#/components/schemas/")
+				require.Containsf(t, schemas, name, "unresolved reference %s", ref)
+				continue
+			}
+			requireRefsResolve(t, value, schemas)
+		}
+	case []interface{}:
+		for _, value := range node {
+			requireRefsResolve(t, value, schemas)
+		}
+	}
+}
+
+func TestOpenAPIUndescribedBinding(t *testing.T) {
+	type uuidRequest struct {
+		ID string `json:"id" binding:"required,uuid4"`
+	}
+	_, err := newOpenAPIDocument([]apiOperation{{
+		Method: http.MethodPost, Path: "/things", OperationID: "createThing", Tag: "things",
+		Body: uuidRequest{},
+	}})
+	require.ErrorContains(t, err, `binding "uuid4" is not described`)
+
+	type thingUri struct {
+		ID int64 `uri:"thing_id" binding:"required,min=1"`
+	}
+	_, err = newOpenAPIDocument([]apiOperation{{
+		Method: http.MethodGet, Path: "/things/:id", OperationID: "getThing", Tag: "things",
+		URI: thingUri{},
+	}})
+	require.ErrorContains(t, err, `path parameter "id" has no uri binding`)
+}
+
+func TestServeDocs(t *testing.T) {
+	server := newTestServer(t, nil)
+
+	testCases := []struct {
+		path        string
+		contentType string
+		contains    string
+	}{
+		{path: "/docs/", contentType: "text/html", contains: "swagger-ui"},
+		{path: "/docs/index.html", contentType: "text/html", contains: "swagger-initializer.js"},
+		{path: "/docs/swagger-initializer.js", contentType: "application/javascript", contains: `url: "/openapi.json"`},
+		{path: "/docs/swagger-ui.css", contentType: "text/css", contains: ".swagger-ui"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, http.StatusOK, recorder.Code)
+			require.Contains(t, recorder.Header().Get("Content-Type"), tc.contentType)
+			require.Contains(t, recorder.Body.String(), tc.contains)
+		})
+	}
+}
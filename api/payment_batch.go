@@ -0,0 +1,480 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/logging"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+)
+
+// paymentBatchReportSize is how many items are read and written at a time while streaming a status report
+const paymentBatchReportSize = 500
+
+const (
+	paymentBatchProcessing         = "processing"
+	paymentBatchCompleted          = "completed"
+	paymentBatchPartiallyCompleted = "partially_completed"
+	paymentBatchFailed             = "failed"
+)
+
+type paymentBatchCounts struct {
+	Total     int64 `json:"total"`
+	Pending   int64 `json:"pending"`
+	Completed int64 `json:"completed"`
+	Rejected  int64 `json:"rejected"`
+	Failed    int64 `json:"failed"`
+}
+
+func (counts *paymentBatchCounts) add(status string, n int64) {
+	counts.Total += n
+	switch status {
+	case db.PaymentBatchItemPending:
+		counts.Pending += n
+	case db.PaymentBatchItemCompleted:
+		counts.Completed += n
+	case db.PaymentBatchItemRejected:
+		counts.Rejected += n
+	case db.PaymentBatchItemFailed:
+		counts.Failed += n
+	}
+}
+
+// status is derived from the items, so workers finishing items concurrently never race on a batch row
+func (counts paymentBatchCounts) status() string {
+	switch {
+	case counts.Pending > 0:
+		return paymentBatchProcessing
+	case counts.Completed == counts.Total:
+		return paymentBatchCompleted
+	case counts.Completed == 0:
+		return paymentBatchFailed
+	default:
+		return paymentBatchPartiallyCompleted
+	}
+}
+
+type paymentBatchResponse struct {
+	db.PaymentBatch
+	Status string             `json:"status"`
+	Items  paymentBatchCounts `json:"items"`
+}
+
+func newPaymentBatchResponse(batch db.PaymentBatch, counts paymentBatchCounts) paymentBatchResponse {
+	return paymentBatchResponse{PaymentBatch: batch, Status: counts.status(), Items: counts}
+}
+
+// createPaymentBatch accepts a pain.001 document or a CSV file of transfers from the authenticated user's accounts.
+// Every instruction is checked like a single transfer, the ones that fail are stored as rejected with the reason
+// and the rest are left pending for the scheduler to execute
+func (server *Server) createPaymentBatch(ctx *gin.Context) {
+	var format string
+	switch ctx.ContentType() {
+	case "application/xml", "text/xml":
+		format = db.PaymentBatchPain001
+	case "text/csv":
+		format = db.PaymentBatchCSV
+	default:
+		writeError(ctx, newAPIError(unsupportedMediaTypeCode, "upload a pain.001 document as application/xml or a CSV file as text/csv"))
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPaymentBatchBytes)
+	var file paymentFile
+	var err error
+	if format == db.PaymentBatchPain001 {
+		file, err = parsePain001(body)
+	} else {
+		file, err = parsePaymentCSV(body)
+	}
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(ctx, apiErrorf(payloadTooLargeCode, "the file must be at most %d bytes", maxPaymentBatchBytes))
+			return
+		}
+		writeError(ctx, newAPIError(invalidRequestCode, err.Error()))
+		return
+	}
+	if len(file.Instructions) == 0 {
+		writeError(ctx, newAPIError(invalidRequestCode, "the file has no transfers"))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	items, err := server.checkPaymentInstructions(ctx, authPayload.Username, file.Instructions)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	result, err := server.store.CreatePaymentBatchTx(ctx, db.CreatePaymentBatchTxParams{
+		Owner:     authPayload.Username,
+		Format:    format,
+		MessageID: file.MessageID,
+		Items:     items,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	var counts paymentBatchCounts
+	for _, item := range result.Items {
+		counts.add(item.Status, 1)
+	}
+	ctx.JSON(http.StatusAccepted, newPaymentBatchResponse(result.Batch, counts))
+}
+
+// checkPaymentInstructions applies the single transfer rules to every instruction: the from account
+// belongs to the owner and holds the currency, and the to account exists. It only fails on database errors
+func (server *Server) checkPaymentInstructions(ctx *gin.Context, owner string, instructions []paymentInstruction) ([]db.CreatePaymentBatchItemParams, error) {
+	// a payroll file pays from a few accounts to many, each account is read once
+	accounts := make(map[int64]*db.Account)
+	getAccount := func(id int64) (*db.Account, error) {
+		if account, ok := accounts[id]; ok {
+			return account, nil
+		}
+		account, err := server.store.GetAccount(ctx, id)
+		if err != nil {
+			if err != sql.ErrNoRows {
+				return nil, err
+			}
+			accounts[id] = nil
+			return nil, nil
+		}
+		accounts[id] = &account
+		return &account, nil
+	}
+
+	items := make([]db.CreatePaymentBatchItemParams, len(instructions))
+	for i, instruction := range instructions {
+		reason, err := func() (string, error) {
+			if !util.IsSuppoertedCurrency(instruction.Currency) {
+				return fmt.Sprintf("unsupported currency %q", instruction.Currency), nil
+			}
+			fromAccount, err := getAccount(instruction.FromAccountID)
+			if err != nil || fromAccount == nil {
+				return fmt.Sprintf("from account [%d] not found", instruction.FromAccountID), err
+			}
+			if fromAccount.Owner != owner {
+				return "from account doesn`t belong to the authenticated user", nil
+			}
+			if fromAccount.Currency != instruction.Currency {
+				return fmt.Sprintf("account [%d] currency mismatch: %s vs %s", fromAccount.ID, fromAccount.Currency, instruction.Currency), nil
+			}
+			toAccount, err := getAccount(instruction.ToAccountID)
+			if err != nil || toAccount == nil {
+				return fmt.Sprintf("to account [%d] not found", instruction.ToAccountID), err
+			}
+			return "", nil
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		items[i] = db.CreatePaymentBatchItemParams{
+			Line:          instruction.Line,
+			EndToEndID:    instruction.EndToEndID,
+			FromAccountID: instruction.FromAccountID,
+			ToAccountID:   instruction.ToAccountID,
+			Amount:        instruction.Amount,
+			Currency:      instruction.Currency,
+			Status:        db.PaymentBatchItemPending,
+		}
+		if reason != "" {
+			items[i].Status = db.PaymentBatchItemRejected
+			items[i].FailureReason = sql.NullString{String: reason, Valid: true}
+		}
+	}
+	return items, nil
+}
+
+type listPaymentBatchesRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listPaymentBatchesResponse struct {
+	PaymentBatches []db.PaymentBatch `json:"payment_batches"`
+	NextCursor     string            `json:"next_cursor,omitempty"`
+}
+
+// listPaymentBatches lists the authenticated user's payment batches, newest first
+func (server *Server) listPaymentBatches(ctx *gin.Context) {
+	var req listPaymentBatchesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	page, ok := server.bindPagination(ctx, "payment_batches:"+authPayload.Username, req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
+	}
+
+	batches, err := server.store.ListPaymentBatches(ctx, db.ListPaymentBatchesParams{
+		Owner:           authPayload.Username,
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, batches)
+		return
+	}
+
+	batches, next, err := keysetPage(server, page, batches, func(batch db.PaymentBatch) (time.Time, int64) {
+		return batch.CreatedAt, batch.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listPaymentBatchesResponse{PaymentBatches: batches, NextCursor: next})
+}
+
+type paymentBatchUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// accessiblePaymentBatch gets a payment batch uploaded by the authenticated user, or any for bankers and admins
+func (server *Server) accessiblePaymentBatch(ctx *gin.Context) (db.PaymentBatch, bool) {
+	var uri paymentBatchUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return db.PaymentBatch{}, false
+	}
+	batch, err := server.store.GetPaymentBatch(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return batch, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if batch.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx, newAPIError(notOwnerCode, "payment batch doesn`t belong to the authenticated user"))
+		return batch, false
+	}
+	return batch, true
+}
+
+func (server *Server) paymentBatchCounts(ctx *gin.Context, batchID int64) (paymentBatchCounts, error) {
+	var counts paymentBatchCounts
+	rows, err := server.store.CountPaymentBatchItems(ctx, batchID)
+	if err != nil {
+		return counts, err
+	}
+	for _, row := range rows {
+		counts.add(row.Status, row.Count)
+	}
+	return counts, nil
+}
+
+// getPaymentBatch returns a batch with how far its items have got
+func (server *Server) getPaymentBatch(ctx *gin.Context) {
+	batch, ok := server.accessiblePaymentBatch(ctx)
+	if !ok {
+		return
+	}
+	counts, err := server.paymentBatchCounts(ctx, batch.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newPaymentBatchResponse(batch, counts))
+}
+
+type listPaymentBatchItemsResponse struct {
+	Items      []db.PaymentBatchItem `json:"items"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// listPaymentBatchItems lists the status of every line of a batch, in file order
+func (server *Server) listPaymentBatchItems(ctx *gin.Context) {
+	var req listPaymentBatchesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	batch, ok := server.accessiblePaymentBatch(ctx)
+	if !ok {
+		return
+	}
+	page, ok := server.bindPagination(ctx, fmt.Sprintf("payment_batch_items:%d", batch.ID), req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
+	}
+
+	items, err := server.store.ListPaymentBatchItems(ctx, db.ListPaymentBatchItemsParams{
+		BatchID:        batch.ID,
+		AfterCreatedAt: page.cursorTime(),
+		AfterID:        page.cursorID(),
+		Limit:          page.limit(),
+		Offset:         page.offset(),
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, items)
+		return
+	}
+
+	items, next, err := keysetPage(server, page, items, func(item db.PaymentBatchItem) (time.Time, int64) {
+		return item.CreatedAt, item.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listPaymentBatchItemsResponse{Items: items, NextCursor: next})
+}
+
+const pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+
+type pain002GroupHeader struct {
+	MessageID        string `xml:"MsgId"`
+	CreationDateTime string `xml:"CreDtTm"`
+}
+
+type pain002OriginalGroup struct {
+	MessageID            string `xml:"OrgnlMsgId"`
+	MessageNameID        string `xml:"OrgnlMsgNmId"`
+	NumberOfTransactions int64  `xml:"OrgnlNbOfTxs"`
+	GroupStatus          string `xml:"GrpSts"`
+}
+
+type pain002Transaction struct {
+	StatusID              string `xml:"StsId"`
+	OriginalEndToEndID    string `xml:"OrgnlEndToEndId,omitempty"`
+	TransactionStatus     string `xml:"TxSts"`
+	AdditionalInformation string `xml:"StsRsnInf>AddtlInf,omitempty"`
+	AcceptanceDateTime    string `xml:"AccptncDtTm,omitempty"`
+}
+
+// pain002GroupStatus maps the batch status to an ISO 20022 group status code
+func pain002GroupStatus(counts paymentBatchCounts) string {
+	switch counts.status() {
+	case paymentBatchProcessing:
+		return "ACSP"
+	case paymentBatchCompleted:
+		return "ACSC"
+	case paymentBatchFailed:
+		return "RJCT"
+	default:
+		return "PART"
+	}
+}
+
+// pain002TransactionStatus maps an item status to an ISO 20022 transaction status code
+func pain002TransactionStatus(status string) string {
+	switch status {
+	case db.PaymentBatchItemCompleted:
+		return "ACSC"
+	case db.PaymentBatchItemPending:
+		return "PDNG"
+	default:
+		return "RJCT"
+	}
+}
+
+// getPaymentBatchReport streams a pain.002 customer payment status report with a status for every line
+func (server *Server) getPaymentBatchReport(ctx *gin.Context) {
+	batch, ok := server.accessiblePaymentBatch(ctx)
+	if !ok {
+		return
+	}
+	counts, err := server.paymentBatchCounts(ctx, batch.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Type", "application/xml")
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="payment-batch-%d-status.xml"`, batch.ID))
+	ctx.Status(http.StatusOK)
+
+	// the status is sent with the first bytes, so from here a failure can only cut the report short
+	if err := server.streamPaymentBatchReport(ctx, batch, counts); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "cannot stream payment batch status report", "batch_id", batch.ID, "error", err)
+		ctx.Abort()
+	}
+}
+
+func (server *Server) streamPaymentBatchReport(ctx *gin.Context, batch db.PaymentBatch, counts paymentBatchCounts) error {
+	// csv uploads have no message id of their own
+	messageID := batch.MessageID
+	messageNameID := "pain.001.001.03"
+	if batch.Format == db.PaymentBatchCSV {
+		messageID = "BATCH-" + strconv.FormatInt(batch.ID, 10)
+		messageNameID = "CSV"
+	}
+
+	stream := newXMLStream(ctx.Writer)
+	stream.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: pain002Namespace})
+	stream.start("CstmrPmtStsRpt")
+	stream.element("GrpHdr", pain002GroupHeader{
+		MessageID:        fmt.Sprintf("%s-PSR-%d-%d", statementBankID, batch.ID, time.Now().Unix()),
+		CreationDateTime: time.Now().UTC().Format(time.RFC3339),
+	})
+	stream.element("OrgnlGrpInfAndSts", pain002OriginalGroup{
+		MessageID:            messageID,
+		MessageNameID:        messageNameID,
+		NumberOfTransactions: counts.Total,
+		GroupStatus:          pain002GroupStatus(counts),
+	})
+	stream.start("OrgnlPmtInfAndSts")
+	stream.element("OrgnlPmtInfId", messageID)
+
+	arg := db.ListPaymentBatchItemsParams{
+		BatchID: batch.ID,
+		Limit:   paymentBatchReportSize,
+	}
+	for {
+		items, err := server.store.ListPaymentBatchItems(ctx, arg)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			tx := pain002Transaction{
+				StatusID:              strconv.Itoa(int(item.Line)),
+				OriginalEndToEndID:    item.EndToEndID,
+				TransactionStatus:     pain002TransactionStatus(item.Status),
+				AdditionalInformation: truncateString(item.FailureReason.String, 105),
+			}
+			if item.ProcessedAt.Valid && item.Status == db.PaymentBatchItemCompleted {
+				tx.AcceptanceDateTime = item.ProcessedAt.Time.UTC().Format(time.RFC3339)
+			}
+			stream.element("TxInfAndSts", tx)
+		}
+		if err := stream.flush(); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+
+		if len(items) < paymentBatchReportSize {
+			break
+		}
+		last := items[len(items)-1]
+		arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = sql.NullInt64{Int64: last.ID, Valid: true}
+	}
+
+	stream.end("OrgnlPmtInfAndSts", "CstmrPmtStsRpt", "Document")
+	return stream.flush()
+}
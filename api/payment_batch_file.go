@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/joekings2k/gobank/util"
+)
+
+const (
+	// maxPaymentBatchItems caps how many instructions one upload can hold
+	maxPaymentBatchItems = 10000
+	// maxPaymentBatchBytes caps the size of an uploaded file
+	maxPaymentBatchBytes = 10 << 20
+	// maxEndToEndIDLength is the pain.001 limit for EndToEndId
+	maxEndToEndIDLength = 35
+)
+
+// paymentInstruction is one transfer read from an uploaded file, before the accounts are checked
+type paymentInstruction struct {
+	Line          int32
+	EndToEndID    string
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+}
+
+type paymentFile struct {
+	MessageID    string
+	Instructions []paymentInstruction
+}
+
+// paymentFileError is a problem with the file itself, which rejects the whole upload
+type paymentFileError struct {
+	Line int32
+	Err  error
+}
+
+func (err *paymentFileError) Error() string {
+	if err.Line == 0 {
+		return err.Err.Error()
+	}
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+func (err *paymentFileError) Unwrap() error {
+	return err.Err
+}
+
+// pain.001.001.03 elements the bank reads. Names are matched without their namespace,
+// so later pain.001 versions with the same layout are read too
+type pain001Document struct {
+	GroupHeader struct {
+		MessageID            string `xml:"MsgId"`
+		NumberOfTransactions string `xml:"NbOfTxs"`
+		ControlSum           string `xml:"CtrlSum"`
+	} `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PaymentInformation []struct {
+		DebtorAccount pain001Account `xml:"DbtrAcct"`
+		Transactions  []struct {
+			EndToEndID       string `xml:"PmtId>EndToEndId"`
+			InstructedAmount struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"Amt>InstdAmt"`
+			CreditorAccount pain001Account `xml:"CdtrAcct"`
+		} `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type pain001Account struct {
+	IBAN  string `xml:"Id>IBAN"`
+	Other string `xml:"Id>Othr>Id"`
+}
+
+// accountID reads the account number, the bank has no IBANs so only Othr/Id is accepted
+func (account pain001Account) accountID() (int64, error) {
+	if account.Other == "" {
+		if account.IBAN != "" {
+			return 0, fmt.Errorf("IBAN %s is not an account at this bank, use Othr/Id with the account number", account.IBAN)
+		}
+		return 0, errors.New("account number is missing")
+	}
+	return parseAccountID(account.Other)
+}
+
+func parseAccountID(s string) (int64, error) {
+	id, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid account number %q", s)
+	}
+	return id, nil
+}
+
+// parsePain001 reads the credit transfers out of a pain.001 customer credit transfer initiation.
+// NbOfTxs and CtrlSum, when given, have to match the transactions
+func parsePain001(r io.Reader) (paymentFile, error) {
+	var doc pain001Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return paymentFile{}, &paymentFileError{Err: fmt.Errorf("invalid pain.001 document: %w", err)}
+	}
+	file := paymentFile{MessageID: strings.TrimSpace(doc.GroupHeader.MessageID)}
+	if file.MessageID == "" {
+		return file, &paymentFileError{Err: errors.New("GrpHdr/MsgId is missing")}
+	}
+
+	var controlSum int64
+	for _, info := range doc.PaymentInformation {
+		fromAccountID, err := info.DebtorAccount.accountID()
+		if err != nil {
+			return file, &paymentFileError{Line: int32(len(file.Instructions) + 1), Err: fmt.Errorf("debtor account: %w", err)}
+		}
+		for _, tx := range info.Transactions {
+			line := int32(len(file.Instructions) + 1)
+			if line > maxPaymentBatchItems {
+				return file, &paymentFileError{Err: fmt.Errorf("a batch can hold at most %d transactions", maxPaymentBatchItems)}
+			}
+			toAccountID, err := tx.CreditorAccount.accountID()
+			if err != nil {
+				return file, &paymentFileError{Line: line, Err: fmt.Errorf("creditor account: %w", err)}
+			}
+			amount, err := util.ParseAmount(strings.TrimSpace(tx.InstructedAmount.Value))
+			if err != nil {
+				return file, &paymentFileError{Line: line, Err: err}
+			}
+			endToEndID := strings.TrimSpace(tx.EndToEndID)
+			if len(endToEndID) > maxEndToEndIDLength {
+				return file, &paymentFileError{Line: line, Err: fmt.Errorf("EndToEndId is longer than %d characters", maxEndToEndIDLength)}
+			}
+			controlSum += amount
+			file.Instructions = append(file.Instructions, paymentInstruction{
+				Line:          line,
+				EndToEndID:    endToEndID,
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+				Currency:      strings.TrimSpace(tx.InstructedAmount.Currency),
+			})
+		}
+	}
+
+	if n := strings.TrimSpace(doc.GroupHeader.NumberOfTransactions); n != "" && n != strconv.Itoa(len(file.Instructions)) {
+		return file, &paymentFileError{Err: fmt.Errorf("NbOfTxs is %s, the document has %d transactions", n, len(file.Instructions))}
+	}
+	if sum := strings.TrimSpace(doc.GroupHeader.ControlSum); sum != "" {
+		expected, err := util.ParseAmount(sum)
+		if err != nil || expected != controlSum {
+			return file, &paymentFileError{Err: fmt.Errorf("CtrlSum is %s, the transactions add up to %s", sum, util.FormatAmount(controlSum))}
+		}
+	}
+	return file, nil
+}
+
+// paymentCSVHeader is the header a CSV upload starts with, end_to_end_id is optional
+var paymentCSVHeader = []string{"from_account_id", "to_account_id", "amount", "currency", "end_to_end_id"}
+
+// parsePaymentCSV reads one transfer per row after the header. Amounts are decimals like in pain.001
+func parsePaymentCSV(r io.Reader) (paymentFile, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return paymentFile{}, &paymentFileError{Err: fmt.Errorf("invalid CSV header: %w", err)}
+	}
+	if len(header) < 4 || len(header) > len(paymentCSVHeader) {
+		return paymentFile{}, &paymentFileError{Err: fmt.Errorf("CSV header must be %s", strings.Join(paymentCSVHeader, ","))}
+	}
+	for i, column := range header {
+		if strings.TrimSpace(strings.ToLower(column)) != paymentCSVHeader[i] {
+			return paymentFile{}, &paymentFileError{Err: fmt.Errorf("CSV header must be %s", strings.Join(paymentCSVHeader, ","))}
+		}
+	}
+
+	var file paymentFile
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line := int32(len(file.Instructions) + 1)
+		if err != nil {
+			return file, &paymentFileError{Line: line, Err: err}
+		}
+		if line > maxPaymentBatchItems {
+			return file, &paymentFileError{Err: fmt.Errorf("a batch can hold at most %d transactions", maxPaymentBatchItems)}
+		}
+		if len(record) != len(header) {
+			return file, &paymentFileError{Line: line, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(record))}
+		}
+
+		instruction := paymentInstruction{Line: line, Currency: strings.TrimSpace(record[3])}
+		if instruction.FromAccountID, err = parseAccountID(record[0]); err != nil {
+			return file, &paymentFileError{Line: line, Err: fmt.Errorf("from_account_id: %w", err)}
+		}
+		if instruction.ToAccountID, err = parseAccountID(record[1]); err != nil {
+			return file, &paymentFileError{Line: line, Err: fmt.Errorf("to_account_id: %w", err)}
+		}
+		if instruction.Amount, err = util.ParseAmount(strings.TrimSpace(record[2])); err != nil {
+			return file, &paymentFileError{Line: line, Err: err}
+		}
+		if len(record) > 4 {
+			instruction.EndToEndID = strings.TrimSpace(record[4])
+			if len(instruction.EndToEndID) > maxEndToEndIDLength {
+				return file, &paymentFileError{Line: line, Err: fmt.Errorf("end_to_end_id is longer than %d characters", maxEndToEndIDLength)}
+			}
+		}
+		file.Instructions = append(file.Instructions, instruction)
+	}
+	return file, nil
+}
@@ -0,0 +1,402 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+// pain001 builds a pain.001.001.03 document paying amounts from one account to others
+func pain001(messageID string, nbOfTxs string, ctrlSum string, fromAccountID int64, txs ...string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>%s</MsgId><CreDtTm>2024-01-31T09:00:00</CreDtTm><NbOfTxs>%s</NbOfTxs><CtrlSum>%s</CtrlSum></GrpHdr>
+    <PmtInf>
+      <PmtInfId>PAYROLL</PmtInfId>
+      <DbtrAcct><Id><Othr><Id>%d</Id></Othr></Id></DbtrAcct>
+      %s
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`, messageID, nbOfTxs, ctrlSum, fromAccountID, strings.Join(txs, "\n"))
+}
+
+func pain001Tx(endToEndID string, amount string, currency string, toAccount string) string {
+	return fmt.Sprintf(`<CdtTrfTxInf><PmtId><EndToEndId>%s</EndToEndId></PmtId>`+
+		`<Amt><InstdAmt Ccy="%s">%s</InstdAmt></Amt><CdtrAcct><Id>%s</Id></CdtrAcct></CdtTrfTxInf>`,
+		endToEndID, currency, amount, toAccount)
+}
+
+func othr(accountID int64) string {
+	return fmt.Sprintf("<Othr><Id>%d</Id></Othr>", accountID)
+}
+
+func TestCreatePaymentBatch(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account1.Currency = util.USD
+	account2 := randomAccount(user2.Username)
+	account3 := randomAccount(user2.Username)
+	batch := db.PaymentBatch{ID: 1, Owner: user1.Username, Format: db.PaymentBatchPain001, MessageID: "MSG-1", CreatedAt: time.Now()}
+
+	// echoes the items back the way the database stores them
+	createBatch := func(store *mockdb.MockStore, check func(arg db.CreatePaymentBatchTxParams)) {
+		store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_ interface{}, arg db.CreatePaymentBatchTxParams) (db.CreatePaymentBatchTxResult, error) {
+				check(arg)
+				result := db.CreatePaymentBatchTxResult{Batch: batch}
+				for i, params := range arg.Items {
+					result.Items = append(result.Items, db.PaymentBatchItem{ID: int64(i + 1), Line: params.Line, Status: params.Status})
+				}
+				return result, nil
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		contentType   string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "Pain001",
+			contentType: "application/xml",
+			body: pain001("MSG-1", "2", "15.50", account1.ID,
+				pain001Tx("E2E-1", "10.00", util.USD, othr(account2.ID)),
+				pain001Tx("E2E-2", "5.5", util.USD, othr(account3.ID))),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				createBatch(store, func(arg db.CreatePaymentBatchTxParams) {
+					require.Equal(t, user1.Username, arg.Owner)
+					require.Equal(t, db.PaymentBatchPain001, arg.Format)
+					require.Equal(t, "MSG-1", arg.MessageID)
+					require.Equal(t, []db.CreatePaymentBatchItemParams{
+						{Line: 1, EndToEndID: "E2E-1", FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 1000, Currency: util.USD, Status: db.PaymentBatchItemPending},
+						{Line: 2, EndToEndID: "E2E-2", FromAccountID: account1.ID, ToAccountID: account3.ID, Amount: 550, Currency: util.USD, Status: db.PaymentBatchItemPending},
+					}, arg.Items)
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var response paymentBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, batch.ID, response.ID)
+				require.Equal(t, paymentBatchProcessing, response.Status)
+				require.Equal(t, paymentBatchCounts{Total: 2, Pending: 2}, response.Items)
+			},
+		},
+		{
+			name:        "CSVWithRejectedLines",
+			contentType: "text/csv; charset=utf-8",
+			body: "from_account_id,to_account_id,amount,currency\n" +
+				fmt.Sprintf("%d,%d,1.00,USD\n", account1.ID, account2.ID) +
+				fmt.Sprintf("%d,%d,1.00,EUR\n", account1.ID, account2.ID) +
+				fmt.Sprintf("%d,%d,1.00,USD\n", account2.ID, account1.ID) +
+				fmt.Sprintf("%d,%d,1.00,USD\n", account1.ID, 999999) +
+				fmt.Sprintf("%d,%d,1.00,GBP\n", account1.ID, account2.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(999999))).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				createBatch(store, func(arg db.CreatePaymentBatchTxParams) {
+					require.Equal(t, db.PaymentBatchCSV, arg.Format)
+					require.Empty(t, arg.MessageID)
+					require.Len(t, arg.Items, 5)
+					require.Equal(t, db.PaymentBatchItemPending, arg.Items[0].Status)
+					for _, item := range arg.Items[1:] {
+						require.Equal(t, db.PaymentBatchItemRejected, item.Status)
+					}
+					require.Contains(t, arg.Items[1].FailureReason.String, "currency mismatch")
+					require.Contains(t, arg.Items[2].FailureReason.String, "doesn`t belong")
+					require.Contains(t, arg.Items[3].FailureReason.String, "not found")
+					require.Contains(t, arg.Items[4].FailureReason.String, "unsupported currency")
+				})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusAccepted, recorder.Code)
+				var response paymentBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, paymentBatchCounts{Total: 5, Pending: 1, Rejected: 4}, response.Items)
+			},
+		},
+		{
+			name:        "ControlSumMismatch",
+			contentType: "application/xml",
+			body: pain001("MSG-1", "1", "20.00", account1.ID,
+				pain001Tx("E2E-1", "10.00", util.USD, othr(account2.ID))),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "CtrlSum")
+			},
+		},
+		{
+			name:        "IBANCreditor",
+			contentType: "application/xml",
+			body: pain001("MSG-1", "", "", account1.ID,
+				pain001Tx("E2E-1", "10.00", util.USD, othr(account2.ID)),
+				pain001Tx("E2E-2", "10.00", util.USD, "<IBAN>DE89370400440532013000</IBAN>")),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "line 2")
+			},
+		},
+		{
+			name:        "InvalidCSVAmount",
+			contentType: "text/csv",
+			body:        fmt.Sprintf("from_account_id,to_account_id,amount,currency\n%d,%d,1.005,USD\n", account1.ID, account2.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "line 1")
+			},
+		},
+		{
+			name:        "Empty",
+			contentType: "text/csv",
+			body:        "from_account_id,to_account_id,amount,currency\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "UnsupportedMediaType",
+			contentType: "application/json",
+			body:        "{}",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:        "DuplicateMessageID",
+			contentType: "application/xml",
+			body: pain001("MSG-1", "1", "10.00", account1.ID,
+				pain001Tx("E2E-1", "10.00", util.USD, othr(account2.ID))),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.CreatePaymentBatchTxResult{}, &pq.Error{Code: "23505", Constraint: "payment_batches_owner_message_id_idx"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				require.Contains(t, recorder.Body.String(), duplicateMessageIDCode)
+			},
+		},
+		{
+			name:        "InternalError",
+			contentType: "text/csv",
+			body:        fmt.Sprintf("from_account_id,to_account_id,amount,currency\n%d,%d,1.00,USD\n", account1.ID, account2.ID),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+				store.EXPECT().CreatePaymentBatchTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/payment-batches", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", tc.contentType)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, user1.Role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetPaymentBatch(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	batch := db.PaymentBatch{ID: util.RandomInt(1, 1000), Owner: user1.Username, Format: db.PaymentBatchCSV}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "PartiallyCompleted",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().CountPaymentBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return([]db.CountPaymentBatchItemsRow{
+					{Status: db.PaymentBatchItemCompleted, Count: 8},
+					{Status: db.PaymentBatchItemFailed, Count: 1},
+					{Status: db.PaymentBatchItemRejected, Count: 1},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var response paymentBatchResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+				require.Equal(t, paymentBatchPartiallyCompleted, response.Status)
+				require.Equal(t, paymentBatchCounts{Total: 10, Completed: 8, Failed: 1, Rejected: 1}, response.Items)
+			},
+		},
+		{
+			name:     "Banker",
+			username: user2.Username,
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().CountPaymentBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return([]db.CountPaymentBatchItemsRow{
+					{Status: db.PaymentBatchItemCompleted, Count: 2},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), paymentBatchCompleted)
+			},
+		},
+		{
+			name:     "Unauthorized",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+				store.EXPECT().CountPaymentBatchItems(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetPaymentBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(db.PaymentBatch{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/payment-batches/%d", batch.ID), nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetPaymentBatchReport(t *testing.T) {
+	user, _ := ramdomUser(t)
+	batch := db.PaymentBatch{ID: util.RandomInt(1, 1000), Owner: user.Username, Format: db.PaymentBatchPain001, MessageID: "MSG-1"}
+	items := []db.PaymentBatchItem{
+		{ID: 1, BatchID: batch.ID, Line: 1, EndToEndID: "E2E-1", Status: db.PaymentBatchItemCompleted,
+			ProcessedAt: sql.NullTime{Time: time.Now(), Valid: true}},
+		{ID: 2, BatchID: batch.ID, Line: 2, EndToEndID: "E2E-2", Status: db.PaymentBatchItemFailed,
+			FailureReason: sql.NullString{String: db.ErrInsufficientFunds.Error(), Valid: true}},
+		{ID: 3, BatchID: batch.ID, Line: 3, Status: db.PaymentBatchItemPending},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetPaymentBatch(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return(batch, nil)
+	store.EXPECT().CountPaymentBatchItems(gomock.Any(), gomock.Eq(batch.ID)).Times(1).Return([]db.CountPaymentBatchItemsRow{
+		{Status: db.PaymentBatchItemCompleted, Count: 1},
+		{Status: db.PaymentBatchItemFailed, Count: 1},
+		{Status: db.PaymentBatchItemPending, Count: 1},
+	}, nil)
+	arg := db.ListPaymentBatchItemsParams{BatchID: batch.ID, Limit: paymentBatchReportSize}
+	store.EXPECT().ListPaymentBatchItems(gomock.Any(), gomock.Eq(arg)).Times(1).Return(items, nil)
+	expectTokenNotRevoked(store)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/payment-batches/%d/report", batch.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, user.Role, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var report struct {
+		OriginalMessageID string `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>OrgnlMsgId"`
+		NumberOfTxs       int    `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>OrgnlNbOfTxs"`
+		GroupStatus       string `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts>GrpSts"`
+		Transactions      []struct {
+			StatusID   string `xml:"StsId"`
+			EndToEndID string `xml:"OrgnlEndToEndId"`
+			Status     string `xml:"TxSts"`
+			Reason     string `xml:"StsRsnInf>AddtlInf"`
+		} `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts>TxInfAndSts"`
+	}
+	require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &report))
+	require.Equal(t, "MSG-1", report.OriginalMessageID)
+	require.Equal(t, 3, report.NumberOfTxs)
+	require.Equal(t, "ACSP", report.GroupStatus)
+	require.Len(t, report.Transactions, 3)
+	require.Equal(t, "ACSC", report.Transactions[0].Status)
+	require.Equal(t, "E2E-1", report.Transactions[0].EndToEndID)
+	require.Equal(t, "RJCT", report.Transactions[1].Status)
+	require.Equal(t, db.ErrInsufficientFunds.Error(), report.Transactions[1].Reason)
+	require.Equal(t, "3", report.Transactions[2].StatusID)
+	require.Equal(t, "PDNG", report.Transactions[2].Status)
+}
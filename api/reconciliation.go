@@ -0,0 +1,86 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/worker"
+)
+
+type runReconciliationRequest struct {
+	// Save stores the report, signed, so it can be fetched again later
+	Save bool `json:"save"`
+}
+
+type runReconciliationResponse struct {
+	Balanced bool                        `json:"balanced"`
+	Report   worker.ReconciliationReport `json:"report"`
+	Saved    *db.ReconciliationReport    `json:"saved,omitempty"`
+}
+
+func (server *Server) reconciler() *worker.Reconciler {
+	return worker.NewReconciler(server.store, server.config.ReportSigningKey, server.config.ReconciliationBatchSize)
+}
+
+// runReconciliation checks every account and transfer against the ledger, it is only routed for admins
+func (server *Server) runReconciliation(ctx *gin.Context) {
+	var req runReconciliationRequest
+	// the body is optional
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	reconciler := server.reconciler()
+	report, err := reconciler.Reconcile(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	rsp := runReconciliationResponse{
+		Balanced: report.Balanced(),
+		Report:   report,
+	}
+	if req.Save {
+		saved, err := reconciler.Save(ctx, report)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+		rsp.Saved = &saved
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type getReconciliationRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getReconciliationResponse struct {
+	db.ReconciliationReport
+	// SignatureValid is false when the stored report no longer matches its signature
+	SignatureValid bool `json:"signature_valid"`
+}
+
+func (server *Server) getReconciliation(ctx *gin.Context) {
+	var req getReconciliationRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	saved, err := server.store.GetReconciliationReport(ctx, req.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, getReconciliationResponse{
+		ReconciliationReport: saved,
+		SignatureValid:       server.reconciler().Verify(saved),
+	})
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/joekings2k/gobank/worker"
+	"github.com/stretchr/testify/require"
+)
+
+func expectLedgerTotals(store *mockdb.MockStore, accounts []db.ListAccountLedgerTotalsRow, transfers []db.ListTransferEntryTotalsRow) {
+	store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Any()).Times(1).Return(accounts, nil)
+	store.EXPECT().ListTransferEntryTotals(gomock.Any(), gomock.Any()).Times(1).Return(transfers, nil)
+}
+
+func TestRunReconciliation(t *testing.T) {
+	balanced := []db.ListAccountLedgerTotalsRow{{ID: 1, Balance: 10, AvailableBalance: 10, EntriesTotal: 10}}
+	drifted := []db.ListAccountLedgerTotalsRow{{ID: 1, Balance: 10, AvailableBalance: 10, EntriesTotal: 5}}
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Balanced",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLedgerTotals(store, balanced, nil)
+				store.EXPECT().CreateReconciliationReport(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got runReconciliationResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.True(t, got.Balanced)
+				require.Equal(t, int64(1), got.Report.AccountsChecked)
+				require.Nil(t, got.Saved)
+			},
+		},
+		{
+			name: "DriftSaved",
+			role: util.AdminRole,
+			body: `{"save": true}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectLedgerTotals(store, drifted, nil)
+				store.EXPECT().CreateReconciliationReport(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateReconciliationReportParams) (db.ReconciliationReport, error) {
+						require.Equal(t, int64(1), arg.AccountDrifts)
+						require.NotEmpty(t, arg.Signature)
+						return db.ReconciliationReport{ID: 7, AccountDrifts: arg.AccountDrifts, Report: arg.Report, Signature: arg.Signature}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got runReconciliationResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.False(t, got.Balanced)
+				require.Len(t, got.Report.AccountDrifts, 1)
+				require.NotNil(t, got.Saved)
+				require.Equal(t, int64(7), got.Saved.ID)
+			},
+		},
+		{
+			name: "Forbidden",
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidBody",
+			role: util.AdminRole,
+			body: `{"save": "yes"}`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/admin/reconciliations", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", tc.role, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetReconciliation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	// sign a report the way the server does, so the stored signature is valid
+	var saved db.ReconciliationReport
+	store.EXPECT().CreateReconciliationReport(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ interface{}, arg db.CreateReconciliationReportParams) (db.ReconciliationReport, error) {
+			saved = db.ReconciliationReport{ID: 3, Report: arg.Report, Signature: arg.Signature}
+			return saved, nil
+		})
+	reconciler := worker.NewReconciler(store, server.config.ReportSigningKey, 0)
+	_, err := reconciler.Save(t.Context(), worker.ReconciliationReport{})
+	require.NoError(t, err)
+	tampered := saved
+	tampered.Report = json.RawMessage(`{"accounts_checked":0}`)
+
+	testCases := []struct {
+		name          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			id:   saved.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationReport(gomock.Any(), gomock.Eq(saved.ID)).Times(1).Return(saved, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got getReconciliationResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, saved.ID, got.ID)
+				require.True(t, got.SignatureValid)
+			},
+		},
+		{
+			name: "Tampered",
+			id:   saved.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationReport(gomock.Any(), gomock.Eq(saved.ID)).Times(1).Return(tampered, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got getReconciliationResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.False(t, got.SignatureValid)
+			},
+		},
+		{
+			name: "NotFound",
+			id:   saved.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationReport(gomock.Any(), gomock.Eq(saved.ID)).Times(1).Return(db.ReconciliationReport{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetReconciliationReport(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			recorder := httptest.NewRecorder()
+			url := fmt.Sprintf("/admin/reconciliations/%d", tc.id)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", util.AdminRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+)
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64     `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64     `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64     `json:"amount" binding:"required,gt=0"`
+	Currency      string    `json:"currency" binding:"required,currency"`
+	ExecuteAt     time.Time `json:"execute_at" binding:"required"`
+}
+
+// createScheduledTransfer books a transfer for a future time. The accounts are checked now,
+// funds are only checked when the scheduler executes it
+func (server *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	if !req.ExecuteAt.After(time.Now()) {
+		writeError(ctx, invalidField("execute_at", "future", "must be in the future"))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, newAPIError(notOwnerCode, "from account doesn`t belong to the authenticated user"))
+		return
+	}
+	if _, valid = server.loadAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	scheduled, err := server.store.CreateScheduledTransfer(ctx, db.CreateScheduledTransferParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		ExecuteAt:     req.ExecuteAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+type listScheduledTransfersRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listScheduledTransfersResponse struct {
+	ScheduledTransfers []db.ScheduledTransfer `json:"scheduled_transfers"`
+	NextCursor         string                 `json:"next_cursor,omitempty"`
+}
+
+// listScheduledTransfers lists the authenticated user's scheduled transfers, newest first
+func (server *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	page, ok := server.bindPagination(ctx, "scheduled_transfers:"+authPayload.Username, req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
+	}
+
+	scheduled, err := server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+		Owner:           authPayload.Username,
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, scheduled)
+		return
+	}
+
+	scheduled, next, err := keysetPage(server, page, scheduled, func(scheduled db.ScheduledTransfer) (time.Time, int64) {
+		return scheduled.CreatedAt, scheduled.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listScheduledTransfersResponse{ScheduledTransfers: scheduled, NextCursor: next})
+}
+
+type scheduledTransferUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// accessibleScheduledTransfer gets a scheduled transfer booked by the authenticated user,
+// or any for bankers and admins
+func (server *Server) accessibleScheduledTransfer(ctx *gin.Context) (db.ScheduledTransfer, bool) {
+	var uri scheduledTransferUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return db.ScheduledTransfer{}, false
+	}
+	scheduled, err := server.store.GetScheduledTransfer(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return scheduled, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if scheduled.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx, newAPIError(notOwnerCode, "scheduled transfer doesn`t belong to the authenticated user"))
+		return scheduled, false
+	}
+	return scheduled, true
+}
+
+func (server *Server) getScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := server.accessibleScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, scheduled)
+}
+
+// cancelScheduledTransfer cancels a scheduled transfer that hasn't been executed yet
+func (server *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	scheduled, ok := server.accessibleScheduledTransfer(ctx)
+	if !ok {
+		return
+	}
+
+	cancelled, err := server.store.CancelScheduledTransfer(ctx, scheduled.ID)
+	if err != nil {
+		// the scheduler got to it first, or it was already cancelled
+		if err == sql.ErrNoRows {
+			writeError(ctx, apiErrorf(notPendingCode, "scheduled transfer [%d] is no longer pending", scheduled.ID))
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, cancelled)
+}
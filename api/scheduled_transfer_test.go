@@ -0,0 +1,297 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.EUR
+	executeAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateScheduledTransferParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					ExecuteAt:     executeAt,
+				}
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.ScheduledTransfer{ID: 1, Owner: user1.Username, Status: db.ScheduledTransferPending}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "ExecuteAtInPast",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      time.Now().Add(-time.Minute),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingExecuteAt",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.EUR,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "ToAccountNotFound",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"execute_at":      executeAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/scheduled-transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	user, _ := ramdomUser(t)
+	scheduled := db.ScheduledTransfer{
+		ID:     util.RandomInt(1, 1000),
+		Owner:  user.Username,
+		Amount: 10,
+		Status: db.ScheduledTransferPending,
+	}
+	cancelled := scheduled
+	cancelled.Status = db.ScheduledTransferCancelled
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.ScheduledTransfer
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.ScheduledTransferCancelled, got.Status)
+			},
+		},
+		{
+			name:     "NoLongerPending",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(db.ScheduledTransfer{}, sql.ErrNoRows)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled-transfers/%d/cancel", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListScheduledTransfers(t *testing.T) {
+	user, _ := ramdomUser(t)
+	scheduled := []db.ScheduledTransfer{
+		{ID: 2, Owner: user.Username, Status: db.ScheduledTransferPending},
+		{ID: 1, Owner: user.Username, Status: db.ScheduledTransferCompleted},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	expectTokenNotRevoked(store)
+	store.EXPECT().ListScheduledTransfers(gomock.Any(), gomock.Eq(db.ListScheduledTransfersParams{
+		Owner: user.Username,
+		Limit: 6,
+	})).Times(1).Return(scheduled, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/scheduled-transfers?page_size=5", nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got listScheduledTransfersResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, scheduled, got.ScheduledTransfers)
+	require.Empty(t, got.NextCursor)
+}
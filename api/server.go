@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/logging"
+	"github.com/joekings2k/gobank/metrics"
 	"github.com/joekings2k/gobank/token"
 	"github.com/joekings2k/gobank/util"
 )
@@ -17,9 +26,15 @@ type Server  struct {
 	store db.Store
 	tokenMaker token.Maker
 	router *gin.Engine
+	logger *slog.Logger
+	// openAPISpec is the OpenAPI document served at /openapi.json
+	openAPISpec []byte
+	// shuttingDown fails the readiness check while the server drains its requests
+	shuttingDown atomic.Bool
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error ) {
+// NewServer creates the HTTP server, logger gets a line per request and the panics of handlers
+func NewServer(config util.Config, store db.Store, logger *slog.Logger) (*Server, error ) {
 	tokenMaker,err := token.NewPasetoMaker(config.TokenSymmeticKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err) 
@@ -28,41 +43,135 @@ func NewServer(config util.Config, store db.Store) (*Server, error ) {
 		config: config,
 		store: store,
 		tokenMaker: tokenMaker,
+		logger: logger,
+	}
+	server.openAPISpec, err = openAPISpec()
+	if err != nil {
+		return nil, err
 	}
 	
 	if v,ok :=binding.Validator.Engine().(*validator.Validate);ok {
 		v.RegisterValidation("currency",validCurrency)
+		v.RegisterValidation("role",validRole)
+		v.RegisterValidation("recurrence",validRecurrence)
+		v.RegisterTagNameFunc(bindingFieldName)
 	}
 	server.setupRouter()
 	return server, nil
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
-	// check health
-	router.GET("/",server.checkHealth )
+	router := gin.New()
+	// ctx hands the store the deadline, trace and logger of the request
+	router.ContextWithFallback = true
+	router.Use(requestIDMiddleware(), tracingMiddleware(), loggingMiddleware(server.logger), metricsMiddleware())
+	router.Use(gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		logging.FromContext(ctx).ErrorContext(ctx, "handler panicked", "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+		writeError(ctx, fmt.Errorf("panic: %v", recovered))
+	}))
+	// probes
+	router.GET("/livez",server.checkLiveness)
+	router.GET("/readyz",server.checkReadiness)
+	router.GET("/metrics",gin.WrapH(metrics.Handler()))
+
+	// api docs
+	router.GET("/openapi.json",server.getOpenAPISpec)
+	router.GET("/docs/*filepath",server.serveDocs)
  
 	//user routes 
 	router.POST("/users",server.createUser)
 	router.POST("/users/login",server.loginUser)
+	router.POST("/tokens/renew_access",server.renewAccessToken)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store))
+
+	authRoutes.POST("/users/logout",server.logoutUser)
 
 	authRoutes.POST("/accounts",server.createAccount)
 	authRoutes.GET("/accounts/:id",server.getAccount )
 	authRoutes.GET("/accounts",server.ListAccounts )
-	authRoutes.PATCH("/accounts/:id",server.updateAccount)
 	authRoutes.DELETE("/accounts/:id",server.deleteAccount)
+	authRoutes.GET("/accounts/:id/transfers",server.listAccountTransfers)
+	authRoutes.GET("/accounts/:id/entries",server.listAccountEntries)
+	authRoutes.GET("/accounts/:id/balance",server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statement",server.getAccountStatement)
 
 	// transfers
 	authRoutes.POST("/transfers", server.createTransfer)
+	authRoutes.GET("/transfers/:id", server.getTransfer)
+	authRoutes.POST("/transfers/:id/refund", server.refundTransfer)
+	authRoutes.POST("/transfers/authorize", server.authorizeTransfer)
+	authRoutes.POST("/transfers/:id/capture", server.captureTransfer)
+	authRoutes.POST("/transfers/:id/void", server.voidTransfer)
+
+	// scheduled transfers
+	authRoutes.POST("/scheduled-transfers", server.createScheduledTransfer)
+	authRoutes.GET("/scheduled-transfers", server.listScheduledTransfers)
+	authRoutes.GET("/scheduled-transfers/:id", server.getScheduledTransfer)
+	authRoutes.POST("/scheduled-transfers/:id/cancel", server.cancelScheduledTransfer)
+
+	// standing orders
+	authRoutes.POST("/standing-orders", server.createStandingOrder)
+	authRoutes.GET("/standing-orders", server.listStandingOrders)
+	authRoutes.GET("/standing-orders/:id", server.getStandingOrder)
+	authRoutes.POST("/standing-orders/:id/cancel", server.cancelStandingOrder)
+	authRoutes.GET("/standing-orders/:id/executions", server.listStandingOrderExecutions)
+
+	// bulk payments
+	authRoutes.POST("/payment-batches", server.createPaymentBatch)
+	authRoutes.GET("/payment-batches", server.listPaymentBatches)
+	authRoutes.GET("/payment-batches/:id", server.getPaymentBatch)
+	authRoutes.GET("/payment-batches/:id/items", server.listPaymentBatchItems)
+	authRoutes.GET("/payment-batches/:id/report", server.getPaymentBatchReport)
+
+	// bankers and admins
+	bankerRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.store, util.BankerRole, util.AdminRole))
+	bankerRoutes.PATCH("/accounts/:id",server.updateAccount)
+	bankerRoutes.PUT("/accounts/:id/overdraft_limit",server.updateOverdraftLimit)
+	bankerRoutes.POST("/transfers/:id/reverse",server.reverseTransfer)
+
+	// admin
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker, server.store, util.AdminRole))
+	adminRoutes.GET("/users/:username",server.getUser)
+	adminRoutes.PATCH("/users/:username/role",server.updateUserRole)
+	adminRoutes.POST("/users/:username/revoke_tokens",server.revokeUserTokens)
+	adminRoutes.POST("/exchange_rates",server.createExchangeRates)
+	adminRoutes.POST("/reconciliations",server.runReconciliation)
+	adminRoutes.GET("/reconciliations/:id",server.getReconciliation)
 	server.router = router
 }
 
-func (server *Server) Start(addres string)error{
-	return server.router.Run(addres)
-}
+// Start serves HTTP on address until ctx is cancelled, then stops accepting connections and waits for the
+// requests in flight to finish, for at most config.ShutdownTimeout when it is set
+func (server *Server) Start(ctx context.Context, address string)error{
+	httpServer := &http.Server{
+		Addr: address,
+		Handler: server.router,
+	}
+	served := make(chan error, 1)
+	go func() {
+		served <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
 
-func errorResponse (err error) gin.H{
-	return  gin.H{"error":err.Error()}
+	server.shuttingDown.Store(true)
+	shutdownCtx := context.WithoutCancel(ctx)
+	if server.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		shutdownCtx, cancel = context.WithTimeout(shutdownCtx, server.config.ShutdownTimeout)
+		defer cancel()
+	}
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("cannot drain requests: %w", err)
+	}
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
+
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+)
+
+type createStandingOrderRequest struct {
+	FromAccountID  int64      `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID    int64      `json:"to_account_id" binding:"required,min=1"`
+	Amount         int64      `json:"amount" binding:"required,gt=0"`
+	Currency       string     `json:"currency" binding:"required,currency"`
+	Recurrence     string     `json:"recurrence" binding:"required,recurrence"`
+	StartAt        time.Time  `json:"start_at" binding:"required"`
+	EndAt          *time.Time `json:"end_at"`
+	MaxOccurrences *int32     `json:"max_occurrences" binding:"omitempty,min=1"`
+}
+
+// createStandingOrder sets up a recurring transfer. The first occurrence runs at start_at, the series
+// ends at end_at or after max_occurrences, or runs until cancelled when neither is given
+func (server *Server) createStandingOrder(ctx *gin.Context) {
+	var req createStandingOrderRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	if !req.StartAt.After(time.Now()) {
+		writeError(ctx, invalidField("start_at", "future", "must be in the future"))
+		return
+	}
+	if req.EndAt != nil && !req.EndAt.After(req.StartAt) {
+		writeError(ctx, invalidField("end_at", "gtfield", "must be after start_at"))
+		return
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, newAPIError(notOwnerCode, "from account doesn`t belong to the authenticated user"))
+		return
+	}
+	if _, valid = server.loadAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	arg := db.CreateStandingOrderParams{
+		Owner:         authPayload.Username,
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		Recurrence:    req.Recurrence,
+		StartAt:       req.StartAt,
+	}
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{Time: *req.EndAt, Valid: true}
+	}
+	if req.MaxOccurrences != nil {
+		arg.MaxOccurrences = sql.NullInt32{Int32: *req.MaxOccurrences, Valid: true}
+	}
+	order, err := server.store.CreateStandingOrder(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+type listStandingOrdersRequest struct {
+	PageID   int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=10"`
+	Cursor   string `form:"cursor"`
+}
+
+type listStandingOrdersResponse struct {
+	StandingOrders []db.StandingOrder `json:"standing_orders"`
+	NextCursor     string             `json:"next_cursor,omitempty"`
+}
+
+// listStandingOrders lists the authenticated user's standing orders, newest first
+func (server *Server) listStandingOrders(ctx *gin.Context) {
+	var req listStandingOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	page, ok := server.bindPagination(ctx, "standing_orders:"+authPayload.Username, req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
+	}
+
+	orders, err := server.store.ListStandingOrders(ctx, db.ListStandingOrdersParams{
+		Owner:           authPayload.Username,
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, orders)
+		return
+	}
+
+	orders, next, err := keysetPage(server, page, orders, func(order db.StandingOrder) (time.Time, int64) {
+		return order.CreatedAt, order.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listStandingOrdersResponse{StandingOrders: orders, NextCursor: next})
+}
+
+type standingOrderUri struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// accessibleStandingOrder gets a standing order set up by the authenticated user,
+// or any for bankers and admins
+func (server *Server) accessibleStandingOrder(ctx *gin.Context) (db.StandingOrder, bool) {
+	var uri standingOrderUri
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return db.StandingOrder{}, false
+	}
+	order, err := server.store.GetStandingOrder(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return order, false
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if order.Owner != authPayload.Username && !util.IsPrivilegedRole(authPayload.Role) {
+		writeError(ctx, newAPIError(notOwnerCode, "standing order doesn`t belong to the authenticated user"))
+		return order, false
+	}
+	return order, true
+}
+
+func (server *Server) getStandingOrder(ctx *gin.Context) {
+	order, ok := server.accessibleStandingOrder(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, order)
+}
+
+// cancelStandingOrder stops a standing order, occurrences already run are kept
+func (server *Server) cancelStandingOrder(ctx *gin.Context) {
+	order, ok := server.accessibleStandingOrder(ctx)
+	if !ok {
+		return
+	}
+
+	cancelled, err := server.store.CancelStandingOrder(ctx, order.ID)
+	if err != nil {
+		// the series already ended, or it was already cancelled
+		if err == sql.ErrNoRows {
+			writeError(ctx, apiErrorf(notActiveCode, "standing order [%d] is no longer active", order.ID))
+			return
+		}
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, cancelled)
+}
+
+type listStandingOrderExecutionsResponse struct {
+	Executions []db.StandingOrderExecution `json:"executions"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+// listStandingOrderExecutions lists the occurrences a standing order has run, newest first,
+// including those skipped for insufficient funds
+func (server *Server) listStandingOrderExecutions(ctx *gin.Context) {
+	var req listStandingOrdersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	order, ok := server.accessibleStandingOrder(ctx)
+	if !ok {
+		return
+	}
+	page, ok := server.bindPagination(ctx, fmt.Sprintf("standing_order_executions:%d", order.ID), req.PageID, req.PageSize, req.Cursor)
+	if !ok {
+		return
+	}
+
+	executions, err := server.store.ListStandingOrderExecutions(ctx, db.ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, executions)
+		return
+	}
+
+	executions, next, err := keysetPage(server, page, executions, func(execution db.StandingOrderExecution) (time.Time, int64) {
+		return execution.CreatedAt, execution.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listStandingOrderExecutionsResponse{Executions: executions, NextCursor: next})
+}
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateStandingOrder(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	startAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	endAt := startAt.AddDate(1, 0, 0)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "RRULE:FREQ=MONTHLY;INTERVAL=1",
+				"start_at":        startAt,
+				"end_at":          endAt,
+				"max_occurrences": 6,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateStandingOrderParams{
+					Owner:          user1.Username,
+					FromAccountID:  account1.ID,
+					ToAccountID:    account2.ID,
+					Amount:         10,
+					Recurrence:     "RRULE:FREQ=MONTHLY;INTERVAL=1",
+					StartAt:        startAt,
+					EndAt:          sql.NullTime{Time: endAt, Valid: true},
+					MaxOccurrences: sql.NullInt32{Int32: 6, Valid: true},
+				}
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.StandingOrder{ID: 1, Owner: user1.Username, Status: db.StandingOrderActive}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "OpenEnded",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=WEEKLY",
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.CreateStandingOrderParams{
+					Owner:         user1.Username,
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					Recurrence:    "FREQ=WEEKLY",
+					StartAt:       startAt,
+				}
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.StandingOrder{ID: 1, Owner: user1.Username, Status: db.StandingOrderActive}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidRecurrence",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=HOURLY",
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "StartAtInPast",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=DAILY",
+				"start_at":        time.Now().Add(-time.Minute),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "EndAtBeforeStartAt",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=DAILY",
+				"start_at":        startAt,
+				"end_at":          startAt.Add(-time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidMaxOccurrences",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=DAILY",
+				"start_at":        startAt,
+				"max_occurrences": 0,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"recurrence":      "FREQ=DAILY",
+				"start_at":        startAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().CreateStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/standing-orders", bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCancelStandingOrder(t *testing.T) {
+	user, _ := ramdomUser(t)
+	order := db.StandingOrder{
+		ID:         util.RandomInt(1, 1000),
+		Owner:      user.Username,
+		Amount:     10,
+		Recurrence: "FREQ=MONTHLY",
+		Status:     db.StandingOrderActive,
+	}
+	cancelled := order
+	cancelled.Status = db.StandingOrderCancelled
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.StandingOrder
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.StandingOrderCancelled, got.Status)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker_user",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NoLongerActive",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(db.StandingOrder{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(db.StandingOrder{}, sql.ErrNoRows)
+				store.EXPECT().CancelStandingOrder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/standing-orders/%d/cancel", order.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListStandingOrderExecutions(t *testing.T) {
+	user, _ := ramdomUser(t)
+	order := db.StandingOrder{
+		ID:     util.RandomInt(1, 1000),
+		Owner:  user.Username,
+		Status: db.StandingOrderActive,
+	}
+	executions := []db.StandingOrderExecution{
+		{
+			ID:              2,
+			StandingOrderID: order.ID,
+			Occurrence:      2,
+			Status:          db.ExecutionSkipped,
+			FailureReason:   sql.NullString{String: "insufficient funds", Valid: true},
+		},
+		{
+			ID:              1,
+			StandingOrderID: order.ID,
+			Occurrence:      1,
+			Status:          db.ExecutionCompleted,
+			TransferID:      sql.NullInt64{Int64: 1, Valid: true},
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	expectTokenNotRevoked(store)
+	store.EXPECT().GetStandingOrder(gomock.Any(), gomock.Eq(order.ID)).Times(1).Return(order, nil)
+	store.EXPECT().ListStandingOrderExecutions(gomock.Any(), gomock.Eq(db.ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		Limit:           6,
+	})).Times(1).Return(executions, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	url := fmt.Sprintf("/standing-orders/%d/executions?page_size=5", order.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got listStandingOrderExecutionsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, executions, got.Executions)
+	require.Empty(t, got.NextCursor)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/logging"
+)
+
+// statementBatchSize is how many entries are read and written at a time while streaming a statement
+const statementBatchSize = 500
+
+type getStatementRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format string    `form:"format" binding:"omitempty,oneof=csv ofx camt053"`
+}
+
+// getAccountStatement streams the account's statement for [from, to): the opening balance,
+// every entry with its counterparty, and the closing balance
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri accountHistoryUri
+	var req getStatementRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	if !req.To.After(req.From) {
+		writeError(ctx, invalidField("to", "gtfield", "must be after from"))
+		return
+	}
+	if req.Format == "" {
+		req.Format = statementCSV
+	}
+
+	account, valid := server.accessibleAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	// the balances and the entries are read from one snapshot, so a transfer committing
+	// while the statement streams can't stop it adding up
+	streaming := false
+	err = server.store.ReadTx(ctx, func(q db.Querier) error {
+		// the range is half open, so the opening balance is everything before from
+		opening, err := q.GetAccountBalanceAsOf(ctx, db.GetAccountBalanceAsOfParams{
+			AsOf:      req.From.Add(-time.Microsecond),
+			AccountID: account.ID,
+		})
+		if err != nil {
+			return err
+		}
+		closing, err := q.GetAccountBalanceAsOf(ctx, db.GetAccountBalanceAsOfParams{
+			AsOf:      req.To.Add(-time.Microsecond),
+			AccountID: account.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		writer := newStatementWriter(req.Format, ctx.Writer)
+		ctx.Header("Content-Type", writer.contentType())
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%d-%s-%s.%s"`,
+			account.ID, req.From.UTC().Format("20060102"), req.To.UTC().Format("20060102"), writer.extension()))
+		ctx.Status(http.StatusOK)
+		streaming = true
+
+		return streamStatement(ctx, q, writer, statement{
+			Account:     account,
+			OwnerName:   owner.FullName,
+			From:        req.From,
+			To:          req.To,
+			Opening:     opening.Balance,
+			Closing:     closing.Balance,
+			GeneratedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		if !streaming {
+			writeError(ctx, err)
+			return
+		}
+		// the status is sent with the first bytes, so once streaming a failure can only cut the statement short
+		logging.FromContext(ctx).ErrorContext(ctx, "cannot stream statement", "account_id", account.ID, "error", err)
+		ctx.Abort()
+	}
+}
+
+// streamStatement writes s with the entries q reads for it
+func streamStatement(ctx *gin.Context, q db.Querier, writer statementWriter, s statement) error {
+	if err := writer.begin(s); err != nil {
+		return err
+	}
+
+	balance := s.Opening
+	arg := db.ListStatementEntriesParams{
+		AccountID: s.Account.ID,
+		FromTime:  s.From,
+		ToTime:    s.To,
+		Limit:     statementBatchSize,
+	}
+	for {
+		entries, err := q.ListStatementEntries(ctx, arg)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			balance += entry.Amount
+			if err := writer.entry(statementEntry{ListStatementEntriesRow: entry, Balance: balance}); err != nil {
+				return err
+			}
+		}
+		if err := writer.flush(); err != nil {
+			return err
+		}
+		ctx.Writer.Flush()
+
+		if len(entries) < statementBatchSize {
+			break
+		}
+		last := entries[len(entries)-1]
+		arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+		arg.AfterID = last.ID
+	}
+
+	// the snapshot keeps later entries out, so not adding up means the ledger itself is off
+	if balance != s.Closing {
+		return fmt.Errorf("entries add up to %d, closing balance is %d", balance, s.Closing)
+	}
+	return writer.end()
+}
@@ -0,0 +1,396 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+)
+
+const (
+	statementCSV     = "csv"
+	statementOFX     = "ofx"
+	statementCamt053 = "camt053"
+)
+
+// statementBankID identifies the bank in OFX and camt.053 statements
+const statementBankID = "GOBANK"
+
+// statement is what a statement says besides its entries
+type statement struct {
+	Account     db.Account
+	OwnerName   string
+	From        time.Time
+	To          time.Time
+	Opening     int64
+	Closing     int64
+	GeneratedAt time.Time
+}
+
+// statementEntry is an entry with the balance after it
+type statementEntry struct {
+	db.ListStatementEntriesRow
+	Balance int64
+}
+
+// statementWriter renders a statement as it is streamed: begin once, entry for each entry in order, then end.
+// flush pushes out what is buffered so far
+type statementWriter interface {
+	contentType() string
+	extension() string
+	begin(s statement) error
+	entry(e statementEntry) error
+	flush() error
+	end() error
+}
+
+func newStatementWriter(format string, w io.Writer) statementWriter {
+	switch format {
+	case statementOFX:
+		return &ofxStatementWriter{w: w, xml: newXMLStream(w)}
+	case statementCamt053:
+		return &camt053StatementWriter{w: w, xml: newXMLStream(w)}
+	default:
+		return &csvStatementWriter{w: csv.NewWriter(w)}
+	}
+}
+
+func formatNullInt64(n sql.NullInt64) string {
+	if !n.Valid {
+		return ""
+	}
+	return strconv.FormatInt(n.Int64, 10)
+}
+
+// csvStatementWriter writes a row per entry between an opening and a closing balance row
+type csvStatementWriter struct {
+	w *csv.Writer
+	s statement
+}
+
+func (writer *csvStatementWriter) contentType() string { return "text/csv; charset=utf-8" }
+func (writer *csvStatementWriter) extension() string   { return "csv" }
+
+func (writer *csvStatementWriter) balanceRow(kind string, at time.Time, balance int64) {
+	writer.w.Write([]string{kind, at.UTC().Format(time.RFC3339Nano), "", "", "", "", "", util.FormatAmount(balance), writer.s.Account.Currency})
+}
+
+func (writer *csvStatementWriter) begin(s statement) error {
+	writer.s = s
+	writer.w.Write([]string{"type", "date", "entry_id", "transfer_id", "counterparty_account_id", "counterparty_name", "amount", "balance", "currency"})
+	writer.balanceRow("opening_balance", s.From, s.Opening)
+	return writer.w.Error()
+}
+
+func (writer *csvStatementWriter) entry(e statementEntry) error {
+	writer.w.Write([]string{
+		"entry",
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		strconv.FormatInt(e.ID, 10),
+		formatNullInt64(e.TransferID),
+		formatNullInt64(e.CounterpartyAccountID),
+		e.CounterpartyName.String,
+		util.FormatAmount(e.Amount),
+		util.FormatAmount(e.Balance),
+		writer.s.Account.Currency,
+	})
+	return writer.w.Error()
+}
+
+func (writer *csvStatementWriter) flush() error {
+	writer.w.Flush()
+	return writer.w.Error()
+}
+
+func (writer *csvStatementWriter) end() error {
+	writer.balanceRow("closing_balance", writer.s.To, writer.s.Closing)
+	return writer.flush()
+}
+
+// xmlStream writes an XML document piece by piece. The first error sticks, and is returned by flush
+type xmlStream struct {
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLStream(w io.Writer) *xmlStream {
+	return &xmlStream{enc: xml.NewEncoder(w)}
+}
+
+func (stream *xmlStream) start(name string, attr ...xml.Attr) {
+	if stream.err == nil {
+		stream.err = stream.enc.EncodeToken(xml.StartElement{Name: xml.Name{Local: name}, Attr: attr})
+	}
+}
+
+func (stream *xmlStream) end(names ...string) {
+	for _, name := range names {
+		if stream.err == nil {
+			stream.err = stream.enc.EncodeToken(xml.EndElement{Name: xml.Name{Local: name}})
+		}
+	}
+}
+
+// element writes v as an element called name, v is either text or a struct with xml tags
+func (stream *xmlStream) element(name string, v interface{}) {
+	if stream.err == nil {
+		stream.err = stream.enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: name}})
+	}
+}
+
+func (stream *xmlStream) flush() error {
+	if stream.err == nil {
+		stream.err = stream.enc.Flush()
+	}
+	return stream.err
+}
+
+// ofxTime formats t the way OFX writes dates
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:GMT]"
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignon struct {
+	Status   ofxStatus `xml:"SONRS>STATUS"`
+	Server   string    `xml:"SONRS>DTSERVER"`
+	Language string    `xml:"SONRS>LANGUAGE"`
+}
+
+type ofxBankAccount struct {
+	BankID string `xml:"BANKID"`
+	ID     int64  `xml:"ACCTID"`
+	Type   string `xml:"ACCTTYPE"`
+}
+
+type ofxTransaction struct {
+	Type   string `xml:"TRNTYPE"`
+	Posted string `xml:"DTPOSTED"`
+	Amount string `xml:"TRNAMT"`
+	ID     int64  `xml:"FITID"`
+	Name   string `xml:"NAME,omitempty"`
+	Memo   string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// ofxStatementWriter writes an OFX 2.2 bank statement response
+type ofxStatementWriter struct {
+	w   io.Writer
+	xml *xmlStream
+	s   statement
+}
+
+func (writer *ofxStatementWriter) contentType() string { return "application/x-ofx" }
+func (writer *ofxStatementWriter) extension() string   { return "ofx" }
+
+func (writer *ofxStatementWriter) begin(s statement) error {
+	writer.s = s
+	header := xml.Header + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	if _, err := io.WriteString(writer.w, header); err != nil {
+		return err
+	}
+
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+	writer.xml.start("OFX")
+	writer.xml.element("SIGNONMSGSRSV1", ofxSignon{Status: ok, Server: ofxTime(s.GeneratedAt), Language: "ENG"})
+	writer.xml.start("BANKMSGSRSV1")
+	writer.xml.start("STMTTRNRS")
+	writer.xml.element("TRNUID", 0)
+	writer.xml.element("STATUS", ok)
+	writer.xml.start("STMTRS")
+	writer.xml.element("CURDEF", s.Account.Currency)
+	writer.xml.element("BANKACCTFROM", ofxBankAccount{BankID: statementBankID, ID: s.Account.ID, Type: "CHECKING"})
+	writer.xml.start("BANKTRANLIST")
+	writer.xml.element("DTSTART", ofxTime(s.From))
+	writer.xml.element("DTEND", ofxTime(s.To))
+	return writer.xml.err
+}
+
+func (writer *ofxStatementWriter) entry(e statementEntry) error {
+	transaction := ofxTransaction{
+		Type:   "CREDIT",
+		Posted: ofxTime(e.CreatedAt),
+		Amount: util.FormatAmount(e.Amount),
+		ID:     e.ID,
+		Name:   truncateString(e.CounterpartyName.String, 32),
+	}
+	if e.Amount < 0 {
+		transaction.Type = "DEBIT"
+	}
+	if e.TransferID.Valid {
+		transaction.Memo = fmt.Sprintf("transfer %d", e.TransferID.Int64)
+		if e.CounterpartyAccountID.Valid {
+			transaction.Memo += fmt.Sprintf(", account %d", e.CounterpartyAccountID.Int64)
+		}
+	}
+	writer.xml.element("STMTTRN", transaction)
+	return writer.xml.err
+}
+
+func (writer *ofxStatementWriter) flush() error {
+	return writer.xml.flush()
+}
+
+func (writer *ofxStatementWriter) end() error {
+	writer.xml.end("BANKTRANLIST")
+	writer.xml.element("LEDGERBAL", ofxBalance{Amount: util.FormatAmount(writer.s.Closing), AsOf: ofxTime(writer.s.To)})
+	writer.xml.end("STMTRS", "STMTTRNRS", "BANKMSGSRSV1", "OFX")
+	return writer.xml.flush()
+}
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy,omitempty"`
+	Owner    string `xml:"Ownr>Nm,omitempty"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>DtTm"`
+}
+
+type camtParty struct {
+	Name string `xml:"Nm,omitempty"`
+}
+
+type camtTransactionDetails struct {
+	TransferID      int64        `xml:"Refs>TxId"`
+	Debtor          *camtParty   `xml:"RltdPties>Dbtr,omitempty"`
+	DebtorAccount   *camtAccount `xml:"RltdPties>DbtrAcct,omitempty"`
+	Creditor        *camtParty   `xml:"RltdPties>Cdtr,omitempty"`
+	CreditorAccount *camtAccount `xml:"RltdPties>CdtrAcct,omitempty"`
+}
+
+type camtEntry struct {
+	Reference   int64                   `xml:"NtryRef"`
+	Amount      camtAmount              `xml:"Amt"`
+	Indicator   string                  `xml:"CdtDbtInd"`
+	Status      string                  `xml:"Sts"`
+	BookingDate string                  `xml:"BookgDt>DtTm"`
+	ValueDate   string                  `xml:"ValDt>DtTm"`
+	Code        string                  `xml:"BkTxCd>Prtry>Cd"`
+	Issuer      string                  `xml:"BkTxCd>Prtry>Issr"`
+	Details     *camtTransactionDetails `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+// camtIndicator splits a signed amount into the unsigned amount and credit/debit indicator camt.053 uses
+func camtIndicator(amount int64, currency string) (camtAmount, string) {
+	if amount < 0 {
+		return camtAmount{Currency: currency, Value: util.FormatAmount(-amount)}, "DBIT"
+	}
+	return camtAmount{Currency: currency, Value: util.FormatAmount(amount)}, "CRDT"
+}
+
+// camt053StatementWriter writes an ISO 20022 camt.053.001.02 bank to customer statement
+type camt053StatementWriter struct {
+	w   io.Writer
+	xml *xmlStream
+	s   statement
+}
+
+func (writer *camt053StatementWriter) contentType() string { return "application/xml" }
+func (writer *camt053StatementWriter) extension() string   { return "xml" }
+
+func (writer *camt053StatementWriter) balance(code string, at time.Time, balance int64) {
+	amount, indicator := camtIndicator(balance, writer.s.Account.Currency)
+	writer.xml.element("Bal", camtBalance{Code: code, Amount: amount, Indicator: indicator, Date: at.UTC().Format(time.RFC3339)})
+}
+
+func (writer *camt053StatementWriter) begin(s statement) error {
+	writer.s = s
+	if _, err := io.WriteString(writer.w, xml.Header); err != nil {
+		return err
+	}
+
+	id := fmt.Sprintf("%d-%s-%s", s.Account.ID, s.From.UTC().Format("20060102T150405"), s.To.UTC().Format("20060102T150405"))
+	writer.xml.start("Document", xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: camt053Namespace})
+	writer.xml.start("BkToCstmrStmt")
+	writer.xml.start("GrpHdr")
+	writer.xml.element("MsgId", id)
+	writer.xml.element("CreDtTm", s.GeneratedAt.UTC().Format(time.RFC3339))
+	writer.xml.end("GrpHdr")
+	writer.xml.start("Stmt")
+	writer.xml.element("Id", id)
+	writer.xml.element("CreDtTm", s.GeneratedAt.UTC().Format(time.RFC3339))
+	writer.xml.start("FrToDt")
+	writer.xml.element("FrDtTm", s.From.UTC().Format(time.RFC3339))
+	writer.xml.element("ToDtTm", s.To.UTC().Format(time.RFC3339))
+	writer.xml.end("FrToDt")
+	writer.xml.element("Acct", camtAccount{ID: strconv.FormatInt(s.Account.ID, 10), Currency: s.Account.Currency, Owner: s.OwnerName})
+	// the schema puts the balances before the entries, which is why both are worked out up front
+	writer.balance("OPBD", s.From, s.Opening)
+	writer.balance("CLBD", s.To, s.Closing)
+	return writer.xml.err
+}
+
+func (writer *camt053StatementWriter) entry(e statementEntry) error {
+	amount, indicator := camtIndicator(e.Amount, writer.s.Account.Currency)
+	entry := camtEntry{
+		Reference:   e.ID,
+		Amount:      amount,
+		Indicator:   indicator,
+		Status:      "BOOK",
+		BookingDate: e.CreatedAt.UTC().Format(time.RFC3339),
+		ValueDate:   e.CreatedAt.UTC().Format(time.RFC3339),
+		Code:        "ADJUSTMENT",
+		Issuer:      statementBankID,
+	}
+	if e.TransferID.Valid {
+		entry.Code = "TRANSFER"
+		details := &camtTransactionDetails{TransferID: e.TransferID.Int64}
+		if e.CounterpartyAccountID.Valid {
+			party := &camtParty{Name: e.CounterpartyName.String}
+			account := &camtAccount{ID: strconv.FormatInt(e.CounterpartyAccountID.Int64, 10)}
+			// money coming in was paid by the counterparty, money going out was paid to it
+			if e.Amount < 0 {
+				details.Creditor, details.CreditorAccount = party, account
+			} else {
+				details.Debtor, details.DebtorAccount = party, account
+			}
+		}
+		entry.Details = details
+	}
+	writer.xml.element("Ntry", entry)
+	return writer.xml.err
+}
+
+func (writer *camt053StatementWriter) flush() error {
+	return writer.xml.flush()
+}
+
+func (writer *camt053StatementWriter) end() error {
+	writer.xml.end("Stmt", "BkToCstmrStmt", "Document")
+	return writer.xml.flush()
+}
+
+// truncateString cuts s to at most n runes
+func truncateString(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+// expectStatement stubs the reads a statement makes, opening balance 100 and the given entries
+func expectStatement(t *testing.T, store *mockdb.MockStore, user db.User, account db.Account, entries ...[]db.ListStatementEntriesRow) {
+	closing := int64(100)
+	for _, batch := range entries {
+		for _, entry := range batch {
+			closing += entry.Amount
+		}
+	}
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	expectReadTx(store)
+	gomock.InOrder(
+		store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Return(db.GetAccountBalanceAsOfRow{Balance: 100}, nil),
+		store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Return(db.GetAccountBalanceAsOfRow{Balance: closing}, nil),
+	)
+	calls := make([]*gomock.Call, len(entries))
+	after := db.ListStatementEntriesRow{}
+	for i, batch := range entries {
+		// each batch carries on after the last entry of the one before
+		afterCreatedAt := sql.NullTime{Time: after.CreatedAt, Valid: i > 0}
+		afterID := after.ID
+		calls[i] = store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ interface{}, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+				require.Equal(t, account.ID, arg.AccountID)
+				require.Equal(t, afterCreatedAt, arg.AfterCreatedAt)
+				require.Equal(t, afterID, arg.AfterID)
+				require.Equal(t, int32(statementBatchSize), arg.Limit)
+				return batch, nil
+			})
+		if len(batch) > 0 {
+			after = batch[len(batch)-1]
+		}
+	}
+	gomock.InOrder(calls...)
+}
+
+// expectReadTx stubs a read transaction, running its function against store itself
+func expectReadTx(store *mockdb.MockStore) {
+	store.EXPECT().ReadTx(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, fn func(db.Querier) error) error {
+			return fn(store)
+		})
+}
+
+func statementEntries(n int, from time.Time) []db.ListStatementEntriesRow {
+	entries := make([]db.ListStatementEntriesRow, n)
+	for i := range entries {
+		entries[i] = db.ListStatementEntriesRow{
+			ID:                    int64(i + 1),
+			Amount:                int64(10 * (i%2*2 - 1)),
+			CreatedAt:             from.Add(time.Duration(i) * time.Second),
+			TransferID:            sql.NullInt64{Int64: int64(i + 1), Valid: true},
+			CounterpartyAccountID: sql.NullInt64{Int64: 99, Valid: true},
+			CounterpartyName:      sql.NullString{String: "Ada & Co", Valid: true},
+		}
+	}
+	return entries
+}
+
+// requireWellFormedXML decodes every token of body
+func requireWellFormedXML(t *testing.T, body []byte) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestGetAccountStatement(t *testing.T) {
+	user, _ := ramdomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = util.USD
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	adjustment := db.ListStatementEntriesRow{ID: 3, Amount: 5, CreatedAt: from.Add(time.Hour)}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "CSV",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectStatement(t, store, user, account, append(statementEntries(2, from), adjustment))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), "text/csv")
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), "statement-")
+
+				rows, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, 6)
+				require.Equal(t, []string{"opening_balance", "2024-03-01T00:00:00Z", "", "", "", "", "", "1.00", util.USD}, rows[1])
+				require.Equal(t, []string{"entry", "2024-03-01T00:00:00Z", "1", "1", "99", "Ada & Co", "-0.10", "0.90", util.USD}, rows[2])
+				require.Equal(t, "", rows[4][3])
+				require.Equal(t, []string{"closing_balance", "2024-04-01T00:00:00Z", "", "", "", "", "", "1.05", util.USD}, rows[5])
+			},
+		},
+		{
+			name:     "Paged",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectStatement(t, store, user, account, statementEntries(statementBatchSize, from), []db.ListStatementEntriesRow{adjustment})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				rows, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, statementBatchSize+4)
+			},
+		},
+		{
+			name:     "OFX",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}, "format": {"ofx"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectStatement(t, store, user, account, append(statementEntries(2, from), adjustment))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				body := recorder.Body.Bytes()
+				requireWellFormedXML(t, body)
+				require.Contains(t, string(body), `<?OFX OFXHEADER="200" VERSION="220"`)
+				require.Equal(t, 3, bytes.Count(body, []byte("<STMTTRN>")))
+				require.Contains(t, string(body), "<TRNTYPE>DEBIT</TRNTYPE>")
+				require.Contains(t, string(body), "<NAME>Ada &amp; Co</NAME>")
+				require.Contains(t, string(body), "<LEDGERBAL><BALAMT>1.05</BALAMT>")
+			},
+		},
+		{
+			name:     "Camt053",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}, "format": {"camt053"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectStatement(t, store, user, account, append(statementEntries(2, from), adjustment))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				body := recorder.Body.Bytes()
+				requireWellFormedXML(t, body)
+
+				var document struct {
+					Statement struct {
+						Balances []camtBalance `xml:"Bal"`
+						Entries  []camtEntry   `xml:"Ntry"`
+					} `xml:"BkToCstmrStmt>Stmt"`
+				}
+				require.NoError(t, xml.Unmarshal(body, &document))
+				require.Len(t, document.Statement.Balances, 2)
+				require.Equal(t, "OPBD", document.Statement.Balances[0].Code)
+				require.Equal(t, "1.00", document.Statement.Balances[0].Amount.Value)
+				require.Equal(t, "CLBD", document.Statement.Balances[1].Code)
+				require.Equal(t, "1.05", document.Statement.Balances[1].Amount.Value)
+
+				entries := document.Statement.Entries
+				require.Len(t, entries, 3)
+				require.Equal(t, "DBIT", entries[0].Indicator)
+				require.Equal(t, "0.10", entries[0].Amount.Value)
+				require.Equal(t, "99", entries[0].Details.CreditorAccount.ID)
+				require.Equal(t, "CRDT", entries[1].Indicator)
+				require.Equal(t, "Ada & Co", entries[1].Details.Debtor.Name)
+				require.Equal(t, "ADJUSTMENT", entries[2].Code)
+				require.Nil(t, entries[2].Details)
+			},
+		},
+		{
+			// entries that don't add up to the closing balance cut the statement short before it
+			name:     "ClosingMismatch",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				expectReadTx(store)
+				gomock.InOrder(
+					store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Return(db.GetAccountBalanceAsOfRow{Balance: 100}, nil),
+					store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Return(db.GetAccountBalanceAsOfRow{Balance: 999}, nil),
+				)
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(1).Return(statementEntries(2, from), nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				rows, err := csv.NewReader(recorder.Body).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, rows, 4)
+				require.Equal(t, "entry", rows[3][0])
+			},
+		},
+		{
+			// nothing has been sent yet, so the failure is still an error response
+			name:     "BalanceError",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				expectReadTx(store)
+				store.EXPECT().GetAccountBalanceAsOf(gomock.Any(), gomock.Any()).Times(1).Return(db.GetAccountBalanceAsOfRow{}, sql.ErrConnDone)
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				require.Equal(t, internalCode, requireErrorBody(t, recorder.Body).Code)
+			},
+		},
+		{
+			name:     "UnknownFormat",
+			username: user.Username,
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}, "format": {"pdf"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "ToBeforeFrom",
+			username: user.Username,
+			query:    url.Values{"from": {to.Format(time.RFC3339)}, "to": {from.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "MissingRange",
+			username: user.Username,
+			query:    url.Values{"format": {"csv"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			query:    url.Values{"from": {from.Format(time.RFC3339)}, "to": {to.Format(time.RFC3339)}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			path := fmt.Sprintf("/accounts/%d/statement?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, path, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+)
+
+type renewAccessTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type renewAccessTokenResponse struct {
+	AccessToken          string    `json:"access_token"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+}
+
+// renewAccessToken mints a new access token from a refresh token whose session is still active.
+// The role is read from the user, the one the refresh token was issued with may be stale
+func (server *Server) renewAccessToken(ctx *gin.Context) {
+	var req renewAccessTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+	if err != nil {
+		writeError(ctx, newAPIError(unauthenticatedCode, err.Error()))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		// a session that doesn't exist is as unusable as a blocked one
+		if errors.Is(err, sql.ErrNoRows) {
+			err = newAPIError(invalidSessionCode, "unknown session")
+		}
+		writeError(ctx, err)
+		return
+	}
+
+	if session.IsBlocked {
+		writeError(ctx, newAPIError(invalidSessionCode, "blocked session"))
+		return
+	}
+	if session.Username != refreshPayload.Username {
+		writeError(ctx, newAPIError(invalidSessionCode, "incorrect session user"))
+		return
+	}
+	if session.RefreshToken != req.RefreshToken {
+		writeError(ctx, newAPIError(invalidSessionCode, "mismatched session token"))
+		return
+	}
+	if time.Now().After(session.ExpiresAt) {
+		writeError(ctx, newAPIError(invalidSessionCode, "expired session"))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, refreshPayload.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.AccessToken,
+		server.config.AccessTokenDuration,
+	)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	response := renewAccessTokenResponse{
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// logoutUser revokes the access token used for the request and, when given, blocks the refresh token's session
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			writeError(ctx, invalidRequest(err))
+			return
+		}
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if req.RefreshToken != "" {
+		refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken, token.RefreshToken)
+		if err != nil {
+			writeError(ctx, newAPIError(unauthenticatedCode, err.Error()))
+			return
+		}
+		if refreshPayload.Username != authPayload.Username {
+			writeError(ctx, newAPIError(notOwnerCode, "refresh token doesn`t belong to the authenticated user"))
+			return
+		}
+		err = server.store.BlockSession(ctx, refreshPayload.ID)
+		if err != nil {
+			writeError(ctx, err)
+			return
+		}
+	}
+
+	err := server.store.RevokeToken(ctx, db.RevokeTokenParams{
+		ID:        authPayload.ID,
+		Username:  authPayload.Username,
+		ExpiresAt: authPayload.ExpiredAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":  "ok",
+		"message": "user has been logged out",
+	})
+}
+
+type revokeUserTokensRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// revokeUserTokens invalidates every access token issued to a user so far and blocks all of their sessions
+func (server *Server) revokeUserTokens(ctx *gin.Context) {
+	var req revokeUserTokensRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	user, err := server.store.RevokeUserTokens(ctx, req.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = server.store.BlockUserSessions(ctx, user.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":            "ok",
+		"message":           "user tokens have been revoked",
+		"tokens_revoked_at": user.TokensRevokedAt,
+	})
+}
@@ -0,0 +1,280 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenewAccessToken(t *testing.T) {
+	user, _ := ramdomUser(t)
+
+	testCases := []struct {
+		name string
+		// tokenType is the type of the token sent for renewal, a refresh token when empty
+		tokenType     token.TokenType
+		buildStubs    func(store *mockdb.MockStore, refreshToken string, payload *token.Payload)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					ExpiresAt:    payload.ExpiredAt,
+				}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: refreshToken,
+					IsBlocked:    true,
+					ExpiresAt:    payload.ExpiredAt,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MismatchedToken",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{
+					ID:           payload.ID,
+					Username:     user.Username,
+					RefreshToken: "another-token",
+					ExpiresAt:    payload.ExpiredAt,
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccessToken",
+			tokenType: token.AccessToken,
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, unauthenticatedCode, requireErrorBody(t, recorder.Body).Code)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, refreshToken string, payload *token.Payload) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.Equal(t, invalidSessionCode, requireErrorBody(t, recorder.Body).Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			tokenType := tc.tokenType
+			if tokenType == "" {
+				tokenType = token.RefreshToken
+			}
+			refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.DepositorRole, tokenType, time.Hour)
+			require.NoError(t, err)
+			tc.buildStubs(store, refreshToken, payload)
+
+			recorder := httptest.NewRecorder()
+			data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRenewAccessTokenAfterRoleChange(t *testing.T) {
+	user, _ := ramdomUser(t)
+	user.Role = util.DepositorRole
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	// the refresh token was issued while the user was a banker
+	refreshToken, payload, err := server.tokenMaker.CreateToken(user.Username, util.BankerRole, token.RefreshToken, time.Hour)
+	require.NoError(t, err)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Eq(payload.ID)).Times(1).Return(db.Session{
+		ID:           payload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		ExpiresAt:    payload.ExpiredAt,
+	}, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+
+	recorder := httptest.NewRecorder()
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+	request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "application/json")
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp renewAccessTokenResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	accessPayload, err := server.tokenMaker.VerifyToken(rsp.AccessToken, token.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, util.DepositorRole, accessPayload.Role)
+}
+
+func TestLogoutUser(t *testing.T) {
+	user, _ := ramdomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeToken(gomock.Any(), gomock.Any()).Times(1).Return(sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/logout", nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	admin, _ := ramdomUser(t)
+	user, _ := ramdomUser(t)
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "BankerNotAllowed",
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "UserNotFound",
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/users/%s/revoke_tokens", user.Username)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, admin.Username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -1,14 +1,16 @@
 package api
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	db "github.com/joekings2k/gobank/db/sqlc"
 	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
 )
 
 type transferRequest struct {
@@ -22,7 +24,11 @@ type transferRequest struct {
 func(server *Server) createTransfer(ctx *gin.Context){
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err!= nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
+		return
+	}
+	idempotencyKey, ok := server.idempotencyKey(ctx, req)
+	if !ok {
 		return
 	}
 	fromAccount, valid  :=server.validAccount(ctx, req.FromAccountID, req.Currency)
@@ -31,11 +37,11 @@ func(server *Server) createTransfer(ctx *gin.Context){
 	}
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doens`t belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized,errorResponse(err))
+		writeError(ctx,newAPIError(notOwnerCode,"from account doens`t belong to the authenticated user"))
 		return
 	}
-	_, valid = server.validAccount(ctx,req.ToAccountID, req.Currency)
+	// the to account may hold another currency, TransferTx converts at the current rate
+	_, valid = server.loadAccount(ctx,req.ToAccountID)
 	if !valid{
 		return
 	}
@@ -44,11 +50,16 @@ func(server *Server) createTransfer(ctx *gin.Context){
 		FromAccountID: req.FromAccountID,
 		ToAccountID: req.ToAccountID,
 		Amount: req.Amount,
+		IdempotencyKey: idempotencyKey,
 	}
 
 	result,err  := server.store.TransferTx(ctx,arg)
 	if err != nil{
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		// a concurrent request with the same key won the race, answer with its response
+		if errors.Is(err, db.ErrIdempotencyKeyInUse) && server.replayIdempotentResponse(ctx, idempotencyKey) {
+			return
+		}
+		writeError(ctx,err)
 		return
 	}
 	ctx.JSON(http.StatusOK,result)
@@ -56,20 +67,179 @@ func(server *Server) createTransfer(ctx *gin.Context){
 
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) ( db.Account, bool){
 
-	account,err := server.store.GetAccount(ctx,accountID)
-	if err != nil {
-		if err == sql.ErrNoRows{
-			ctx.JSON(http.StatusNotFound,errorResponse(err))
-			return db.Account{}, false
-		}
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
-			return db.Account{}, false
+	account,valid := server.loadAccount(ctx,accountID)
+	if !valid {
+		return account,false
 	}
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", accountID,account.Currency,currency)
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidField("currency","currency_mismatch",fmt.Sprintf("account [%d] holds %s, not %s", accountID,account.Currency,currency)))
 		return account,false
 	}
 
 	return  account,true
-}
\ No newline at end of file
+}
+
+// loadAccount gets the account, writing a 404 or 500 response when it can't
+func (server *Server) loadAccount(ctx *gin.Context, accountID int64) ( db.Account, bool){
+	account,err := server.store.GetAccount(ctx,accountID)
+	if err != nil {
+		writeError(ctx,err)
+		return db.Account{}, false
+	}
+	return account,true
+}
+
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer returns a transfer to the owner of either account, or to bankers and admins
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !util.IsPrivilegedRole(authPayload.Role) {
+		for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+			account, valid := server.loadAccount(ctx, accountID)
+			if !valid {
+				return
+			}
+			if account.Owner == authPayload.Username {
+				ctx.JSON(http.StatusOK, transfer)
+				return
+			}
+		}
+		writeError(ctx, newAPIError(notOwnerCode, "transfer doesn`t belong to the authenticated user"))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, transfer)
+}
+
+type listAccountTransfersResponse struct {
+	Transfers  []db.Transfer `json:"transfers"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// listAccountTransfers lists transfers into and out of an account, newest first.
+// Amounts are compared in the account's own currency
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	uri, filter, ok := bindHistoryRequest(ctx)
+	if !ok {
+		return
+	}
+	if _, valid := server.accessibleAccount(ctx, uri.ID); !valid {
+		return
+	}
+	page, ok := server.bindPagination(ctx, filter.scope("transfers", uri.ID), filter.PageID, filter.PageSize, filter.Cursor)
+	if !ok {
+		return
+	}
+
+	arg := db.ListAccountTransfersParams{
+		AccountID:       uri.ID,
+		Outgoing:        filter.Direction != directionIn,
+		Incoming:        filter.Direction != directionOut,
+		StartTime:       filter.startTime(),
+		EndTime:         filter.endTime(),
+		MinAmount:       filter.minAmount(),
+		MaxAmount:       filter.maxAmount(),
+		BeforeCreatedAt: page.cursorTime(),
+		BeforeID:        page.cursorID(),
+		Limit:           page.limit(),
+		Offset:          page.offset(),
+	}
+	transfers, err := server.store.ListAccountTransfers(ctx, arg)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if page.offsetForm {
+		ctx.JSON(http.StatusOK, transfers)
+		return
+	}
+
+	transfers, next, err := keysetPage(server, page, transfers, func(transfer db.Transfer) (time.Time, int64) {
+		return transfer.CreatedAt.Time, transfer.ID
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, listAccountTransfersResponse{Transfers: transfers, NextCursor: next})
+}
+
+// reverseTransfer sends back everything that is left of a transfer. Bankers and admins can
+// reverse any transfer, whether or not the recipient can cover it
+func (server *Server) reverseTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: uri.ID,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type refundTransferRequest struct {
+	// Amount in the recipient's currency, everything that is left when omitted
+	Amount int64 `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// refundTransfer lets the recipient of a transfer send all or part of it back
+func (server *Server) refundTransfer(ctx *gin.Context) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	var req refundTransferRequest
+	// the body is optional
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	toAccount, valid := server.loadAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if toAccount.Owner != authPayload.Username {
+		writeError(ctx, newAPIError(notOwnerCode, "transfer wasn`t sent to the authenticated user"))
+		return
+	}
+
+	result, err := server.store.ReverseTransferTx(ctx, db.ReverseTransferTxParams{
+		TransferID: transfer.ID,
+		Amount:     req.Amount,
+		CheckFunds: true,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
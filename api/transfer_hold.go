@@ -0,0 +1,121 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+)
+
+type authorizeTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        int64  `json:"amount" binding:"required,gt=0"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// ExpiresAt defaults to, and can't be later than, the configured hold duration from now
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// authorizeTransfer holds funds on the sender's account for the recipient to capture or void later
+func (server *Server) authorizeTransfer(ctx *gin.Context) {
+	var req authorizeTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	now := time.Now()
+	expiresAt := now.Add(server.config.HoldDuration)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) || req.ExpiresAt.After(expiresAt) {
+			writeError(ctx, invalidField("expires_at", "range", fmt.Sprintf("must be in the future and within %s", server.config.HoldDuration)))
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
+	if !valid {
+		return
+	}
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if fromAccount.Owner != authPayload.Username {
+		writeError(ctx, newAPIError(notOwnerCode, "from account doesn`t belong to the authenticated user"))
+		return
+	}
+	if _, valid = server.loadAccount(ctx, req.ToAccountID); !valid {
+		return
+	}
+
+	result, err := server.store.AuthorizeTransferTx(ctx, db.AuthorizeTransferTxParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        req.Amount,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// captureTransfer settles an authorized transfer, moving the held funds to the recipient
+func (server *Server) captureTransfer(ctx *gin.Context) {
+	hold, ok := server.recipientHold(ctx)
+	if !ok {
+		return
+	}
+	result, err := server.store.CaptureTransferTx(ctx, hold.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// voidTransfer cancels an authorized transfer and gives the held funds back to the sender
+func (server *Server) voidTransfer(ctx *gin.Context) {
+	hold, ok := server.recipientHold(ctx)
+	if !ok {
+		return
+	}
+	result, err := server.store.VoidTransferTx(ctx, hold.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// recipientHold gets a transfer for its recipient, who decides whether to capture or void it,
+// or for bankers and admins
+func (server *Server) recipientHold(ctx *gin.Context) (db.Transfer, bool) {
+	var uri getTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return db.Transfer{}, false
+	}
+	transfer, err := server.store.GetTransfer(ctx, uri.ID)
+	if err != nil {
+		writeError(ctx, err)
+		return transfer, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if util.IsPrivilegedRole(authPayload.Role) {
+		return transfer, true
+	}
+	toAccount, valid := server.loadAccount(ctx, transfer.ToAccountID)
+	if !valid {
+		return transfer, false
+	}
+	if toAccount.Owner != authPayload.Username {
+		writeError(ctx, newAPIError(notOwnerCode, "transfer wasn`t sent to the authenticated user"))
+		return transfer, false
+	}
+	return transfer, true
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorizeTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"expires_at":      expiresAt,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.AuthorizeTransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					ExpiresAt:     expiresAt,
+				}
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.TransferHoldTxResult{Transfer: db.Transfer{Status: db.TransferAuthorized}}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.TransferHoldTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, db.TransferAuthorized, got.Transfer.Status)
+			},
+		},
+		{
+			name:     "DefaultExpiry",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.AuthorizeTransferTxParams) (db.TransferHoldTxResult, error) {
+						require.WithinDuration(t, time.Now().Add(24*time.Hour), arg.ExpiresAt, time.Minute)
+						return db.TransferHoldTxResult{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "ExpiresAtTooLate",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+				"expires_at":      time.Now().Add(48 * time.Hour),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: user1.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferHoldTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), insufficientFundsCode)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: user2.Username,
+			body: gin.H{
+				"from_account_id": account1.ID,
+				"to_account_id":   account2.ID,
+				"amount":          10,
+				"currency":        util.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().AuthorizeTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers/authorize", bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestCaptureTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	hold := randomTransfer(account1, account2)
+	hold.Status = db.TransferAuthorized
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Recipient",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Sender",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotAuthorized",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).
+					Return(db.TransferTxResult{}, db.ErrTransferNotAuthorized)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), transferNotAuthorizedCode)
+			},
+		},
+		{
+			name:     "Expired",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).
+					Return(db.TransferTxResult{}, db.ErrHoldExpired)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), holdExpiredCode)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().CaptureTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/capture", hold.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestVoidTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	hold := randomTransfer(account1, account2)
+	hold.Status = db.TransferAuthorized
+	voided := hold
+	voided.Status = db.TransferVoided
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+	expectTokenNotRevoked(store)
+	store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(hold.ID)).Times(1).Return(hold, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().VoidTransferTx(gomock.Any(), gomock.Eq(hold.ID)).Times(1).
+		Return(db.TransferHoldTxResult{Transfer: voided, FromAccount: account1}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+	url := fmt.Sprintf("/transfers/%d/void", hold.ID)
+	request, err := http.NewRequest(http.MethodPost, url, nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user2.Username, util.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var got db.TransferHoldTxResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+	require.Equal(t, voided, got.Transfer)
+}
@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 	"time"
 
@@ -47,7 +49,7 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
@@ -72,7 +74,7 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "", time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "", util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Any()).Times(0)
@@ -97,7 +99,7 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(db.Account{},sql.ErrNoRows)
@@ -118,7 +120,7 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(db.Account{},sql.ErrConnDone)
@@ -131,7 +133,7 @@ func  TestCreateTransfer(t *testing.T) {
 			},
 		},
 		{
-			name :"CurrencyMismatch",
+			name :"CrossCurrency",
 			body: gin.H{
 				"from_account_id":account1.ID,
 				"to_account_id":account3.ID,
@@ -139,11 +141,35 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account3.ID)).Times(1).Return(account3,nil)
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID: account3.ID,
+					Amount: 10,
+				}
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusOK,recorder.Code)
+			},
+		},
+		{
+			name :"CurrencyMismatch",
+			body: gin.H{
+				"from_account_id":account1.ID,
+				"to_account_id":account2.ID,
+				"amount":10,
+				"currency":util.EUR,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
 				
 				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(0)
 			},
@@ -151,6 +177,52 @@ func  TestCreateTransfer(t *testing.T) {
 				require.Equal(t,http.StatusBadRequest,recorder.Code)
 			},
 		},
+		{
+			name :"NoExchangeRate",
+			body: gin.H{
+				"from_account_id":account1.ID,
+				"to_account_id":account3.ID,
+				"amount":10,
+				"currency":util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account3.ID)).Times(1).Return(account3,nil)
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.TransferTxResult{},db.ErrNoExchangeRate)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnprocessableEntity,recorder.Code)
+				var body map[string]string
+				require.NoError(t,json.Unmarshal(recorder.Body.Bytes(),&body))
+				require.Equal(t,noExchangeRateCode,body["code"])
+			},
+		},
+		{
+			name :"InsufficientFunds",
+			body: gin.H{
+				"from_account_id":account1.ID,
+				"to_account_id":account2.ID,
+				"amount":10,
+				"currency":util.USD,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
+				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account2.ID)).Times(1).Return(account2,nil)
+				store.EXPECT().TransferTx(gomock.Any(),gomock.Any()).Times(1).Return(db.TransferTxResult{},db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnprocessableEntity,recorder.Code)
+				var body map[string]string
+				require.NoError(t,json.Unmarshal(recorder.Body.Bytes(),&body))
+				require.Equal(t,insufficientFundsCode,body["code"])
+			},
+		},
 		{
 			name :"TransferServerError",
 			body: gin.H{
@@ -160,7 +232,7 @@ func  TestCreateTransfer(t *testing.T) {
 				"currency":util.USD,
 			},
 			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
-				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, time.Minute)
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
 			},
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetAccount(gomock.Any(),gomock.Eq(account1.ID)).Times(1).Return(account1,nil)
@@ -186,6 +258,7 @@ func  TestCreateTransfer(t *testing.T) {
 
 			store :=mockdb.NewMockStore(ctrl)
 			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
 
 			server:= newTestServer(t,store)
 			recorder := httptest.NewRecorder()
@@ -202,4 +275,680 @@ func  TestCreateTransfer(t *testing.T) {
 		})
 	}
 	
-}
\ No newline at end of file
+}
+func TestCreateTransferIdempotency(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+
+	body := gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        util.USD,
+	}
+	hash, err := requestHash(transferRequest{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      util.USD,
+	})
+	require.NoError(t, err)
+	key := util.RandomString(16)
+	storedBody := []byte(`{"transfer":{"id":1}}`)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "NewKey",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(db.GetIdempotencyKeyParams{
+					Username: user1.Username,
+					Key:      key,
+				})).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.TransferTxParams{
+					FromAccountID: account1.ID,
+					ToAccountID:   account2.ID,
+					Amount:        10,
+					IdempotencyKey: &db.IdempotencyKeyParams{
+						Username:    user1.Username,
+						Key:         key,
+						RequestHash: hash,
+						TTL:         time.Hour,
+					},
+				}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Empty(t, recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+		{
+			name: "Replay",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{
+					Username:       user1.Username,
+					Key:            key,
+					RequestHash:    hash,
+					ResponseStatus: http.StatusOK,
+					ResponseBody:   storedBody,
+				}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+				require.Equal(t, storedBody, recorder.Body.Bytes())
+			},
+		},
+		{
+			name: "KeyReusedWithDifferentBody",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{
+					Username:       user1.Username,
+					Key:            key,
+					RequestHash:    "another-hash",
+					ResponseStatus: http.StatusOK,
+					ResponseBody:   storedBody,
+				}, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "ConcurrentRequestWon",
+			buildStubs: func(store *mockdb.MockStore) {
+				gomock.InOrder(
+					store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{}, sql.ErrNoRows),
+					store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(1).Return(db.IdempotencyKey{
+						Username:       user1.Username,
+						Key:            key,
+						RequestHash:    hash,
+						ResponseStatus: http.StatusOK,
+						ResponseBody:   storedBody,
+					}, nil),
+				)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrIdempotencyKeyInUse)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "true", recorder.Header().Get(idempotentReplayedHeader))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user1.Username, util.DepositorRole, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set(idempotencyKeyHeader, key)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	transfer := randomTransfer(account1, account2)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Sender",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.Transfer
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, transfer, got)
+			},
+		},
+		{
+			name:     "Recipient",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Banker",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user1.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d", transfer.ID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListAccountTransfers(t *testing.T) {
+	user, _ := ramdomUser(t)
+	account := randomAccount(user.Username)
+	other := randomAccount("other")
+	transfers := []db.Transfer{randomTransfer(account, other), randomTransfer(other, account)}
+	startTime := time.Now().Add(-24 * time.Hour).UTC().Truncate(time.Second)
+	endTime := startTime.Add(time.Hour)
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := db.ListAccountTransfersParams{
+					AccountID: account.ID,
+					Outgoing:  true,
+					Incoming:  true,
+					Limit:     5,
+					Offset:    0,
+				}
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got []db.Transfer
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, transfers, got)
+			},
+		},
+		{
+			name:     "Filters",
+			username: user.Username,
+			query: url.Values{
+				"page_id":    {"2"},
+				"page_size":  {"10"},
+				"direction":  {"out"},
+				"start_time": {startTime.Format(time.RFC3339)},
+				"end_time":   {endTime.Format(time.RFC3339)},
+				"min_amount": {"10"},
+				"max_amount": {"100"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.ListAccountTransfersParams) ([]db.Transfer, error) {
+						require.Equal(t, account.ID, arg.AccountID)
+						require.True(t, arg.Outgoing)
+						require.False(t, arg.Incoming)
+						require.True(t, arg.StartTime.Valid)
+						require.True(t, startTime.Equal(arg.StartTime.Time))
+						require.True(t, arg.EndTime.Valid)
+						require.True(t, endTime.Equal(arg.EndTime.Time))
+						require.Equal(t, sql.NullInt64{Int64: 10, Valid: true}, arg.MinAmount)
+						require.Equal(t, sql.NullInt64{Int64: 100, Valid: true}, arg.MaxAmount)
+						require.Equal(t, int32(10), arg.Limit)
+						require.Equal(t, int32(10), arg.Offset)
+						return transfers[:1], nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidDirection",
+			username: user.Username,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}, "direction": {"sideways"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidTimeRange",
+			username: user.Username,
+			query: url.Values{
+				"page_id":    {"1"},
+				"page_size":  {"5"},
+				"start_time": {endTime.Format(time.RFC3339)},
+				"end_time":   {startTime.Format(time.RFC3339)},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidAmountRange",
+			username: user.Username,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}, "min_amount": {"100"}, "max_amount": {"10"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UnauthorizedUser",
+			username: "unauthorized_user",
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AccountNotFound",
+			username: user.Username,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: user.Username,
+			query:    url.Values{"page_id": {"1"}, "page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/transfers?%s", account.ID, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestReverseTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	transfer := randomTransfer(account1, account2)
+	reversal := randomTransfer(account2, account1)
+	reversal.ReversesTransferID = sql.NullInt64{Int64: transfer.ID, Valid: true}
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.TransferTxResult{Transfer: reversal}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				var got db.TransferTxResult
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, reversal, got.Transfer)
+			},
+		},
+		{
+			name:     "Depositor",
+			username: user2.Username,
+			role:     util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "AlreadyReversed",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrReversalExceedsTransfer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), reversalExceedsTransferCode)
+			},
+		},
+		{
+			name:     "TransferIsReversal",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrTransferIsReversal)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), transferIsReversalCode)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: "banker",
+			role:     util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/reverse", transfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestRefundTransfer(t *testing.T) {
+	user1, _ := ramdomUser(t)
+	user2, _ := ramdomUser(t)
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	transfer := randomTransfer(account1, account2)
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          []byte
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "Partial",
+			username: user2.Username,
+			body:     []byte(`{"amount":1}`),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID, Amount: 1, CheckFunds: true}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "Full",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.ReverseTransferTxParams{TransferID: transfer.ID, CheckFunds: true}
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "ExceedsTransfer",
+			username: user2.Username,
+			body:     []byte(fmt.Sprintf(`{"amount":%d}`, transfer.ToAmount+1)),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrReversalExceedsTransfer)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), reversalExceedsTransferCode)
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), insufficientFundsCode)
+			},
+		},
+		{
+			name:     "InvalidAmount",
+			username: user2.Username,
+			body:     []byte(`{"amount":-1}`),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "Sender",
+			username: user1.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user2.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(db.Transfer{}, sql.ErrNoRows)
+				store.EXPECT().ReverseTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/transfers/%d/refund", transfer.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, util.DepositorRole, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func randomTransfer(fromAccount db.Account, toAccount db.Account) db.Transfer {
+	amount := util.RandomMoney()
+	return db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		ToAmount:      amount,
+		ExchangeRate:  "1",
+		Status:        db.TransferSettled,
+	}
+}
@@ -2,13 +2,16 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/metrics"
+	"github.com/joekings2k/gobank/token"
 	"github.com/joekings2k/gobank/util"
-	"github.com/lib/pq"
 )
 
 type createUserRequest struct{
@@ -20,6 +23,7 @@ type createUserRequest struct{
 
 type userResponse struct {
 	Username          string    `json:"username"`
+	Role              string    `json:"role"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
@@ -29,6 +33,7 @@ type userResponse struct {
 func newUserResponse(user db.User) userResponse {
 	return userResponse{
 		Username: user.Username,
+		Role: user.Role,
 		FullName: user.FullName,
 		Email: user.Email,
 		PasswordChangedAt: user.PasswordChangedAt,
@@ -39,12 +44,12 @@ func newUserResponse(user db.User) userResponse {
 func(server *Server) createUser(ctx *gin.Context){
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err!= nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 	hashedPassword ,err  := util.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	} 
 	arg := db.CreateUserParams{
@@ -56,14 +61,7 @@ func(server *Server) createUser(ctx *gin.Context){
 
 	user,err  := server.store.CreateUser(ctx,arg)
 	if err != nil{
-		if pqErr, ok :=err.(*pq.Error);ok {
-			switch pqErr.Code.Name(){
-			case  "unique_violation":
-				ctx.JSON(http.StatusForbidden,errorResponse(err))
-		return
-			}
-		}
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 	response := newUserResponse(user)
@@ -77,40 +75,134 @@ type loginUserRequest struct{
 }
 
 type loginUserResponse struct {
-	AccessToken string `json:"access_token"`
-	User userResponse `json:"user"`
+	SessionID             uuid.UUID    `json:"session_id"`
+	AccessToken           string       `json:"access_token"`
+	AccessTokenExpiresAt  time.Time    `json:"access_token_expires_at"`
+	RefreshToken          string       `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
+	User                  userResponse `json:"user"`
 }
 
 func (server *Server) loginUser(ctx *gin.Context){
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err!=nil{
-		ctx.JSON(http.StatusBadRequest,errorResponse(err))
+		writeError(ctx,invalidRequest(err))
 		return
 	}
 	user,err := server.store.GetUser(ctx,req.Username)
 	if err != nil {
-		if err ==sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound,errorResponse(err))
+		if errors.Is(err,sql.ErrNoRows) {
+			metrics.LoginFailed(metrics.LoginUnknownUser)
 		}
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized,errorResponse(err))
+		metrics.LoginFailed(metrics.LoginWrongPassword)
+		writeError(ctx,newAPIError(invalidCredentialsCode,"incorrect password"))
 		return
 	}
-	accessToken,err := server.tokenMaker.CreateToken(
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
+		token.AccessToken,
 		server.config.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError,errorResponse(err))
+		writeError(ctx,err)
 		return
 	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		token.RefreshToken,
+		server.config.RefreshTokenDuration,
+	)
+	if err != nil {
+		writeError(ctx,err)
+		return
+	}
+
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID: refreshPayload.ID,
+		Username: user.Username,
+		RefreshToken: refreshToken,
+		UserAgent: ctx.Request.UserAgent(),
+		ClientIp: ctx.ClientIP(),
+		IsBlocked: false,
+		ExpiresAt: refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		writeError(ctx,err)
+		return
+	}
+
 	response := loginUserResponse{
+		SessionID: session.ID,
 		AccessToken: accessToken,
+		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		RefreshToken: refreshToken,
+		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 		User: newUserResponse(user),
 	}
 	ctx.JSON(http.StatusOK, response)
 }
+
+type getUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+func (server *Server) getUser(ctx *gin.Context) {
+	var req getUserRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type updateUserRoleBody struct {
+	Role string `json:"role" binding:"required,role"`
+}
+
+// updateUserRole changes a user's role and revokes their tokens so the new role applies on next login
+func (server *Server) updateUserRole(ctx *gin.Context) {
+	var uri getUserRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+	var body updateUserRoleBody
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		writeError(ctx, invalidRequest(err))
+		return
+	}
+
+	user, err := server.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: uri.Username,
+		Role:     body.Role,
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	_, err = server.store.RevokeUserTokens(ctx, user.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	err = server.store.BlockUserSessions(ctx, user.Username)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -10,6 +10,7 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -107,7 +108,7 @@ func TestCreateUser(t *testing.T) {
 				CreateUser(gomock.Any(),gomock.Any()).Times(1).Return(db.User{}, &pq.Error{Code: "23505"})
 			},
 			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden,recorder.Code)
+				require.Equal(t, http.StatusConflict,recorder.Code)
 			},
 		},
 		{
@@ -169,11 +170,40 @@ func TestLoginUser(t *testing.T) {
 			}, 
 			buildStubs: func(store *mockdb.MockStore) {
 				store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user.Username)).Times(1).Return(user,nil)
+				store.EXPECT().CreateSession(gomock.Any(),gomock.Any()).Times(1)
 			},
 			checkresponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				require.Equal(t,http.StatusOK,recorder.Code)
 			},
 		},
+		{
+			name:"IncorrectPassword",
+			body:gin.H{
+				"username":user.Username,
+				"password":"incorrect",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user.Username)).Times(1).Return(user,nil)
+				store.EXPECT().CreateSession(gomock.Any(),gomock.Any()).Times(0)
+			},
+			checkresponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusUnauthorized,recorder.Code)
+			},
+		},
+		{
+			name:"CreateSessionError",
+			body:gin.H{
+				"username":user.Username,
+				"password":password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(),gomock.Eq(user.Username)).Times(1).Return(user,nil)
+				store.EXPECT().CreateSession(gomock.Any(),gomock.Any()).Times(1).Return(db.Session{}, sql.ErrConnDone)
+			},
+			checkresponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t,http.StatusInternalServerError,recorder.Code)
+			},
+		},
 	 }
 
 	for i := range testCases {
@@ -207,6 +237,7 @@ func ramdomUser(t *testing.T) ( user db.User, password string ){
 
  user = db.User{
 	Username: util.RandomOwner(),
+	Role: util.DepositorRole,
 	HashedPassword: hashedPassword,
 	FullName: util.RandomOwner(),
 	Email: util.RandomEmail(),
@@ -225,4 +256,81 @@ func requireBodyMatchUser(t *testing.T, body *bytes.Buffer, user db.User) {
 	require.Equal(t, user.Username, gotUser.Username)
   require.Equal(t, user.FullName, gotUser.FullName)
   require.Equal(t, user.Email, gotUser.Email)
-}
\ No newline at end of file
+}
+func TestUpdateUserRole(t *testing.T) {
+	user, _ := ramdomUser(t)
+	updatedUser := user
+	updatedUser.Role = util.BankerRole
+
+	testCases := []struct {
+		name          string
+		role          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: util.AdminRole,
+			body: gin.H{"role": util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Eq(db.UpdateUserRoleParams{
+					Username: user.Username,
+					Role:     util.BankerRole,
+				})).Times(1).Return(updatedUser, nil)
+				store.EXPECT().RevokeUserTokens(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(updatedUser, nil)
+				store.EXPECT().BlockUserSessions(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidRole",
+			role: util.AdminRole,
+			body: gin.H{"role": "superuser"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "DepositorNotAllowed",
+			role: util.DepositorRole,
+			body: gin.H{"role": util.AdminRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/admin/users/%s/role", user.Username)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "admin", tc.role, time.Minute)
+			request.Header.Set("Content-Type", "application/json")
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -10,4 +10,19 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 			return util.IsSuppoertedCurrency(currency)
 		}
 		return false
-}
\ No newline at end of file
+}
+
+var validRole validator.Func = func(fieldLevel validator.FieldLevel) bool {
+		if role,ok := fieldLevel.Field().Interface().(string); ok {
+			return util.IsSupportedRole(role)
+		}
+		return false
+}
+
+var validRecurrence validator.Func = func(fieldLevel validator.FieldLevel) bool {
+		if rule,ok := fieldLevel.Field().Interface().(string); ok {
+			_, err := util.ParseRecurrence(rule)
+			return err == nil
+		}
+		return false
+}
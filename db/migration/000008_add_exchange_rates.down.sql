@@ -0,0 +1,5 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "exchange_rate";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_amount";
+
+DROP TABLE IF EXISTS "exchange_rates";
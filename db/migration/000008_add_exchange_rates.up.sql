@@ -0,0 +1,24 @@
+CREATE TABLE "exchange_rates" (
+  "id" bigserial PRIMARY KEY,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" numeric(20,10) NOT NULL,
+  "effective_from" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "exchange_rates" ("from_currency", "to_currency", "effective_from");
+
+ALTER TABLE "exchange_rates" ADD CONSTRAINT "rate_positive" CHECK ("rate" > 0);
+
+COMMENT ON COLUMN "exchange_rates"."rate" IS 'units of to_currency per unit of from_currency';
+
+ALTER TABLE "transfers" ADD COLUMN "to_amount" bigint;
+
+UPDATE "transfers" SET "to_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "to_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" numeric(20,10) NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN "transfers"."to_amount" IS 'amount credited, in the to account currency';
@@ -0,0 +1,5 @@
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("from_account_id", "created_at");
+
+CREATE INDEX ON "transfers" ("to_account_id", "created_at");
@@ -0,0 +1 @@
+CREATE INDEX ON "accounts" ("owner", "created_at", "id");
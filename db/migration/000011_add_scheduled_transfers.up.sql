@@ -0,0 +1,31 @@
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "execute_at" timestamptz NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "executed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "scheduled_transfers" ("status", "execute_at");
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "created_at", "id");
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'pending, completed, failed or cancelled';
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
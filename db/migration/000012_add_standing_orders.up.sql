@@ -0,0 +1,56 @@
+CREATE TABLE "standing_orders" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "recurrence" varchar NOT NULL,
+  "start_at" timestamptz NOT NULL,
+  "end_at" timestamptz,
+  "max_occurrences" int,
+  "occurrences" int NOT NULL DEFAULT 0,
+  "next_run_at" timestamptz,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "standing_order_executions" (
+  "id" bigserial PRIMARY KEY,
+  "standing_order_id" bigint NOT NULL,
+  "occurrence" int NOT NULL,
+  "scheduled_for" timestamptz NOT NULL,
+  "status" varchar NOT NULL,
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "standing_orders" ("status", "next_run_at");
+
+CREATE INDEX ON "standing_orders" ("owner", "created_at", "id");
+
+CREATE UNIQUE INDEX ON "standing_order_executions" ("standing_order_id", "occurrence");
+
+COMMENT ON COLUMN "standing_orders"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "standing_orders"."recurrence" IS 'RRULE subset, FREQ and INTERVAL';
+
+COMMENT ON COLUMN "standing_orders"."occurrences" IS 'occurrences run so far, including skipped ones';
+
+COMMENT ON COLUMN "standing_orders"."next_run_at" IS 'null once the order has ended';
+
+COMMENT ON COLUMN "standing_orders"."status" IS 'active, completed or cancelled';
+
+COMMENT ON COLUMN "standing_order_executions"."status" IS 'completed, skipped or failed';
+
+ALTER TABLE "standing_orders" ADD CONSTRAINT "standing_order_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_orders" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("standing_order_id") REFERENCES "standing_orders" ("id");
+
+ALTER TABLE "standing_order_executions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "reverses_transfer_id" bigint;
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("reverses_transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "transfers" ("reverses_transfer_id");
+
+COMMENT ON COLUMN "transfers"."reverses_transfer_id" IS 'transfer this one reverses or refunds, money flows back from its to account';
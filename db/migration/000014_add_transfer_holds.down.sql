@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS "transfers_status_expires_at_idx";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "expires_at";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "status";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "available_balance";
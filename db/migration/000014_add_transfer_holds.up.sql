@@ -0,0 +1,17 @@
+ALTER TABLE "accounts" ADD COLUMN "available_balance" bigint;
+
+UPDATE "accounts" SET "available_balance" = "balance";
+
+ALTER TABLE "accounts" ALTER COLUMN "available_balance" SET NOT NULL;
+
+COMMENT ON COLUMN "accounts"."available_balance" IS 'balance less the amounts held by authorized transfers';
+
+ALTER TABLE "transfers" ADD COLUMN "status" varchar NOT NULL DEFAULT 'settled';
+
+ALTER TABLE "transfers" ADD COLUMN "expires_at" timestamptz;
+
+COMMENT ON COLUMN "transfers"."status" IS 'authorized, settled, voided or expired';
+
+COMMENT ON COLUMN "transfers"."expires_at" IS 'when an authorized transfer releases its hold';
+
+CREATE INDEX ON "transfers" ("status", "expires_at");
@@ -0,0 +1,32 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer that posted the entry, null for adjustments';
+
+-- a transfer and its entries are written in one transaction, so they share created_at
+UPDATE "entries" e SET "transfer_id" = t."id"
+FROM "transfers" t
+WHERE e."transfer_id" IS NULL
+AND e."created_at" = t."created_at"
+AND ((e."account_id" = t."from_account_id" AND e."amount" = -t."amount")
+  OR (e."account_id" = t."to_account_id" AND e."amount" = t."to_amount"));
+
+CREATE TABLE "reconciliation_reports" (
+  "id" bigserial PRIMARY KEY,
+  "started_at" timestamptz NOT NULL,
+  "finished_at" timestamptz NOT NULL,
+  "accounts_checked" bigint NOT NULL,
+  "transfers_checked" bigint NOT NULL,
+  "account_drifts" bigint NOT NULL,
+  "transfer_mismatches" bigint NOT NULL,
+  "report" json NOT NULL,
+  "signature" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "reconciliation_reports"."report" IS 'json, not jsonb, so the signed bytes are kept as they were';
+
+COMMENT ON COLUMN "reconciliation_reports"."signature" IS 'hex HMAC-SHA256 of report';
@@ -0,0 +1,13 @@
+CREATE TABLE "balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "snapshot_at" timestamptz NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "snapshot_at")
+);
+
+ALTER TABLE "balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+COMMENT ON COLUMN "balance_snapshots"."snapshot_at" IS 'end of the day the snapshot closes, midnight UTC';
+
+COMMENT ON COLUMN "balance_snapshots"."balance" IS 'sum of the account entries created up to snapshot_at';
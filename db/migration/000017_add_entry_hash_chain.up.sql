@@ -0,0 +1,9 @@
+ALTER TABLE "entries" ADD COLUMN "prev_hash" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries" ADD COLUMN "hash" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "entries" ("account_id", "id");
+
+COMMENT ON COLUMN "entries"."prev_hash" IS 'hash of the previous entry on the account, empty for the first chained one';
+
+COMMENT ON COLUMN "entries"."hash" IS 'hex HMAC-SHA256 of the entry and prev_hash, empty for entries posted before the chain';
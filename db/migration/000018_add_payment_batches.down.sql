@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "payment_batch_items";
+
+DROP TABLE IF EXISTS "payment_batches";
@@ -0,0 +1,48 @@
+CREATE TABLE "payment_batches" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "format" varchar NOT NULL,
+  "message_id" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "payment_batch_items" (
+  "id" bigserial PRIMARY KEY,
+  "batch_id" bigint NOT NULL,
+  "line" int NOT NULL,
+  "end_to_end_id" varchar NOT NULL DEFAULT '',
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "transfer_id" bigint,
+  "failure_reason" varchar,
+  "processed_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "payment_batches" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "payment_batch_items" ADD FOREIGN KEY ("batch_id") REFERENCES "payment_batches" ("id");
+
+ALTER TABLE "payment_batch_items" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "payment_batches" ("owner", "created_at", "id");
+
+-- the same pain.001 message can't be submitted twice
+CREATE UNIQUE INDEX ON "payment_batches" ("owner", "message_id") WHERE "message_id" <> '';
+
+CREATE UNIQUE INDEX ON "payment_batch_items" ("batch_id", "line");
+
+CREATE INDEX ON "payment_batch_items" ("status", "batch_id", "line");
+
+COMMENT ON COLUMN "payment_batches"."format" IS 'pain001 or csv';
+
+COMMENT ON COLUMN "payment_batches"."message_id" IS 'pain.001 GrpHdr/MsgId, empty for csv';
+
+COMMENT ON COLUMN "payment_batch_items"."line" IS 'position of the instruction in the uploaded file, from 1';
+
+COMMENT ON COLUMN "payment_batch_items"."from_account_id" IS 'as uploaded, the account may not exist when the item is rejected';
+
+COMMENT ON COLUMN "payment_batch_items"."status" IS 'pending, completed, rejected on upload or failed on execution';
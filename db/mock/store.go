@@ -6,9 +6,12 @@ package mockdb
 
 import (
 	context "context"
+	sql "database/sql"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	db "github.com/joekings2k/gobank/db/sqlc"
 )
 
@@ -35,212 +38,1184 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AddAccountAvailableBalance mocks base method.
+func (m *MockStore) AddAccountAvailableBalance(arg0 context.Context, arg1 db.AddAccountAvailableBalanceParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAccountAvailableBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountAvailableBalance indicates an expected call of AddAccountAvailableBalance.
+func (mr *MockStoreMockRecorder) AddAccountAvailableBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountAvailableBalance", reflect.TypeOf((*MockStore)(nil).AddAccountAvailableBalance), arg0, arg1)
+}
+
 // AddAccountBalance mocks base method.
 func (m *MockStore) AddAccountBalance(arg0 context.Context, arg1 db.AddAccountBalanceParams) (db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddAccountBalance", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "AddAccountBalance", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAccountBalance indicates an expected call of AddAccountBalance.
+func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
+}
+
+// AdjustBalanceTx mocks base method.
+func (m *MockStore) AdjustBalanceTx(arg0 context.Context, arg1 db.AdjustBalanceTxParams) (db.AdjustBalanceTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalanceTx", arg0, arg1)
+	ret0, _ := ret[0].(db.AdjustBalanceTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalanceTx indicates an expected call of AdjustBalanceTx.
+func (mr *MockStoreMockRecorder) AdjustBalanceTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalanceTx", reflect.TypeOf((*MockStore)(nil).AdjustBalanceTx), arg0, arg1)
+}
+
+// AdvanceStandingOrder mocks base method.
+func (m *MockStore) AdvanceStandingOrder(arg0 context.Context, arg1 db.AdvanceStandingOrderParams) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceStandingOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceStandingOrder indicates an expected call of AdvanceStandingOrder.
+func (mr *MockStoreMockRecorder) AdvanceStandingOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceStandingOrder", reflect.TypeOf((*MockStore)(nil).AdvanceStandingOrder), arg0, arg1)
+}
+
+// AuthorizeTransferTx mocks base method.
+func (m *MockStore) AuthorizeTransferTx(arg0 context.Context, arg1 db.AuthorizeTransferTxParams) (db.TransferHoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthorizeTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferHoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthorizeTransferTx indicates an expected call of AuthorizeTransferTx.
+func (mr *MockStoreMockRecorder) AuthorizeTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthorizeTransferTx", reflect.TypeOf((*MockStore)(nil).AuthorizeTransferTx), arg0, arg1)
+}
+
+// BlockSession mocks base method.
+func (m *MockStore) BlockSession(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSession", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockSession indicates an expected call of BlockSession.
+func (mr *MockStoreMockRecorder) BlockSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSession", reflect.TypeOf((*MockStore)(nil).BlockSession), arg0, arg1)
+}
+
+// BlockUserSessions mocks base method.
+func (m *MockStore) BlockUserSessions(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockUserSessions", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlockUserSessions indicates an expected call of BlockUserSessions.
+func (mr *MockStoreMockRecorder) BlockUserSessions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockUserSessions", reflect.TypeOf((*MockStore)(nil).BlockUserSessions), arg0, arg1)
+}
+
+// CancelScheduledTransfer mocks base method.
+func (m *MockStore) CancelScheduledTransfer(arg0 context.Context, arg1 int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelScheduledTransfer indicates an expected call of CancelScheduledTransfer.
+func (mr *MockStoreMockRecorder) CancelScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CancelScheduledTransfer), arg0, arg1)
+}
+
+// CancelStandingOrder mocks base method.
+func (m *MockStore) CancelStandingOrder(arg0 context.Context, arg1 int64) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelStandingOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelStandingOrder indicates an expected call of CancelStandingOrder.
+func (mr *MockStoreMockRecorder) CancelStandingOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelStandingOrder", reflect.TypeOf((*MockStore)(nil).CancelStandingOrder), arg0, arg1)
+}
+
+// CaptureTransferTx mocks base method.
+func (m *MockStore) CaptureTransferTx(arg0 context.Context, arg1 int64) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CaptureTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CaptureTransferTx indicates an expected call of CaptureTransferTx.
+func (mr *MockStoreMockRecorder) CaptureTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CaptureTransferTx", reflect.TypeOf((*MockStore)(nil).CaptureTransferTx), arg0, arg1)
+}
+
+// CompletePaymentBatchItem mocks base method.
+func (m *MockStore) CompletePaymentBatchItem(arg0 context.Context, arg1 db.CompletePaymentBatchItemParams) (db.PaymentBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompletePaymentBatchItem", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompletePaymentBatchItem indicates an expected call of CompletePaymentBatchItem.
+func (mr *MockStoreMockRecorder) CompletePaymentBatchItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletePaymentBatchItem", reflect.TypeOf((*MockStore)(nil).CompletePaymentBatchItem), arg0, arg1)
+}
+
+// CompleteScheduledTransfer mocks base method.
+func (m *MockStore) CompleteScheduledTransfer(arg0 context.Context, arg1 db.CompleteScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteScheduledTransfer indicates an expected call of CompleteScheduledTransfer.
+func (mr *MockStoreMockRecorder) CompleteScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CompleteScheduledTransfer), arg0, arg1)
+}
+
+// CountAccountEntries mocks base method.
+func (m *MockStore) CountAccountEntries(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountAccountEntries indicates an expected call of CountAccountEntries.
+func (mr *MockStoreMockRecorder) CountAccountEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountAccountEntries", reflect.TypeOf((*MockStore)(nil).CountAccountEntries), arg0, arg1)
+}
+
+// CountPaymentBatchItems mocks base method.
+func (m *MockStore) CountPaymentBatchItems(arg0 context.Context, arg1 int64) ([]db.CountPaymentBatchItemsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPaymentBatchItems", arg0, arg1)
+	ret0, _ := ret[0].([]db.CountPaymentBatchItemsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPaymentBatchItems indicates an expected call of CountPaymentBatchItems.
+func (mr *MockStoreMockRecorder) CountPaymentBatchItems(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPaymentBatchItems", reflect.TypeOf((*MockStore)(nil).CountPaymentBatchItems), arg0, arg1)
+}
+
+// CreateAccount mocks base method.
+func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAccount indicates an expected call of CreateAccount.
+func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+}
+
+// CreateBalanceSnapshots mocks base method.
+func (m *MockStore) CreateBalanceSnapshots(arg0 context.Context, arg1 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateBalanceSnapshots", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateBalanceSnapshots indicates an expected call of CreateBalanceSnapshots.
+func (mr *MockStoreMockRecorder) CreateBalanceSnapshots(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateBalanceSnapshots", reflect.TypeOf((*MockStore)(nil).CreateBalanceSnapshots), arg0, arg1)
+}
+
+// CreateEntry mocks base method.
+func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateEntry indicates an expected call of CreateEntry.
+func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+}
+
+// CreateExchangeRate mocks base method.
+func (m *MockStore) CreateExchangeRate(arg0 context.Context, arg1 db.CreateExchangeRateParams) (db.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExchangeRate", arg0, arg1)
+	ret0, _ := ret[0].(db.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExchangeRate indicates an expected call of CreateExchangeRate.
+func (mr *MockStoreMockRecorder) CreateExchangeRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExchangeRate", reflect.TypeOf((*MockStore)(nil).CreateExchangeRate), arg0, arg1)
+}
+
+// CreateExchangeRatesTx mocks base method.
+func (m *MockStore) CreateExchangeRatesTx(arg0 context.Context, arg1 []db.CreateExchangeRateParams) ([]db.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateExchangeRatesTx", arg0, arg1)
+	ret0, _ := ret[0].([]db.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateExchangeRatesTx indicates an expected call of CreateExchangeRatesTx.
+func (mr *MockStoreMockRecorder) CreateExchangeRatesTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateExchangeRatesTx", reflect.TypeOf((*MockStore)(nil).CreateExchangeRatesTx), arg0, arg1)
+}
+
+// CreateIdempotencyKey mocks base method.
+func (m *MockStore) CreateIdempotencyKey(arg0 context.Context, arg1 db.CreateIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateIdempotencyKey indicates an expected call of CreateIdempotencyKey.
+func (mr *MockStoreMockRecorder) CreateIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIdempotencyKey", reflect.TypeOf((*MockStore)(nil).CreateIdempotencyKey), arg0, arg1)
+}
+
+// CreatePaymentBatch mocks base method.
+func (m *MockStore) CreatePaymentBatch(arg0 context.Context, arg1 db.CreatePaymentBatchParams) (db.PaymentBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentBatch", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentBatch indicates an expected call of CreatePaymentBatch.
+func (mr *MockStoreMockRecorder) CreatePaymentBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentBatch", reflect.TypeOf((*MockStore)(nil).CreatePaymentBatch), arg0, arg1)
+}
+
+// CreatePaymentBatchItem mocks base method.
+func (m *MockStore) CreatePaymentBatchItem(arg0 context.Context, arg1 db.CreatePaymentBatchItemParams) (db.PaymentBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentBatchItem", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentBatchItem indicates an expected call of CreatePaymentBatchItem.
+func (mr *MockStoreMockRecorder) CreatePaymentBatchItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentBatchItem", reflect.TypeOf((*MockStore)(nil).CreatePaymentBatchItem), arg0, arg1)
+}
+
+// CreatePaymentBatchTx mocks base method.
+func (m *MockStore) CreatePaymentBatchTx(arg0 context.Context, arg1 db.CreatePaymentBatchTxParams) (db.CreatePaymentBatchTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePaymentBatchTx", arg0, arg1)
+	ret0, _ := ret[0].(db.CreatePaymentBatchTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreatePaymentBatchTx indicates an expected call of CreatePaymentBatchTx.
+func (mr *MockStoreMockRecorder) CreatePaymentBatchTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePaymentBatchTx", reflect.TypeOf((*MockStore)(nil).CreatePaymentBatchTx), arg0, arg1)
+}
+
+// CreateReconciliationReport mocks base method.
+func (m *MockStore) CreateReconciliationReport(arg0 context.Context, arg1 db.CreateReconciliationReportParams) (db.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReconciliationReport", arg0, arg1)
+	ret0, _ := ret[0].(db.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateReconciliationReport indicates an expected call of CreateReconciliationReport.
+func (mr *MockStoreMockRecorder) CreateReconciliationReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReconciliationReport", reflect.TypeOf((*MockStore)(nil).CreateReconciliationReport), arg0, arg1)
+}
+
+// CreateScheduledTransfer mocks base method.
+func (m *MockStore) CreateScheduledTransfer(arg0 context.Context, arg1 db.CreateScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateScheduledTransfer indicates an expected call of CreateScheduledTransfer.
+func (mr *MockStoreMockRecorder) CreateScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateScheduledTransfer", reflect.TypeOf((*MockStore)(nil).CreateScheduledTransfer), arg0, arg1)
+}
+
+// CreateSession mocks base method.
+func (m *MockStore) CreateSession(arg0 context.Context, arg1 db.CreateSessionParams) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockStoreMockRecorder) CreateSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockStore)(nil).CreateSession), arg0, arg1)
+}
+
+// CreateStandingOrder mocks base method.
+func (m *MockStore) CreateStandingOrder(arg0 context.Context, arg1 db.CreateStandingOrderParams) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStandingOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStandingOrder indicates an expected call of CreateStandingOrder.
+func (mr *MockStoreMockRecorder) CreateStandingOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStandingOrder", reflect.TypeOf((*MockStore)(nil).CreateStandingOrder), arg0, arg1)
+}
+
+// CreateStandingOrderExecution mocks base method.
+func (m *MockStore) CreateStandingOrderExecution(arg0 context.Context, arg1 db.CreateStandingOrderExecutionParams) (db.StandingOrderExecution, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateStandingOrderExecution", arg0, arg1)
+	ret0, _ := ret[0].(db.StandingOrderExecution)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateStandingOrderExecution indicates an expected call of CreateStandingOrderExecution.
+func (mr *MockStoreMockRecorder) CreateStandingOrderExecution(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateStandingOrderExecution", reflect.TypeOf((*MockStore)(nil).CreateStandingOrderExecution), arg0, arg1)
+}
+
+// CreateTransfer mocks base method.
+func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransfer indicates an expected call of CreateTransfer.
+func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+}
+
+// CreateTransferHold mocks base method.
+func (m *MockStore) CreateTransferHold(arg0 context.Context, arg1 db.CreateTransferHoldParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTransferHold", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTransferHold indicates an expected call of CreateTransferHold.
+func (mr *MockStoreMockRecorder) CreateTransferHold(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransferHold", reflect.TypeOf((*MockStore)(nil).CreateTransferHold), arg0, arg1)
+}
+
+// CreateUser mocks base method.
+func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+}
+
+// DeleteAccountTx mocks base method.
+func (m *MockStore) DeleteAccountTx(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccountTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccountTx indicates an expected call of DeleteAccountTx.
+func (mr *MockStoreMockRecorder) DeleteAccountTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountTx", reflect.TypeOf((*MockStore)(nil).DeleteAccountTx), arg0, arg1)
+}
+
+// DeleteEntry mocks base method.
+func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteEntry indicates an expected call of DeleteEntry.
+func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+}
+
+// DeleteExpiredIdempotencyKeys mocks base method.
+func (m *MockStore) DeleteExpiredIdempotencyKeys(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredIdempotencyKeys", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredIdempotencyKeys indicates an expected call of DeleteExpiredIdempotencyKeys.
+func (mr *MockStoreMockRecorder) DeleteExpiredIdempotencyKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredIdempotencyKeys", reflect.TypeOf((*MockStore)(nil).DeleteExpiredIdempotencyKeys), arg0)
+}
+
+// DeleteExpiredRevokedTokens mocks base method.
+func (m *MockStore) DeleteExpiredRevokedTokens(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteExpiredRevokedTokens", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteExpiredRevokedTokens indicates an expected call of DeleteExpiredRevokedTokens.
+func (mr *MockStoreMockRecorder) DeleteExpiredRevokedTokens(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteExpiredRevokedTokens", reflect.TypeOf((*MockStore)(nil).DeleteExpiredRevokedTokens), arg0)
+}
+
+// ExecutePaymentBatchItemTx mocks base method.
+func (m *MockStore) ExecutePaymentBatchItemTx(arg0 context.Context) (db.ExecutePaymentBatchItemTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecutePaymentBatchItemTx", arg0)
+	ret0, _ := ret[0].(db.ExecutePaymentBatchItemTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecutePaymentBatchItemTx indicates an expected call of ExecutePaymentBatchItemTx.
+func (mr *MockStoreMockRecorder) ExecutePaymentBatchItemTx(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecutePaymentBatchItemTx", reflect.TypeOf((*MockStore)(nil).ExecutePaymentBatchItemTx), arg0)
+}
+
+// ExecuteScheduledTransferTx mocks base method.
+func (m *MockStore) ExecuteScheduledTransferTx(arg0 context.Context) (db.ExecuteScheduledTransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteScheduledTransferTx", arg0)
+	ret0, _ := ret[0].(db.ExecuteScheduledTransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteScheduledTransferTx indicates an expected call of ExecuteScheduledTransferTx.
+func (mr *MockStoreMockRecorder) ExecuteScheduledTransferTx(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteScheduledTransferTx", reflect.TypeOf((*MockStore)(nil).ExecuteScheduledTransferTx), arg0)
+}
+
+// ExecuteStandingOrderTx mocks base method.
+func (m *MockStore) ExecuteStandingOrderTx(arg0 context.Context) (db.ExecuteStandingOrderTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExecuteStandingOrderTx", arg0)
+	ret0, _ := ret[0].(db.ExecuteStandingOrderTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExecuteStandingOrderTx indicates an expected call of ExecuteStandingOrderTx.
+func (mr *MockStoreMockRecorder) ExecuteStandingOrderTx(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecuteStandingOrderTx", reflect.TypeOf((*MockStore)(nil).ExecuteStandingOrderTx), arg0)
+}
+
+// ExpireTransferHoldsTx mocks base method.
+func (m *MockStore) ExpireTransferHoldsTx(arg0 context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireTransferHoldsTx", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireTransferHoldsTx indicates an expected call of ExpireTransferHoldsTx.
+func (mr *MockStoreMockRecorder) ExpireTransferHoldsTx(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireTransferHoldsTx", reflect.TypeOf((*MockStore)(nil).ExpireTransferHoldsTx), arg0)
+}
+
+// FailPaymentBatchItem mocks base method.
+func (m *MockStore) FailPaymentBatchItem(arg0 context.Context, arg1 db.FailPaymentBatchItemParams) (db.PaymentBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailPaymentBatchItem", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailPaymentBatchItem indicates an expected call of FailPaymentBatchItem.
+func (mr *MockStoreMockRecorder) FailPaymentBatchItem(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailPaymentBatchItem", reflect.TypeOf((*MockStore)(nil).FailPaymentBatchItem), arg0, arg1)
+}
+
+// FailScheduledTransfer mocks base method.
+func (m *MockStore) FailScheduledTransfer(arg0 context.Context, arg1 db.FailScheduledTransferParams) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FailScheduledTransfer indicates an expected call of FailScheduledTransfer.
+func (mr *MockStoreMockRecorder) FailScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailScheduledTransfer", reflect.TypeOf((*MockStore)(nil).FailScheduledTransfer), arg0, arg1)
+}
+
+// GetAccount mocks base method.
+func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccount indicates an expected call of GetAccount.
+func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+}
+
+// GetAccountBalanceAsOf mocks base method.
+func (m *MockStore) GetAccountBalanceAsOf(arg0 context.Context, arg1 db.GetAccountBalanceAsOfParams) (db.GetAccountBalanceAsOfRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountBalanceAsOf", arg0, arg1)
+	ret0, _ := ret[0].(db.GetAccountBalanceAsOfRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountBalanceAsOf indicates an expected call of GetAccountBalanceAsOf.
+func (mr *MockStoreMockRecorder) GetAccountBalanceAsOf(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountBalanceAsOf", reflect.TypeOf((*MockStore)(nil).GetAccountBalanceAsOf), arg0, arg1)
+}
+
+// GetAccountForupdate mocks base method.
+func (m *MockStore) GetAccountForupdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountForupdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountForupdate indicates an expected call of GetAccountForupdate.
+func (mr *MockStoreMockRecorder) GetAccountForupdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForupdate", reflect.TypeOf((*MockStore)(nil).GetAccountForupdate), arg0, arg1)
+}
+
+// GetDueScheduledTransferForUpdate mocks base method.
+func (m *MockStore) GetDueScheduledTransferForUpdate(arg0 context.Context) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueScheduledTransferForUpdate", arg0)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueScheduledTransferForUpdate indicates an expected call of GetDueScheduledTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetDueScheduledTransferForUpdate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueScheduledTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetDueScheduledTransferForUpdate), arg0)
+}
+
+// GetDueStandingOrderForUpdate mocks base method.
+func (m *MockStore) GetDueStandingOrderForUpdate(arg0 context.Context) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueStandingOrderForUpdate", arg0)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueStandingOrderForUpdate indicates an expected call of GetDueStandingOrderForUpdate.
+func (mr *MockStoreMockRecorder) GetDueStandingOrderForUpdate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueStandingOrderForUpdate", reflect.TypeOf((*MockStore)(nil).GetDueStandingOrderForUpdate), arg0)
+}
+
+// GetEntry mocks base method.
+func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+}
+
+// GetExchangeRate mocks base method.
+func (m *MockStore) GetExchangeRate(arg0 context.Context, arg1 db.GetExchangeRateParams) (db.ExchangeRate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExchangeRate", arg0, arg1)
+	ret0, _ := ret[0].(db.ExchangeRate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExchangeRate indicates an expected call of GetExchangeRate.
+func (mr *MockStoreMockRecorder) GetExchangeRate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExchangeRate", reflect.TypeOf((*MockStore)(nil).GetExchangeRate), arg0, arg1)
+}
+
+// GetExpiredTransferHoldForUpdate mocks base method.
+func (m *MockStore) GetExpiredTransferHoldForUpdate(arg0 context.Context) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExpiredTransferHoldForUpdate", arg0)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExpiredTransferHoldForUpdate indicates an expected call of GetExpiredTransferHoldForUpdate.
+func (mr *MockStoreMockRecorder) GetExpiredTransferHoldForUpdate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredTransferHoldForUpdate", reflect.TypeOf((*MockStore)(nil).GetExpiredTransferHoldForUpdate), arg0)
+}
+
+// GetIdempotencyKey mocks base method.
+func (m *MockStore) GetIdempotencyKey(arg0 context.Context, arg1 db.GetIdempotencyKeyParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIdempotencyKey", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIdempotencyKey indicates an expected call of GetIdempotencyKey.
+func (mr *MockStoreMockRecorder) GetIdempotencyKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIdempotencyKey", reflect.TypeOf((*MockStore)(nil).GetIdempotencyKey), arg0, arg1)
+}
+
+// GetLastEntryHash mocks base method.
+func (m *MockStore) GetLastEntryHash(arg0 context.Context, arg1 int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastEntryHash", arg0, arg1)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastEntryHash indicates an expected call of GetLastEntryHash.
+func (mr *MockStoreMockRecorder) GetLastEntryHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastEntryHash", reflect.TypeOf((*MockStore)(nil).GetLastEntryHash), arg0, arg1)
+}
+
+// GetMigrationVersion mocks base method.
+func (m *MockStore) GetMigrationVersion(arg0 context.Context) (db.MigrationVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigrationVersion", arg0)
+	ret0, _ := ret[0].(db.MigrationVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMigrationVersion indicates an expected call of GetMigrationVersion.
+func (mr *MockStoreMockRecorder) GetMigrationVersion(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigrationVersion", reflect.TypeOf((*MockStore)(nil).GetMigrationVersion), arg0)
+}
+
+// GetPaymentBatch mocks base method.
+func (m *MockStore) GetPaymentBatch(arg0 context.Context, arg1 int64) (db.PaymentBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentBatch", arg0, arg1)
+	ret0, _ := ret[0].(db.PaymentBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentBatch indicates an expected call of GetPaymentBatch.
+func (mr *MockStoreMockRecorder) GetPaymentBatch(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentBatch", reflect.TypeOf((*MockStore)(nil).GetPaymentBatch), arg0, arg1)
+}
+
+// GetPendingPaymentBatchItemForUpdate mocks base method.
+func (m *MockStore) GetPendingPaymentBatchItemForUpdate(arg0 context.Context) (db.PaymentBatchItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingPaymentBatchItemForUpdate", arg0)
+	ret0, _ := ret[0].(db.PaymentBatchItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingPaymentBatchItemForUpdate indicates an expected call of GetPendingPaymentBatchItemForUpdate.
+func (mr *MockStoreMockRecorder) GetPendingPaymentBatchItemForUpdate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingPaymentBatchItemForUpdate", reflect.TypeOf((*MockStore)(nil).GetPendingPaymentBatchItemForUpdate), arg0)
+}
+
+// GetReconciliationReport mocks base method.
+func (m *MockStore) GetReconciliationReport(arg0 context.Context, arg1 int64) (db.ReconciliationReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReconciliationReport", arg0, arg1)
+	ret0, _ := ret[0].(db.ReconciliationReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReconciliationReport indicates an expected call of GetReconciliationReport.
+func (mr *MockStoreMockRecorder) GetReconciliationReport(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReconciliationReport", reflect.TypeOf((*MockStore)(nil).GetReconciliationReport), arg0, arg1)
+}
+
+// GetScheduledTransfer mocks base method.
+func (m *MockStore) GetScheduledTransfer(arg0 context.Context, arg1 int64) (db.ScheduledTransfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.ScheduledTransfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledTransfer indicates an expected call of GetScheduledTransfer.
+func (mr *MockStoreMockRecorder) GetScheduledTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledTransfer", reflect.TypeOf((*MockStore)(nil).GetScheduledTransfer), arg0, arg1)
+}
+
+// GetSession mocks base method.
+func (m *MockStore) GetSession(arg0 context.Context, arg1 uuid.UUID) (db.Session, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSession", arg0, arg1)
+	ret0, _ := ret[0].(db.Session)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSession indicates an expected call of GetSession.
+func (mr *MockStoreMockRecorder) GetSession(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockStore)(nil).GetSession), arg0, arg1)
+}
+
+// GetStandingOrder mocks base method.
+func (m *MockStore) GetStandingOrder(arg0 context.Context, arg1 int64) (db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStandingOrder", arg0, arg1)
+	ret0, _ := ret[0].(db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStandingOrder indicates an expected call of GetStandingOrder.
+func (mr *MockStoreMockRecorder) GetStandingOrder(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStandingOrder", reflect.TypeOf((*MockStore)(nil).GetStandingOrder), arg0, arg1)
+}
+
+// GetTransfer mocks base method.
+func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransfer indicates an expected call of GetTransfer.
+func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+}
+
+// GetTransferForUpdate mocks base method.
+func (m *MockStore) GetTransferForUpdate(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferForUpdate", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTransferForUpdate indicates an expected call of GetTransferForUpdate.
+func (mr *MockStoreMockRecorder) GetTransferForUpdate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferForUpdate", reflect.TypeOf((*MockStore)(nil).GetTransferForUpdate), arg0, arg1)
+}
+
+// GetTransferReversedAmounts mocks base method.
+func (m *MockStore) GetTransferReversedAmounts(arg0 context.Context, arg1 sql.NullInt64) (db.GetTransferReversedAmountsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTransferReversedAmounts", arg0, arg1)
+	ret0, _ := ret[0].(db.GetTransferReversedAmountsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddAccountBalance indicates an expected call of AddAccountBalance.
-func (mr *MockStoreMockRecorder) AddAccountBalance(arg0, arg1 interface{}) *gomock.Call {
+// GetTransferReversedAmounts indicates an expected call of GetTransferReversedAmounts.
+func (mr *MockStoreMockRecorder) GetTransferReversedAmounts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAccountBalance", reflect.TypeOf((*MockStore)(nil).AddAccountBalance), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransferReversedAmounts", reflect.TypeOf((*MockStore)(nil).GetTransferReversedAmounts), arg0, arg1)
 }
 
-// CreateAccount mocks base method.
-func (m *MockStore) CreateAccount(arg0 context.Context, arg1 db.CreateAccountParams) (db.Account, error) {
+// GetUser mocks base method.
+func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateAccount indicates an expected call of CreateAccount.
-func (mr *MockStoreMockRecorder) CreateAccount(arg0, arg1 interface{}) *gomock.Call {
+// GetUser indicates an expected call of GetUser.
+func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
 }
 
-// CreateEntry mocks base method.
-func (m *MockStore) CreateEntry(arg0 context.Context, arg1 db.CreateEntryParams) (db.Entry, error) {
+// IsTokenRevoked mocks base method.
+func (m *MockStore) IsTokenRevoked(arg0 context.Context, arg1 db.IsTokenRevokedParams) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "IsTokenRevoked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateEntry indicates an expected call of CreateEntry.
-func (mr *MockStoreMockRecorder) CreateEntry(arg0, arg1 interface{}) *gomock.Call {
+// IsTokenRevoked indicates an expected call of IsTokenRevoked.
+func (mr *MockStoreMockRecorder) IsTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateEntry", reflect.TypeOf((*MockStore)(nil).CreateEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsTokenRevoked", reflect.TypeOf((*MockStore)(nil).IsTokenRevoked), arg0, arg1)
 }
 
-// CreateTransfer mocks base method.
-func (m *MockStore) CreateTransfer(arg0 context.Context, arg1 db.CreateTransferParams) (db.Transfer, error) {
+// ListAccountEntries mocks base method.
+func (m *MockStore) ListAccountEntries(arg0 context.Context, arg1 db.ListAccountEntriesParams) ([]db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ListAccountEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateTransfer indicates an expected call of CreateTransfer.
-func (mr *MockStoreMockRecorder) CreateTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ListAccountEntries indicates an expected call of ListAccountEntries.
+func (mr *MockStoreMockRecorder) ListAccountEntries(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTransfer", reflect.TypeOf((*MockStore)(nil).CreateTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountEntries", reflect.TypeOf((*MockStore)(nil).ListAccountEntries), arg0, arg1)
 }
 
-// CreateUser mocks base method.
-func (m *MockStore) CreateUser(arg0 context.Context, arg1 db.CreateUserParams) (db.User, error) {
+// ListAccountLedgerTotals mocks base method.
+func (m *MockStore) ListAccountLedgerTotals(arg0 context.Context, arg1 db.ListAccountLedgerTotalsParams) ([]db.ListAccountLedgerTotalsRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListAccountLedgerTotals", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListAccountLedgerTotalsRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateUser indicates an expected call of CreateUser.
-func (mr *MockStoreMockRecorder) CreateUser(arg0, arg1 interface{}) *gomock.Call {
+// ListAccountLedgerTotals indicates an expected call of ListAccountLedgerTotals.
+func (mr *MockStoreMockRecorder) ListAccountLedgerTotals(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockStore)(nil).CreateUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountLedgerTotals", reflect.TypeOf((*MockStore)(nil).ListAccountLedgerTotals), arg0, arg1)
 }
 
-// DeleteAccount mocks base method.
-func (m *MockStore) DeleteAccount(arg0 context.Context, arg1 int64) error {
+// ListAccountTransfers mocks base method.
+func (m *MockStore) ListAccountTransfers(arg0 context.Context, arg1 db.ListAccountTransfersParams) ([]db.Transfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAccount", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListAccountTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteAccount indicates an expected call of DeleteAccount.
-func (mr *MockStoreMockRecorder) DeleteAccount(arg0, arg1 interface{}) *gomock.Call {
+// ListAccountTransfers indicates an expected call of ListAccountTransfers.
+func (mr *MockStoreMockRecorder) ListAccountTransfers(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockStore)(nil).DeleteAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountTransfers", reflect.TypeOf((*MockStore)(nil).ListAccountTransfers), arg0, arg1)
 }
 
-// DeleteEntry mocks base method.
-func (m *MockStore) DeleteEntry(arg0 context.Context, arg1 int64) error {
+// ListAccounts mocks base method.
+func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteEntry", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// DeleteEntry indicates an expected call of DeleteEntry.
-func (mr *MockStoreMockRecorder) DeleteEntry(arg0, arg1 interface{}) *gomock.Call {
+// ListAccounts indicates an expected call of ListAccounts.
+func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteEntry", reflect.TypeOf((*MockStore)(nil).DeleteEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
 }
 
-// GetAccount mocks base method.
-func (m *MockStore) GetAccount(arg0 context.Context, arg1 int64) (db.Account, error) {
+// ListAccountsAfter mocks base method.
+func (m *MockStore) ListAccountsAfter(arg0 context.Context, arg1 db.ListAccountsAfterParams) ([]db.Account, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccount", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "ListAccountsAfter", arg0, arg1)
+	ret0, _ := ret[0].([]db.Account)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccount indicates an expected call of GetAccount.
-func (mr *MockStoreMockRecorder) GetAccount(arg0, arg1 interface{}) *gomock.Call {
+// ListAccountsAfter indicates an expected call of ListAccountsAfter.
+func (mr *MockStoreMockRecorder) ListAccountsAfter(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccount", reflect.TypeOf((*MockStore)(nil).GetAccount), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccountsAfter", reflect.TypeOf((*MockStore)(nil).ListAccountsAfter), arg0, arg1)
 }
 
-// GetAccountForupdate mocks base method.
-func (m *MockStore) GetAccountForupdate(arg0 context.Context, arg1 int64) (db.Account, error) {
+// ListEntries mocks base method.
+func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAccountForupdate", arg0, arg1)
-	ret0, _ := ret[0].(db.Account)
+	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAccountForupdate indicates an expected call of GetAccountForupdate.
-func (mr *MockStoreMockRecorder) GetAccountForupdate(arg0, arg1 interface{}) *gomock.Call {
+// ListEntries indicates an expected call of ListEntries.
+func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountForupdate", reflect.TypeOf((*MockStore)(nil).GetAccountForupdate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
 }
 
-// GetEntry mocks base method.
-func (m *MockStore) GetEntry(arg0 context.Context, arg1 int64) (db.Entry, error) {
+// ListEntryChain mocks base method.
+func (m *MockStore) ListEntryChain(arg0 context.Context, arg1 db.ListEntryChainParams) ([]db.Entry, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEntry", arg0, arg1)
-	ret0, _ := ret[0].(db.Entry)
+	ret := m.ctrl.Call(m, "ListEntryChain", arg0, arg1)
+	ret0, _ := ret[0].([]db.Entry)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetEntry indicates an expected call of GetEntry.
-func (mr *MockStoreMockRecorder) GetEntry(arg0, arg1 interface{}) *gomock.Call {
+// ListEntryChain indicates an expected call of ListEntryChain.
+func (mr *MockStoreMockRecorder) ListEntryChain(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockStore)(nil).GetEntry), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntryChain", reflect.TypeOf((*MockStore)(nil).ListEntryChain), arg0, arg1)
 }
 
-// GetTransfer mocks base method.
-func (m *MockStore) GetTransfer(arg0 context.Context, arg1 int64) (db.Transfer, error) {
+// ListExchangeRates mocks base method.
+func (m *MockStore) ListExchangeRates(arg0 context.Context, arg1 db.ListExchangeRatesParams) ([]db.ExchangeRate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetTransfer", arg0, arg1)
-	ret0, _ := ret[0].(db.Transfer)
+	ret := m.ctrl.Call(m, "ListExchangeRates", arg0, arg1)
+	ret0, _ := ret[0].([]db.ExchangeRate)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetTransfer indicates an expected call of GetTransfer.
-func (mr *MockStoreMockRecorder) GetTransfer(arg0, arg1 interface{}) *gomock.Call {
+// ListExchangeRates indicates an expected call of ListExchangeRates.
+func (mr *MockStoreMockRecorder) ListExchangeRates(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTransfer", reflect.TypeOf((*MockStore)(nil).GetTransfer), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExchangeRates", reflect.TypeOf((*MockStore)(nil).ListExchangeRates), arg0, arg1)
 }
 
-// GetUser mocks base method.
-func (m *MockStore) GetUser(arg0 context.Context, arg1 string) (db.User, error) {
+// ListPaymentBatchItems mocks base method.
+func (m *MockStore) ListPaymentBatchItems(arg0 context.Context, arg1 db.ListPaymentBatchItemsParams) ([]db.PaymentBatchItem, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUser", arg0, arg1)
-	ret0, _ := ret[0].(db.User)
+	ret := m.ctrl.Call(m, "ListPaymentBatchItems", arg0, arg1)
+	ret0, _ := ret[0].([]db.PaymentBatchItem)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUser indicates an expected call of GetUser.
-func (mr *MockStoreMockRecorder) GetUser(arg0, arg1 interface{}) *gomock.Call {
+// ListPaymentBatchItems indicates an expected call of ListPaymentBatchItems.
+func (mr *MockStoreMockRecorder) ListPaymentBatchItems(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockStore)(nil).GetUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentBatchItems", reflect.TypeOf((*MockStore)(nil).ListPaymentBatchItems), arg0, arg1)
 }
 
-// ListAccounts mocks base method.
-func (m *MockStore) ListAccounts(arg0 context.Context, arg1 db.ListAccountsParams) ([]db.Account, error) {
+// ListPaymentBatches mocks base method.
+func (m *MockStore) ListPaymentBatches(arg0 context.Context, arg1 db.ListPaymentBatchesParams) ([]db.PaymentBatch, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListAccounts", arg0, arg1)
-	ret0, _ := ret[0].([]db.Account)
+	ret := m.ctrl.Call(m, "ListPaymentBatches", arg0, arg1)
+	ret0, _ := ret[0].([]db.PaymentBatch)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListAccounts indicates an expected call of ListAccounts.
-func (mr *MockStoreMockRecorder) ListAccounts(arg0, arg1 interface{}) *gomock.Call {
+// ListPaymentBatches indicates an expected call of ListPaymentBatches.
+func (mr *MockStoreMockRecorder) ListPaymentBatches(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAccounts", reflect.TypeOf((*MockStore)(nil).ListAccounts), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPaymentBatches", reflect.TypeOf((*MockStore)(nil).ListPaymentBatches), arg0, arg1)
 }
 
-// ListEntries mocks base method.
-func (m *MockStore) ListEntries(arg0 context.Context, arg1 db.ListEntriesParams) ([]db.Entry, error) {
+// ListScheduledTransfers mocks base method.
+func (m *MockStore) ListScheduledTransfers(arg0 context.Context, arg1 db.ListScheduledTransfersParams) ([]db.ScheduledTransfer, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListEntries", arg0, arg1)
-	ret0, _ := ret[0].([]db.Entry)
+	ret := m.ctrl.Call(m, "ListScheduledTransfers", arg0, arg1)
+	ret0, _ := ret[0].([]db.ScheduledTransfer)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ListEntries indicates an expected call of ListEntries.
-func (mr *MockStoreMockRecorder) ListEntries(arg0, arg1 interface{}) *gomock.Call {
+// ListScheduledTransfers indicates an expected call of ListScheduledTransfers.
+func (mr *MockStoreMockRecorder) ListScheduledTransfers(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEntries", reflect.TypeOf((*MockStore)(nil).ListEntries), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListScheduledTransfers", reflect.TypeOf((*MockStore)(nil).ListScheduledTransfers), arg0, arg1)
+}
+
+// ListStandingOrderExecutions mocks base method.
+func (m *MockStore) ListStandingOrderExecutions(arg0 context.Context, arg1 db.ListStandingOrderExecutionsParams) ([]db.StandingOrderExecution, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStandingOrderExecutions", arg0, arg1)
+	ret0, _ := ret[0].([]db.StandingOrderExecution)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStandingOrderExecutions indicates an expected call of ListStandingOrderExecutions.
+func (mr *MockStoreMockRecorder) ListStandingOrderExecutions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrderExecutions", reflect.TypeOf((*MockStore)(nil).ListStandingOrderExecutions), arg0, arg1)
+}
+
+// ListStandingOrders mocks base method.
+func (m *MockStore) ListStandingOrders(arg0 context.Context, arg1 db.ListStandingOrdersParams) ([]db.StandingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStandingOrders", arg0, arg1)
+	ret0, _ := ret[0].([]db.StandingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStandingOrders indicates an expected call of ListStandingOrders.
+func (mr *MockStoreMockRecorder) ListStandingOrders(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStandingOrders", reflect.TypeOf((*MockStore)(nil).ListStandingOrders), arg0, arg1)
+}
+
+// ListStatementEntries mocks base method.
+func (m *MockStore) ListStatementEntries(arg0 context.Context, arg1 db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListStatementEntries", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListStatementEntriesRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListStatementEntries indicates an expected call of ListStatementEntries.
+func (mr *MockStoreMockRecorder) ListStatementEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListStatementEntries", reflect.TypeOf((*MockStore)(nil).ListStatementEntries), arg0, arg1)
+}
+
+// ListTransferEntryTotals mocks base method.
+func (m *MockStore) ListTransferEntryTotals(arg0 context.Context, arg1 db.ListTransferEntryTotalsParams) ([]db.ListTransferEntryTotalsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTransferEntryTotals", arg0, arg1)
+	ret0, _ := ret[0].([]db.ListTransferEntryTotalsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTransferEntryTotals indicates an expected call of ListTransferEntryTotals.
+func (mr *MockStoreMockRecorder) ListTransferEntryTotals(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransferEntryTotals", reflect.TypeOf((*MockStore)(nil).ListTransferEntryTotals), arg0, arg1)
 }
 
 // ListTransfers mocks base method.
@@ -258,6 +1233,108 @@ func (mr *MockStoreMockRecorder) ListTransfers(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTransfers", reflect.TypeOf((*MockStore)(nil).ListTransfers), arg0, arg1)
 }
 
+// Ping mocks base method.
+func (m *MockStore) Ping(arg0 context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), arg0)
+}
+
+// ReadTx mocks base method.
+func (m *MockStore) ReadTx(arg0 context.Context, arg1 func(db.Querier) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadTx", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReadTx indicates an expected call of ReadTx.
+func (mr *MockStoreMockRecorder) ReadTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadTx", reflect.TypeOf((*MockStore)(nil).ReadTx), arg0, arg1)
+}
+
+// ReverseTransferTx mocks base method.
+func (m *MockStore) ReverseTransferTx(arg0 context.Context, arg1 db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReverseTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReverseTransferTx indicates an expected call of ReverseTransferTx.
+func (mr *MockStoreMockRecorder) ReverseTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReverseTransferTx", reflect.TypeOf((*MockStore)(nil).ReverseTransferTx), arg0, arg1)
+}
+
+// RevokeToken mocks base method.
+func (m *MockStore) RevokeToken(arg0 context.Context, arg1 db.RevokeTokenParams) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeToken", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeToken indicates an expected call of RevokeToken.
+func (mr *MockStoreMockRecorder) RevokeToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeToken", reflect.TypeOf((*MockStore)(nil).RevokeToken), arg0, arg1)
+}
+
+// RevokeUserTokens mocks base method.
+func (m *MockStore) RevokeUserTokens(arg0 context.Context, arg1 string) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserTokens", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeUserTokens indicates an expected call of RevokeUserTokens.
+func (mr *MockStoreMockRecorder) RevokeUserTokens(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserTokens", reflect.TypeOf((*MockStore)(nil).RevokeUserTokens), arg0, arg1)
+}
+
+// SetEntryHash mocks base method.
+func (m *MockStore) SetEntryHash(arg0 context.Context, arg1 db.SetEntryHashParams) (db.Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEntryHash", arg0, arg1)
+	ret0, _ := ret[0].(db.Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetEntryHash indicates an expected call of SetEntryHash.
+func (mr *MockStoreMockRecorder) SetEntryHash(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEntryHash", reflect.TypeOf((*MockStore)(nil).SetEntryHash), arg0, arg1)
+}
+
+// SetIdempotencyKeyResponse mocks base method.
+func (m *MockStore) SetIdempotencyKeyResponse(arg0 context.Context, arg1 db.SetIdempotencyKeyResponseParams) (db.IdempotencyKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIdempotencyKeyResponse", arg0, arg1)
+	ret0, _ := ret[0].(db.IdempotencyKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetIdempotencyKeyResponse indicates an expected call of SetIdempotencyKeyResponse.
+func (mr *MockStoreMockRecorder) SetIdempotencyKeyResponse(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIdempotencyKeyResponse", reflect.TypeOf((*MockStore)(nil).SetIdempotencyKeyResponse), arg0, arg1)
+}
+
 // TransferTx mocks base method.
 func (m *MockStore) TransferTx(arg0 context.Context, arg1 db.TransferTxParams) (db.TransferTxResult, error) {
 	m.ctrl.T.Helper()
@@ -287,3 +1364,63 @@ func (mr *MockStoreMockRecorder) UpdateAccount(arg0, arg1 interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccount", reflect.TypeOf((*MockStore)(nil).UpdateAccount), arg0, arg1)
 }
+
+// UpdateAccountOverdraftLimit mocks base method.
+func (m *MockStore) UpdateAccountOverdraftLimit(arg0 context.Context, arg1 db.UpdateAccountOverdraftLimitParams) (db.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAccountOverdraftLimit", arg0, arg1)
+	ret0, _ := ret[0].(db.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAccountOverdraftLimit indicates an expected call of UpdateAccountOverdraftLimit.
+func (mr *MockStoreMockRecorder) UpdateAccountOverdraftLimit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAccountOverdraftLimit", reflect.TypeOf((*MockStore)(nil).UpdateAccountOverdraftLimit), arg0, arg1)
+}
+
+// UpdateTransferStatus mocks base method.
+func (m *MockStore) UpdateTransferStatus(arg0 context.Context, arg1 db.UpdateTransferStatusParams) (db.Transfer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTransferStatus", arg0, arg1)
+	ret0, _ := ret[0].(db.Transfer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTransferStatus indicates an expected call of UpdateTransferStatus.
+func (mr *MockStoreMockRecorder) UpdateTransferStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTransferStatus", reflect.TypeOf((*MockStore)(nil).UpdateTransferStatus), arg0, arg1)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockStore) UpdateUserRole(arg0 context.Context, arg1 db.UpdateUserRoleParams) (db.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", arg0, arg1)
+	ret0, _ := ret[0].(db.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockStoreMockRecorder) UpdateUserRole(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockStore)(nil).UpdateUserRole), arg0, arg1)
+}
+
+// VoidTransferTx mocks base method.
+func (m *MockStore) VoidTransferTx(arg0 context.Context, arg1 int64) (db.TransferHoldTxResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VoidTransferTx", arg0, arg1)
+	ret0, _ := ret[0].(db.TransferHoldTxResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VoidTransferTx indicates an expected call of VoidTransferTx.
+func (mr *MockStoreMockRecorder) VoidTransferTx(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VoidTransferTx", reflect.TypeOf((*MockStore)(nil).VoidTransferTx), arg0, arg1)
+}
@@ -1,8 +1,8 @@
 -- name: CreateAccount :one
 INSERT INTO accounts (
-  owner, balance, currency
+  owner, balance, currency, available_balance
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $2
 )
 RETURNING *;
 
@@ -23,18 +23,39 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner)
+AND (sqlc.narg(after_created_at)::timestamptz IS NULL
+  OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
 -- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET balance = balance + sqlc.arg(amount),
+  available_balance = available_balance + sqlc.arg(amount)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: AddAccountAvailableBalance :one
+UPDATE accounts
+SET available_balance = available_balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2, available_balance = available_balance - balance + $2
 WHERE id = $1
 RETURNING *;
 
 -- name: DeleteAccount :exec
 DELETE FROM accounts
 WHERE id = $1;
+
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,36 @@
+-- name: CreateBalanceSnapshots :execrows
+-- each snapshot carries the previous one forward with the entries posted since,
+-- so only one day of entries is read per account
+-- closing a day again corrects snapshots that missed entries committed late, and leaves the rest alone
+INSERT INTO balance_snapshots (account_id, snapshot_at, balance)
+SELECT a.id, sqlc.arg(snapshot_at)::timestamptz,
+  COALESCE(s.balance, 0) + COALESCE((SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id
+    AND e.created_at > COALESCE(s.snapshot_at, '-infinity')
+    AND e.created_at <= sqlc.arg(snapshot_at)), 0)
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT snapshot_at, balance FROM balance_snapshots
+  WHERE account_id = a.id AND snapshot_at < sqlc.arg(snapshot_at)
+  ORDER BY snapshot_at DESC
+  LIMIT 1
+) s ON true
+WHERE a.created_at <= sqlc.arg(snapshot_at)
+ON CONFLICT (account_id, snapshot_at) DO UPDATE SET balance = EXCLUDED.balance
+WHERE balance_snapshots.balance <> EXCLUDED.balance;
+
+-- name: GetAccountBalanceAsOf :one
+SELECT s.snapshot_at,
+  (COALESCE(s.balance, 0) + COALESCE((SELECT SUM(e.amount) FROM entries e
+    WHERE e.account_id = a.id
+    AND e.created_at > COALESCE(s.snapshot_at, '-infinity')
+    AND e.created_at <= sqlc.arg(as_of)), 0))::bigint AS balance
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT snapshot_at, balance FROM balance_snapshots
+  WHERE account_id = a.id AND snapshot_at <= sqlc.arg(as_of)
+  ORDER BY snapshot_at DESC
+  LIMIT 1
+) s ON true
+WHERE a.id = sqlc.arg(account_id);
+
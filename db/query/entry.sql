@@ -1,8 +1,8 @@
 -- name: CreateEntry :one 
 INSERT INTO entries(
-  account_id,amount 
+  account_id,amount,transfer_id
 )VALUES(
-  $1, $2
+  $1, $2, $3
 )
 RETURNING *;
 
@@ -21,3 +21,55 @@ OFFSET $3;
 -- name: DeleteEntry :exec
 DELETE FROM entries
 WHERE id = $1;
+
+-- name: CountAccountEntries :one
+SELECT count(*) FROM entries
+WHERE account_id = $1;
+
+-- name: ListAccountEntries :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+AND ((amount > 0 AND sqlc.arg(credits)::bool) OR (amount < 0 AND sqlc.arg(debits)::bool))
+AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+AND (sqlc.narg(min_amount)::bigint IS NULL OR abs(amount) >= sqlc.narg(min_amount))
+AND (sqlc.narg(max_amount)::bigint IS NULL OR abs(amount) <= sqlc.narg(max_amount))
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: GetLastEntryHash :one
+SELECT hash FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: SetEntryHash :one
+UPDATE entries
+SET prev_hash = $2, hash = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: ListEntryChain :many
+SELECT * FROM entries
+WHERE (account_id, id) > (sqlc.arg(after_account_id)::bigint, sqlc.arg(after_id)::bigint)
+ORDER BY account_id, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListStatementEntries :many
+SELECT e.id, e.amount, e.created_at, e.transfer_id,
+  a.id AS counterparty_account_id,
+  u.full_name AS counterparty_name
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts a ON a.id = CASE WHEN e.amount < 0 THEN t.to_account_id ELSE t.from_account_id END
+LEFT JOIN users u ON u.username = a.owner
+WHERE e.account_id = sqlc.arg(account_id)
+AND e.created_at >= sqlc.arg(from_time)
+AND e.created_at < sqlc.arg(to_time)
+AND (sqlc.narg(after_created_at)::timestamptz IS NULL
+  OR (e.created_at, e.id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::bigint))
+ORDER BY e.created_at, e.id
+LIMIT sqlc.arg('limit');
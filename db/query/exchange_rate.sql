@@ -0,0 +1,24 @@
+-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (
+  from_currency,
+  to_currency,
+  rate,
+  effective_from
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetExchangeRate :one
+SELECT * FROM exchange_rates
+WHERE from_currency = sqlc.arg(from_currency)
+AND to_currency = sqlc.arg(to_currency)
+AND effective_from <= sqlc.arg(at)
+ORDER BY effective_from DESC
+LIMIT 1;
+
+-- name: ListExchangeRates :many
+SELECT * FROM exchange_rates
+ORDER BY from_currency, to_currency, effective_from DESC
+LIMIT $1
+OFFSET $2;
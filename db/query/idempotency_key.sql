@@ -0,0 +1,33 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+  username,
+  key,
+  request_hash,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (username, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+  response_status = 0,
+  response_body = '{}',
+  created_at = now(),
+  expires_at = EXCLUDED.expires_at
+WHERE idempotency_keys.expires_at < now()
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 AND expires_at > now()
+LIMIT 1;
+
+-- name: SetIdempotencyKeyResponse :one
+UPDATE idempotency_keys
+SET response_status = sqlc.arg(response_status),
+  response_body = sqlc.arg(response_body)
+WHERE username = sqlc.arg(username) AND key = sqlc.arg(key)
+RETURNING *;
+
+-- name: DeleteExpiredIdempotencyKeys :execrows
+DELETE FROM idempotency_keys
+WHERE expires_at < now();
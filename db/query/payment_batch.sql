@@ -0,0 +1,22 @@
+-- name: CreatePaymentBatch :one
+INSERT INTO payment_batches (
+  owner,
+  format,
+  message_id
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetPaymentBatch :one
+SELECT * FROM payment_batches
+WHERE id = $1 LIMIT 1;
+
+-- name: ListPaymentBatches :many
+SELECT * FROM payment_batches
+WHERE owner = sqlc.arg(owner)
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
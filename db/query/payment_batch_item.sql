@@ -0,0 +1,49 @@
+-- name: CreatePaymentBatchItem :one
+INSERT INTO payment_batch_items (
+  batch_id,
+  line,
+  end_to_end_id,
+  from_account_id,
+  to_account_id,
+  amount,
+  currency,
+  status,
+  failure_reason
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: ListPaymentBatchItems :many
+SELECT * FROM payment_batch_items
+WHERE batch_id = sqlc.arg(batch_id)
+AND (sqlc.narg(after_created_at)::timestamptz IS NULL
+  OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::bigint))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CountPaymentBatchItems :many
+SELECT status, count(*) AS count FROM payment_batch_items
+WHERE batch_id = $1
+GROUP BY status
+ORDER BY status;
+
+-- name: GetPendingPaymentBatchItemForUpdate :one
+SELECT * FROM payment_batch_items
+WHERE status = 'pending'
+ORDER BY batch_id, line
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CompletePaymentBatchItem :one
+UPDATE payment_batch_items
+SET status = 'completed', transfer_id = $2, processed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: FailPaymentBatchItem :one
+UPDATE payment_batch_items
+SET status = 'failed', failure_reason = $2, processed_at = now()
+WHERE id = $1
+RETURNING *;
@@ -0,0 +1,40 @@
+-- name: ListAccountLedgerTotals :many
+SELECT a.id, a.balance, a.available_balance,
+  COALESCE((SELECT SUM(e.amount) FROM entries e WHERE e.account_id = a.id), 0)::bigint AS entries_total,
+  COALESCE((SELECT SUM(t.amount) FROM transfers t
+    WHERE t.from_account_id = a.id AND t.status = 'authorized'), 0)::bigint AS held_total
+FROM accounts a
+WHERE a.id > sqlc.arg(after_id)
+ORDER BY a.id
+LIMIT sqlc.arg('limit');
+
+-- name: ListTransferEntryTotals :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.to_amount, t.status,
+  COUNT(e.id) AS entry_count,
+  COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.from_account_id), 0)::bigint AS from_entries_total,
+  COALESCE(SUM(e.amount) FILTER (WHERE e.account_id = t.to_account_id), 0)::bigint AS to_entries_total
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+WHERE t.id > sqlc.arg(after_id)
+GROUP BY t.id
+ORDER BY t.id
+LIMIT sqlc.arg('limit');
+
+-- name: CreateReconciliationReport :one
+INSERT INTO reconciliation_reports (
+  started_at,
+  finished_at,
+  accounts_checked,
+  transfers_checked,
+  account_drifts,
+  transfer_mismatches,
+  report,
+  signature
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetReconciliationReport :one
+SELECT * FROM reconciliation_reports
+WHERE id = $1 LIMIT 1;
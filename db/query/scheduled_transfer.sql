@@ -0,0 +1,49 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  execute_at
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE owner = sqlc.arg(owner)
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'cancelled'
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: GetDueScheduledTransferForUpdate :one
+SELECT * FROM scheduled_transfers
+WHERE status = 'pending' AND execute_at <= now()
+ORDER BY execute_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: CompleteScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'completed', transfer_id = $2, executed_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: FailScheduledTransfer :one
+UPDATE scheduled_transfers
+SET status = 'failed', failure_reason = $2, executed_at = now()
+WHERE id = $1
+RETURNING *;
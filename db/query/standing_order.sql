@@ -0,0 +1,47 @@
+-- name: CreateStandingOrder :one
+INSERT INTO standing_orders (
+  owner,
+  from_account_id,
+  to_account_id,
+  amount,
+  recurrence,
+  start_at,
+  end_at,
+  max_occurrences,
+  next_run_at
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $6
+)
+RETURNING *;
+
+-- name: GetStandingOrder :one
+SELECT * FROM standing_orders
+WHERE id = $1 LIMIT 1;
+
+-- name: ListStandingOrders :many
+SELECT * FROM standing_orders
+WHERE owner = sqlc.arg(owner)
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: CancelStandingOrder :one
+UPDATE standing_orders
+SET status = 'cancelled', next_run_at = NULL
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: GetDueStandingOrderForUpdate :one
+SELECT * FROM standing_orders
+WHERE status = 'active' AND next_run_at <= now()
+ORDER BY next_run_at, id
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: AdvanceStandingOrder :one
+UPDATE standing_orders
+SET occurrences = occurrences + 1, next_run_at = $2, status = $3
+WHERE id = $1
+RETURNING *;
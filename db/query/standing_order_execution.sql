@@ -0,0 +1,21 @@
+-- name: CreateStandingOrderExecution :one
+INSERT INTO standing_order_executions (
+  standing_order_id,
+  occurrence,
+  scheduled_for,
+  status,
+  transfer_id,
+  failure_reason
+) VALUES (
+  $1, $2, $3, $4, $5, $6
+)
+RETURNING *;
+
+-- name: ListStandingOrderExecutions :many
+SELECT * FROM standing_order_executions
+WHERE standing_order_id = sqlc.arg(standing_order_id)
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -1,14 +1,40 @@
 -- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, reverses_transfer_id
  ) VALUES (
-    $1, $2, $3)
+    $1, $2, $3, $4, $5, $6)
 RETURNING * ;
 
+-- name: CreateTransferHold :one
+INSERT INTO transfers (
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, status, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, 'authorized', $6
+)
+RETURNING *;
+
+-- name: GetExpiredTransferHoldForUpdate :one
+SELECT * FROM transfers
+WHERE status = 'authorized' AND expires_at <= now()
+ORDER BY expires_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED;
+
 -- name: GetTransfer :one
 SELECT * FROM transfers 
 WHERE id = $1 LIMIT 1 ;
 
+-- name: GetTransferForUpdate :one
+SELECT * FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetTransferReversedAmounts :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount,
+  COALESCE(SUM(to_amount), 0)::bigint AS returned_amount
+FROM transfers
+WHERE reverses_transfer_id = $1;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE from_account_id =$1 OR to_account_id = $2
@@ -16,5 +42,26 @@ ORDER BY id
 LIMIT $3
 OFFSET $4;
 
+-- name: ListAccountTransfers :many
+SELECT * FROM transfers
+WHERE (
+  (from_account_id = sqlc.arg(account_id) AND sqlc.arg(outgoing)::bool)
+  OR (to_account_id = sqlc.arg(account_id) AND sqlc.arg(incoming)::bool)
+)
+AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+AND (sqlc.narg(min_amount)::bigint IS NULL
+  OR (CASE WHEN from_account_id = sqlc.arg(account_id) THEN amount ELSE to_amount END) >= sqlc.narg(min_amount))
+AND (sqlc.narg(max_amount)::bigint IS NULL
+  OR (CASE WHEN from_account_id = sqlc.arg(account_id) THEN amount ELSE to_amount END) <= sqlc.narg(max_amount))
+AND (sqlc.narg(before_created_at)::timestamptz IS NULL
+  OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
 
-
+-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $2
+WHERE id = $1
+RETURNING *;
@@ -12,4 +12,16 @@ RETURNING * ;
 
 -- name: GetUser :one 
 SELECT * FROM users
-WHERE username = $1 LIMIT 1 ;
\ No newline at end of file
+WHERE username = $1 LIMIT 1 ;
+
+-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_revoked_at = now()
+WHERE username = $1
+RETURNING * ;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING * ;
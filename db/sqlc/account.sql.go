@@ -7,13 +7,42 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
+const addAccountAvailableBalance = `-- name: AddAccountAvailableBalance :one
+UPDATE accounts
+SET available_balance = available_balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, available_balance
+`
+
+type AddAccountAvailableBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) AddAccountAvailableBalance(ctx context.Context, arg AddAccountAvailableBalanceParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, addAccountAvailableBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
 const addAccountBalance = `-- name: AddAccountBalance :one
 UPDATE accounts
-SET balance = balance + $1
+SET balance = balance + $1,
+  available_balance = available_balance + $1
 WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, available_balance
 `
 
 type AddAccountBalanceParams struct {
@@ -30,17 +59,19 @@ func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalancePa
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
-  owner, balance, currency
+  owner, balance, currency, available_balance
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $2
 )
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, available_balance
 `
 
 type CreateAccountParams struct {
@@ -58,6 +89,8 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
@@ -73,7 +106,7 @@ func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, available_balance FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
@@ -86,12 +119,14 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const getAccountForupdate = `-- name: GetAccountForupdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, available_balance FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
@@ -105,12 +140,14 @@ func (q *Queries) GetAccountForupdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, overdraft_limit, available_balance FROM accounts
 WHERE owner = $1
 ORDER BY id
 LIMIT $2
@@ -138,6 +175,60 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.AvailableBalance,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountsAfter = `-- name: ListAccountsAfter :many
+SELECT id, owner, balance, currency, created_at, overdraft_limit, available_balance FROM accounts
+WHERE owner = $1
+AND ($2::timestamptz IS NULL
+  OR (created_at, id) > ($2, $3::bigint))
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAccountsAfterParams struct {
+	Owner          string        `json:"owner"`
+	AfterCreatedAt sql.NullTime  `json:"after_created_at"`
+	AfterID        sql.NullInt64 `json:"after_id"`
+	Limit          int32         `json:"limit"`
+}
+
+func (q *Queries) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountsAfter,
+		arg.Owner,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.OverdraftLimit,
+			&i.AvailableBalance,
 		); err != nil {
 			return nil, err
 		}
@@ -154,9 +245,9 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 
 const updateAccount = `-- name: UpdateAccount :one
 UPDATE accounts
-SET balance = $2
+SET balance = $2, available_balance = available_balance - balance + $2
 WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, available_balance
 `
 
 type UpdateAccountParams struct {
@@ -173,6 +264,35 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
+	)
+	return i, err
+}
+
+const updateAccountOverdraftLimit = `-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = $2
+WHERE id = $1
+RETURNING id, owner, balance, currency, created_at, overdraft_limit, available_balance
+`
+
+type UpdateAccountOverdraftLimitParams struct {
+	ID             int64 `json:"id"`
+	OverdraftLimit int64 `json:"overdraft_limit"`
+}
+
+func (q *Queries) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccountOverdraftLimit, arg.ID, arg.OverdraftLimit)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.OverdraftLimit,
+		&i.AvailableBalance,
 	)
 	return i, err
 }
@@ -11,11 +11,15 @@ import (
 )
 
 func createRandomAccount(t *testing.T)Account {
+	return createRandomAccountWithCurrency(t, util.RandomCurrency())
+}
+
+func createRandomAccountWithCurrency(t *testing.T, currency string)Account {
 	user := createRandomUser(t)
 	arg:= CreateAccountParams{
 		Owner: user.Username,
 		Balance: util.RandomMoney(),
-		Currency:util.RandomCurrency() ,
+		Currency: currency,
 	}
 
 	account,err := testQueries.CreateAccount(context.Background(),arg)
@@ -25,6 +29,7 @@ func createRandomAccount(t *testing.T)Account {
 	require.Equal(t , arg.Owner ,account.Owner)
 	require.Equal(t , arg.Balance ,account.Balance)
 	require.Equal(t , arg.Currency ,account.Currency)
+	require.Equal(t , arg.Balance ,account.AvailableBalance)
 
 	require.NotZero(t,account.ID)
 	require.NotZero(t,account.CreatedAt)
@@ -94,8 +99,43 @@ func TestListAccounts(t *testing.T){
 	accounts,err := testQueries.ListAccounts(context.Background(),arg)
 	require.NoError(t,err)
 	require.NotEmpty(t,accounts)
-	for _,account := range accounts{
+	for i,account := range accounts{
 		require.NotEmpty(t,account)
 		require.Equal(t, lastAccount.Owner, account.Owner)
+		// the offset form keeps ordering by id, as it always has
+		if i > 0 {
+			require.Greater(t, account.ID, accounts[i-1].ID)
+		}
+	}
+}
+func TestListAccountsKeyset(t *testing.T) {
+	user := createRandomUser(t)
+	var accounts []Account
+	for _, currency := range []string{util.USD, util.EUR, util.CAD} {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
 	}
-}
\ No newline at end of file
+
+	arg := ListAccountsAfterParams{
+		Owner: user.Username,
+		Limit: 2,
+	}
+	firstPage, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
+	require.Equal(t, accounts[0].ID, firstPage[0].ID)
+	require.Equal(t, accounts[1].ID, firstPage[1].ID)
+
+	last := firstPage[len(firstPage)-1]
+	arg.AfterCreatedAt = sql.NullTime{Time: last.CreatedAt, Valid: true}
+	arg.AfterID = sql.NullInt64{Int64: last.ID, Valid: true}
+	secondPage, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 1)
+	require.Equal(t, accounts[2].ID, secondPage[0].ID)
+}
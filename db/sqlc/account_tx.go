@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrAccountNotEmpty    = errors.New("account balance is not zero")
+	ErrAccountHasActivity = errors.New("account has ledger activity")
+)
+
+type AdjustBalanceTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+type AdjustBalanceTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// AdjustBalanceTx changes an account balance by posting an adjustment entry for the same amount,
+// so the ledger always explains the balance
+func (store *SQLStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	var result AdjustBalanceTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		_, err = q.GetAccountForupdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = store.chainEntry(ctx, q, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		return err
+	})
+	return result, err
+}
+
+// DeleteAccountTx deletes an account only when it is empty and has never been used
+func (store *SQLStore) DeleteAccountTx(ctx context.Context, accountID int64) error {
+	return store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForupdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if account.Balance != 0 {
+			return ErrAccountNotEmpty
+		}
+
+		entries, err := q.CountAccountEntries(ctx, accountID)
+		if err != nil {
+			return err
+		}
+		if entries > 0 {
+			return ErrAccountHasActivity
+		}
+
+		err = q.DeleteAccount(ctx, accountID)
+		// scheduled transfers still refer to the account
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return ErrAccountHasActivity
+		}
+		return err
+	})
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdjustBalanceTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+	account := createRandomAccount(t)
+
+	result, err := store.AdjustBalanceTx(context.Background(), AdjustBalanceTxParams{
+		AccountID: account.ID,
+		Amount:    -10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-10, result.Account.Balance)
+	require.Equal(t, account.ID, result.Entry.AccountID)
+	require.Equal(t, int64(-10), result.Entry.Amount)
+}
+
+func TestDeleteAccountTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account := createRandomAccount(t)
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 1})
+	require.NoError(t, err)
+	err = store.DeleteAccountTx(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: account.ID, Balance: 0})
+	require.NoError(t, err)
+	err = store.DeleteAccountTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	_, err = testQueries.GetAccount(context.Background(), account.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+
+	usedAccount := createRandomAccount(t)
+	createRandomEntryWithSameAccountId(usedAccount.ID)
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: usedAccount.ID, Balance: 0})
+	require.NoError(t, err)
+	err = store.DeleteAccountTx(context.Background(), usedAccount.ID)
+	require.ErrorIs(t, err, ErrAccountHasActivity)
+	// a scheduled transfer refers to the account even without entries
+	scheduledAccount := createRandomAccount(t)
+	_, err = testQueries.UpdateAccount(context.Background(), UpdateAccountParams{ID: scheduledAccount.ID, Balance: 0})
+	require.NoError(t, err)
+	createRandomScheduledTransfer(t, scheduledAccount, createRandomAccount(t), 10, time.Now().Add(time.Hour))
+	err = store.DeleteAccountTx(context.Background(), scheduledAccount.ID)
+	require.ErrorIs(t, err, ErrAccountHasActivity)
+}
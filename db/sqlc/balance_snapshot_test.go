@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getBalanceAsOf(t *testing.T, accountID int64, asOf time.Time) GetAccountBalanceAsOfRow {
+	balance, err := testQueries.GetAccountBalanceAsOf(context.Background(), GetAccountBalanceAsOfParams{
+		AsOf:      asOf,
+		AccountID: accountID,
+	})
+	require.NoError(t, err)
+	return balance
+}
+
+func TestBalanceSnapshots(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	transfer := func(amount int64) {
+		_, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        amount,
+		})
+		require.NoError(t, err)
+	}
+
+	beforeTransfers := time.Now()
+	transfer(10)
+	// postgres keeps microseconds
+	firstDay := time.Now().Truncate(time.Microsecond)
+
+	// no snapshot yet, the balance comes from the entries alone
+	balance := getBalanceAsOf(t, account2.ID, firstDay)
+	require.False(t, balance.SnapshotAt.Valid)
+	require.Equal(t, int64(10), balance.Balance)
+
+	created, err := store.CreateBalanceSnapshots(context.Background(), firstDay)
+	require.NoError(t, err)
+	require.Positive(t, created)
+	// closing the same day again writes nothing
+	created, err = store.CreateBalanceSnapshots(context.Background(), firstDay)
+	require.NoError(t, err)
+	require.Zero(t, created)
+
+	transfer(5)
+	secondDay := time.Now().Truncate(time.Microsecond)
+	transfer(1)
+	_, err = store.CreateBalanceSnapshots(context.Background(), secondDay)
+	require.NoError(t, err)
+
+	balance = getBalanceAsOf(t, account2.ID, secondDay)
+	require.True(t, balance.SnapshotAt.Valid)
+	require.WithinDuration(t, secondDay, balance.SnapshotAt.Time, 0)
+	require.Equal(t, int64(15), balance.Balance)
+
+	// rolled forward from the first snapshot
+	balance = getBalanceAsOf(t, account2.ID, secondDay.Add(-time.Microsecond))
+	require.WithinDuration(t, firstDay, balance.SnapshotAt.Time, 0)
+	require.Equal(t, int64(15), balance.Balance)
+
+	// rolled forward from the second snapshot
+	balance = getBalanceAsOf(t, account2.ID, time.Now())
+	require.Equal(t, int64(16), balance.Balance)
+	require.Equal(t, int64(-16), getBalanceAsOf(t, account1.ID, time.Now()).Balance)
+
+	balance = getBalanceAsOf(t, account2.ID, beforeTransfers)
+	require.False(t, balance.SnapshotAt.Valid)
+	require.Zero(t, balance.Balance)
+
+	// entries committed after their day was closed are picked up when the days are closed again
+	late, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        7,
+	})
+	require.NoError(t, err)
+	_, err = testDB.Exec("UPDATE entries SET created_at = $1 WHERE id IN ($2, $3)",
+		firstDay.Add(-time.Microsecond), late.FromEntry.ID, late.ToEntry.ID)
+	require.NoError(t, err)
+	for _, day := range []time.Time{firstDay, secondDay} {
+		corrected, err := store.CreateBalanceSnapshots(context.Background(), day)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, corrected, int64(2))
+	}
+	require.Equal(t, int64(17), getBalanceAsOf(t, account2.ID, firstDay).Balance)
+	require.Equal(t, int64(22), getBalanceAsOf(t, account2.ID, secondDay).Balance)
+	require.Equal(t, int64(-23), getBalanceAsOf(t, account1.ID, time.Now()).Balance)
+}
@@ -7,30 +7,48 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
+const countAccountEntries = `-- name: CountAccountEntries :one
+SELECT count(*) FROM entries
+WHERE account_id = $1
+`
+
+func (q *Queries) CountAccountEntries(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAccountEntries, accountID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries(
-  account_id,amount 
+  account_id,amount,transfer_id
 )VALUES(
-  $1, $2
+  $1, $2, $3
 )
-RETURNING id, account_id, amount, created_at
+RETURNING id, account_id, amount, created_at, transfer_id, prev_hash, hash
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
+	AccountID  int64         `json:"account_id"`
+	Amount     int64         `json:"amount"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount, arg.TransferID)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
@@ -46,7 +64,7 @@ func (q *Queries) DeleteEntry(ctx context.Context, id int64) error {
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -58,12 +76,101 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
+const getLastEntryHash = `-- name: GetLastEntryHash :one
+SELECT hash FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetLastEntryHash(ctx context.Context, accountID int64) (string, error) {
+	row := q.db.QueryRowContext(ctx, getLastEntryHash, accountID)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const listAccountEntries = `-- name: ListAccountEntries :many
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
+WHERE account_id = $1
+AND ((amount > 0 AND $2::bool) OR (amount < 0 AND $3::bool))
+AND ($4::timestamptz IS NULL OR created_at >= $4)
+AND ($5::timestamptz IS NULL OR created_at < $5)
+AND ($6::bigint IS NULL OR abs(amount) >= $6)
+AND ($7::bigint IS NULL OR abs(amount) <= $7)
+AND ($8::timestamptz IS NULL
+  OR (created_at, id) < ($8, $9::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $10
+OFFSET $11
+`
+
+type ListAccountEntriesParams struct {
+	AccountID       int64         `json:"account_id"`
+	Credits         bool          `json:"credits"`
+	Debits          bool          `json:"debits"`
+	StartTime       sql.NullTime  `json:"start_time"`
+	EndTime         sql.NullTime  `json:"end_time"`
+	MinAmount       sql.NullInt64 `json:"min_amount"`
+	MaxAmount       sql.NullInt64 `json:"max_amount"`
+	BeforeCreatedAt sql.NullTime  `json:"before_created_at"`
+	BeforeID        sql.NullInt64 `json:"before_id"`
+	Limit           int32         `json:"limit"`
+	Offset          int32         `json:"offset"`
+}
+
+func (q *Queries) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountEntries,
+		arg.AccountID,
+		arg.Credits,
+		arg.Debits,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
 WHERE account_id = $1
 ORDER BY id 
 LIMIT $2
@@ -90,6 +197,53 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEntryChain = `-- name: ListEntryChain :many
+SELECT id, account_id, amount, created_at, transfer_id, prev_hash, hash FROM entries
+WHERE (account_id, id) > ($1::bigint, $2::bigint)
+ORDER BY account_id, id
+LIMIT $3
+`
+
+type ListEntryChainParams struct {
+	AfterAccountID int64 `json:"after_account_id"`
+	AfterID        int64 `json:"after_id"`
+	Limit          int32 `json:"limit"`
+}
+
+func (q *Queries) ListEntryChain(ctx context.Context, arg ListEntryChainParams) ([]Entry, error) {
+	rows, err := q.db.QueryContext(ctx, listEntryChain, arg.AfterAccountID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
@@ -103,3 +257,103 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 	}
 	return items, nil
 }
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT e.id, e.amount, e.created_at, e.transfer_id,
+  a.id AS counterparty_account_id,
+  u.full_name AS counterparty_name
+FROM entries e
+LEFT JOIN transfers t ON t.id = e.transfer_id
+LEFT JOIN accounts a ON a.id = CASE WHEN e.amount < 0 THEN t.to_account_id ELSE t.from_account_id END
+LEFT JOIN users u ON u.username = a.owner
+WHERE e.account_id = $1
+AND e.created_at >= $2
+AND e.created_at < $3
+AND ($4::timestamptz IS NULL
+  OR (e.created_at, e.id) > ($4, $5::bigint))
+ORDER BY e.created_at, e.id
+LIMIT $6
+`
+
+type ListStatementEntriesParams struct {
+	AccountID      int64        `json:"account_id"`
+	FromTime       time.Time    `json:"from_time"`
+	ToTime         time.Time    `json:"to_time"`
+	AfterCreatedAt sql.NullTime `json:"after_created_at"`
+	AfterID        int64        `json:"after_id"`
+	Limit          int32        `json:"limit"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64          `json:"id"`
+	Amount                int64          `json:"amount"`
+	CreatedAt             time.Time      `json:"created_at"`
+	TransferID            sql.NullInt64  `json:"transfer_id"`
+	CounterpartyAccountID sql.NullInt64  `json:"counterparty_account_id"`
+	CounterpartyName      sql.NullString `json:"counterparty_name"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listStatementEntries,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setEntryHash = `-- name: SetEntryHash :one
+UPDATE entries
+SET prev_hash = $2, hash = $3
+WHERE id = $1
+RETURNING id, account_id, amount, created_at, transfer_id, prev_hash, hash
+`
+
+type SetEntryHashParams struct {
+	ID       int64  `json:"id"`
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+func (q *Queries) SetEntryHash(ctx context.Context, arg SetEntryHashParams) (Entry, error) {
+	row := q.db.QueryRowContext(ctx, setEntryHash, arg.ID, arg.PrevHash, arg.Hash)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.TransferID,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// EntryHash returns the hash an entry should carry: its content chained to prevHash,
+// the hash of the entry before it on the same account. The hash is keyed, so edited
+// rows can't be rehashed into a chain that still verifies without knowing key
+func EntryHash(key string, prevHash string, entry Entry) string {
+	transferID := ""
+	if entry.TransferID.Valid {
+		transferID = strconv.FormatInt(entry.TransferID.Int64, 10)
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	fmt.Fprintf(mac, "%s|%d|%d|%d|%s|%s",
+		prevHash,
+		entry.ID,
+		entry.AccountID,
+		entry.Amount,
+		transferID,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// chainEntry creates an entry chained to the last one on its account.
+// The account must already be locked, so no other entry can be chained to the same one
+func (store *SQLStore) chainEntry(ctx context.Context, q *Queries, arg CreateEntryParams) (Entry, error) {
+	prevHash, err := q.GetLastEntryHash(ctx, arg.AccountID)
+	if err != nil && err != sql.ErrNoRows {
+		return Entry{}, err
+	}
+
+	// the hash covers the id and created_at, so it is set once the row exists
+	entry, err := q.CreateEntry(ctx, arg)
+	if err != nil {
+		return entry, err
+	}
+	return q.SetEntryHash(ctx, SetEntryHashParams{
+		ID:       entry.ID,
+		PrevHash: prevHash,
+		Hash:     EntryHash(store.entryKey, prevHash, entry),
+	})
+}
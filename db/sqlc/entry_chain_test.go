@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransferTxChainsEntries(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	transfer := func() TransferTxResult {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	first := transfer()
+	require.Empty(t, first.FromEntry.PrevHash)
+	require.Empty(t, first.ToEntry.PrevHash)
+	require.Equal(t, EntryHash(testEntryKey, "", first.FromEntry), first.FromEntry.Hash)
+	require.Equal(t, EntryHash(testEntryKey, "", first.ToEntry), first.ToEntry.Hash)
+
+	second := transfer()
+	require.Equal(t, first.FromEntry.Hash, second.FromEntry.PrevHash)
+	require.Equal(t, first.ToEntry.Hash, second.ToEntry.PrevHash)
+	require.Equal(t, EntryHash(testEntryKey, second.FromEntry.PrevHash, second.FromEntry), second.FromEntry.Hash)
+
+	entries, err := store.ListEntryChain(context.Background(), ListEntryChainParams{
+		AfterAccountID: account1.ID,
+		AfterID:        0,
+		Limit:          10,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(entries), 2)
+	require.Equal(t, first.FromEntry.ID, entries[0].ID)
+	require.Equal(t, second.FromEntry.ID, entries[1].ID)
+
+	// an edited entry no longer matches its hash
+	_, err = testDB.Exec("UPDATE entries SET amount = amount - 1 WHERE id = $1", first.ToEntry.ID)
+	require.NoError(t, err)
+	edited, err := store.GetEntry(context.Background(), first.ToEntry.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, edited.Hash, EntryHash(testEntryKey, edited.PrevHash, edited))
+
+	// and hashing it again without the key doesn't give a hash the chain accepts
+	forged := EntryHash("", edited.PrevHash, edited)
+	_, err = testDB.Exec("UPDATE entries SET hash = $1 WHERE id = $2", forged, edited.ID)
+	require.NoError(t, err)
+	require.NotEqual(t, forged, EntryHash(testEntryKey, edited.PrevHash, edited))
+}
@@ -83,4 +83,111 @@ func TestListEntries (t *testing.T){
 	for _,entry := range entries{
 		require.NotEmpty(t,entry)
 	}
-}
\ No newline at end of file
+}
+
+func TestListAccountEntries(t *testing.T) {
+	acc := createRandomAccount(t)
+
+	var entries []Entry
+	for _, amount := range []int64{100, -50, 200, -300} {
+		entry, err := testQueries.CreateEntry(context.Background(), CreateEntryParams{
+			AccountID: acc.ID,
+			Amount:    amount,
+		})
+		require.NoError(t, err)
+		entries = append(entries, entry)
+	}
+
+	arg := ListAccountEntriesParams{
+		AccountID: acc.ID,
+		Credits:   true,
+		Debits:    true,
+		Limit:     10,
+		Offset:    0,
+	}
+	all, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, all, 4)
+	// newest first
+	require.Equal(t, entries[3].ID, all[0].ID)
+	require.Equal(t, entries[0].ID, all[3].ID)
+
+	arg.Credits = false
+	debits, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, debits, 2)
+	for _, entry := range debits {
+		require.Negative(t, entry.Amount)
+	}
+
+	// amount range compares magnitudes
+	arg.Credits = true
+	arg.MinAmount = sql.NullInt64{Int64: 100, Valid: true}
+	arg.MaxAmount = sql.NullInt64{Int64: 200, Valid: true}
+	inRange, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, inRange, 2)
+
+	arg.MinAmount = sql.NullInt64{}
+	arg.MaxAmount = sql.NullInt64{}
+	arg.StartTime = sql.NullTime{Time: entries[3].CreatedAt.Add(time.Second), Valid: true}
+	none, err := testQueries.ListAccountEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
+
+func TestListStatementEntries(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	owner2, err := testQueries.GetUser(context.Background(), account2.Owner)
+	require.NoError(t, err)
+
+	from := time.Now().Add(-time.Minute)
+	var transfers []TransferTxResult
+	for i := 0; i < 3; i++ {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+		transfers = append(transfers, result)
+	}
+	to := time.Now().Add(time.Minute)
+
+	arg := ListStatementEntriesParams{
+		AccountID: account1.ID,
+		FromTime:  from,
+		ToTime:    to,
+		Limit:     2,
+	}
+	page1, err := testQueries.ListStatementEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page1, 2)
+	// oldest first, each debit shows who it was paid to
+	require.Equal(t, transfers[0].FromEntry.ID, page1[0].ID)
+	require.Equal(t, int64(-10), page1[0].Amount)
+	require.Equal(t, transfers[0].Transfer.ID, page1[0].TransferID.Int64)
+	require.Equal(t, account2.ID, page1[0].CounterpartyAccountID.Int64)
+	require.Equal(t, owner2.FullName, page1[0].CounterpartyName.String)
+
+	arg.AfterCreatedAt = sql.NullTime{Time: page1[1].CreatedAt, Valid: true}
+	arg.AfterID = page1[1].ID
+	page2, err := testQueries.ListStatementEntries(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page2, 1)
+	require.Equal(t, transfers[2].FromEntry.ID, page2[0].ID)
+
+	// credits show who paid
+	credits, err := testQueries.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account2.ID,
+		FromTime:  from,
+		ToTime:    to,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, credits, 3)
+	require.Equal(t, account1.ID, credits[0].CounterpartyAccountID.Int64)
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomExchangeRate(t *testing.T, effectiveFrom time.Time) ExchangeRate {
+	arg := CreateExchangeRateParams{
+		FromCurrency:  util.EUR,
+		ToCurrency:    util.USD,
+		Rate:          "1.0825000000",
+		EffectiveFrom: effectiveFrom,
+	}
+
+	rate, err := testQueries.CreateExchangeRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, rate)
+
+	require.Equal(t, arg.FromCurrency, rate.FromCurrency)
+	require.Equal(t, arg.ToCurrency, rate.ToCurrency)
+	require.Equal(t, arg.Rate, rate.Rate)
+	require.WithinDuration(t, arg.EffectiveFrom, rate.EffectiveFrom, time.Second)
+
+	require.NotZero(t, rate.ID)
+	require.NotZero(t, rate.CreatedAt)
+	return rate
+}
+
+func TestCreateExchangeRate(t *testing.T) {
+	createRandomExchangeRate(t, time.Now())
+}
+
+func TestGetExchangeRate(t *testing.T) {
+	// far enough in the future that no other test's rate is in effect at the same time
+	start := time.Now().AddDate(100, 0, int(util.RandomInt(0, 1000000)))
+	older := createRandomExchangeRate(t, start)
+	newer := createRandomExchangeRate(t, start.Add(time.Hour))
+
+	rate, err := testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.EUR,
+		ToCurrency:   util.USD,
+		At:           start.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, older.ID, rate.ID)
+
+	rate, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.EUR,
+		ToCurrency:   util.USD,
+		At:           start.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, newer.ID, rate.ID)
+
+	_, err = testQueries.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.CAD,
+		At:           start,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+func TestCreateExchangeRatesTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+	effectiveFrom := time.Now().AddDate(200, 0, int(util.RandomInt(0, 1000000)))
+
+	arg := []CreateExchangeRateParams{
+		{FromCurrency: util.EUR, ToCurrency: util.CAD, Rate: "1.4700000000", EffectiveFrom: effectiveFrom},
+		{FromCurrency: util.CAD, ToCurrency: util.EUR, Rate: "0.6800000000", EffectiveFrom: effectiveFrom},
+	}
+	rates, err := store.CreateExchangeRatesTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rates, 2)
+
+	// a duplicate in the batch rolls back the whole load
+	duplicate := []CreateExchangeRateParams{
+		{FromCurrency: util.CAD, ToCurrency: util.USD, Rate: "0.7300000000", EffectiveFrom: effectiveFrom},
+		arg[0],
+	}
+	_, err = store.CreateExchangeRatesTx(context.Background(), duplicate)
+	require.Error(t, err)
+
+	_, err = store.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.CAD,
+		ToCurrency:   util.USD,
+		At:           effectiveFrom,
+	})
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
@@ -0,0 +1,20 @@
+package db
+
+import "context"
+
+// CreateExchangeRatesTx loads a batch of exchange rates, all or none of them
+func (store *SQLStore) CreateExchangeRatesTx(ctx context.Context, arg []CreateExchangeRateParams) ([]ExchangeRate, error) {
+	rates := make([]ExchangeRate, 0, len(arg))
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		for _, params := range arg {
+			rate, err := q.CreateExchangeRate(ctx, params)
+			if err != nil {
+				return err
+			}
+			rates = append(rates, rate)
+		}
+		return nil
+	})
+	return rates, err
+}
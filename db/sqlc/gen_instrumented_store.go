@@ -0,0 +1,162 @@
+//go:build ignore
+
+// gen_instrumented_store writes instrumented_store.go, the methods of InstrumentedStore.
+// Every method of Store, Querier included, is forwarded to the wrapped store between the observers.
+// Run it with go generate after changing the queries or the Store interface.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+const output = "instrumented_store.go"
+
+// imports are the packages the methods of Store may use, only those used are imported
+var imports = []string{"context", "database/sql", "encoding/json", "time", "github.com/google/uuid"}
+
+func main() {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != output
+	}, 0)
+	if err != nil {
+		log.Fatal("cannot parse package: ", err)
+	}
+	pkg, ok := pkgs["db"]
+	if !ok {
+		log.Fatal("package db not found")
+	}
+
+	interfaces := map[string]*ast.InterfaceType{}
+	for _, file := range pkg.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			if iface, ok := spec.Type.(*ast.InterfaceType); ok {
+				interfaces[spec.Name.Name] = iface
+			}
+			return false
+		})
+	}
+
+	methods := map[string]*ast.FuncType{}
+	collectMethods(interfaces, "Store", methods)
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var body bytes.Buffer
+	for _, name := range names {
+		writeMethod(&body, fset, name, methods[name])
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by gen_instrumented_store.go. DO NOT EDIT.\n\n")
+	buf.WriteString("package db\n\nimport (\n")
+	thirdParty := false
+	for _, path := range imports {
+		name := path[strings.LastIndex(path, "/")+1:]
+		if !bytes.Contains(body.Bytes(), []byte(name+".")) {
+			continue
+		}
+		// the standard library comes first, then a blank line
+		if strings.Contains(path, ".") && !thirdParty {
+			buf.WriteString("\n")
+			thirdParty = true
+		}
+		fmt.Fprintf(&buf, "%q\n", path)
+	}
+	buf.WriteString(")\n")
+	buf.Write(body.Bytes())
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal("cannot format generated code: ", err)
+	}
+	if err := os.WriteFile(output, src, 0o644); err != nil {
+		log.Fatal("cannot write ", output, ": ", err)
+	}
+}
+
+// collectMethods adds the methods of the named interface, and of the interfaces it embeds, to methods
+func collectMethods(interfaces map[string]*ast.InterfaceType, name string, methods map[string]*ast.FuncType) {
+	iface, ok := interfaces[name]
+	if !ok {
+		log.Fatalf("interface %s not found", name)
+	}
+	for _, field := range iface.Methods.List {
+		switch typ := field.Type.(type) {
+		case *ast.FuncType:
+			methods[field.Names[0].Name] = typ
+		case *ast.Ident:
+			collectMethods(interfaces, typ.Name, methods)
+		default:
+			log.Fatalf("unsupported element in interface %s", name)
+		}
+	}
+}
+
+// writeMethod writes a method observing the call and forwarding it to the wrapped store.
+// Every method takes a context first and returns an error last
+func writeMethod(buf *bytes.Buffer, fset *token.FileSet, name string, typ *ast.FuncType) {
+	var params, args []string
+	for i, field := range typ.Params.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent(fmt.Sprintf("p%d", i))}
+		}
+		for _, ident := range names {
+			params = append(params, ident.Name+" "+node(fset, field.Type))
+			arg := ident.Name
+			if _, ok := field.Type.(*ast.Ellipsis); ok {
+				arg += "..."
+			}
+			args = append(args, arg)
+		}
+	}
+	if len(args) == 0 || args[0] != "ctx" {
+		log.Fatalf("%s doesn't take a context first", name)
+	}
+
+	var results, values []string
+	for i, field := range typ.Results.List {
+		results = append(results, node(fset, field.Type))
+		values = append(values, fmt.Sprintf("r%d", i))
+	}
+	if len(results) == 0 || results[len(results)-1] != "error" {
+		log.Fatalf("%s doesn't return an error last", name)
+	}
+	values[len(values)-1] = "err"
+
+	resultList := results[0]
+	if len(results) > 1 {
+		resultList = "(" + strings.Join(results, ", ") + ")"
+	}
+	fmt.Fprintf(buf, "\nfunc (store *InstrumentedStore) %s(%s) %s {\n", name, strings.Join(params, ", "), resultList)
+	fmt.Fprintf(buf, "ctx, done := store.observe(ctx, %q)\n", name)
+	fmt.Fprintf(buf, "%s := store.Store.%s(%s)\n", strings.Join(values, ", "), name, strings.Join(args, ", "))
+	fmt.Fprintf(buf, "done(err)\n")
+	fmt.Fprintf(buf, "return %s\n}\n", strings.Join(values, ", "))
+}
+
+func node(fset *token.FileSet, n ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		log.Fatal("cannot print type: ", err)
+	}
+	return buf.String()
+}
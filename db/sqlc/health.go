@@ -0,0 +1,23 @@
+package db
+
+import "context"
+
+// MigrationVersion is the state golang-migrate records in schema_migrations
+type MigrationVersion struct {
+	Version int64 `json:"version"`
+	// Dirty is set when a migration failed halfway and the schema needs fixing by hand
+	Dirty bool `json:"dirty"`
+}
+
+// Ping checks the database can be reached
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return store.db.PingContext(ctx)
+}
+
+// GetMigrationVersion reads the version of the last migration applied.
+// schema_migrations belongs to golang-migrate, so sqlc doesn't generate the query
+func (store *SQLStore) GetMigrationVersion(ctx context.Context) (MigrationVersion, error) {
+	var version MigrationVersion
+	err := store.db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version.Version, &version.Dirty)
+	return version, err
+}
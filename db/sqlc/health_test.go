@@ -0,0 +1,18 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+	require.NoError(t, store.Ping(context.Background()))
+
+	version, err := store.GetMigrationVersion(context.Background())
+	require.NoError(t, err)
+	require.Positive(t, version.Version)
+	require.False(t, version.Dirty)
+}
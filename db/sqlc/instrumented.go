@@ -0,0 +1,40 @@
+package db
+
+import "context"
+
+//go:generate go run gen_instrumented_store.go
+
+// StoreObserver is told about every call made through an InstrumentedStore
+type StoreObserver interface {
+	// ObserveCall is called before method runs, with the context method will get.
+	// The returned func is called once method returned, with its error
+	ObserveCall(ctx context.Context, method string) (context.Context, func(err error))
+}
+
+// InstrumentedStore decorates a Store, running each of its calls between the observers.
+// Its methods are generated by gen_instrumented_store.go
+type InstrumentedStore struct {
+	Store
+	observers []StoreObserver
+}
+
+// NewInstrumentedStore wraps store so observers see every call made to it, in the order given
+func NewInstrumentedStore(store Store, observers ...StoreObserver) Store {
+	return &InstrumentedStore{
+		Store:     store,
+		observers: observers,
+	}
+}
+
+// observe starts observing a call to method, the returned func ends it
+func (store *InstrumentedStore) observe(ctx context.Context, method string) (context.Context, func(err error)) {
+	dones := make([]func(err error), len(store.observers))
+	for i, observer := range store.observers {
+		ctx, dones[i] = observer.ObserveCall(ctx, method)
+	}
+	return ctx, func(err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
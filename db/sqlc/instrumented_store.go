@@ -0,0 +1,662 @@
+// Code generated by gen_instrumented_store.go. DO NOT EDIT.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func (store *InstrumentedStore) AddAccountAvailableBalance(ctx context.Context, arg AddAccountAvailableBalanceParams) (Account, error) {
+	ctx, done := store.observe(ctx, "AddAccountAvailableBalance")
+	r0, err := store.Store.AddAccountAvailableBalance(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	ctx, done := store.observe(ctx, "AddAccountBalance")
+	r0, err := store.Store.AddAccountBalance(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error) {
+	ctx, done := store.observe(ctx, "AdjustBalanceTx")
+	r0, err := store.Store.AdjustBalanceTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) AdvanceStandingOrder(ctx context.Context, arg AdvanceStandingOrderParams) (StandingOrder, error) {
+	ctx, done := store.observe(ctx, "AdvanceStandingOrder")
+	r0, err := store.Store.AdvanceStandingOrder(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (TransferHoldTxResult, error) {
+	ctx, done := store.observe(ctx, "AuthorizeTransferTx")
+	r0, err := store.Store.AuthorizeTransferTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) BlockSession(ctx context.Context, id uuid.UUID) error {
+	ctx, done := store.observe(ctx, "BlockSession")
+	err := store.Store.BlockSession(ctx, id)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) BlockUserSessions(ctx context.Context, username string) error {
+	ctx, done := store.observe(ctx, "BlockUserSessions")
+	err := store.Store.BlockUserSessions(ctx, username)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "CancelScheduledTransfer")
+	r0, err := store.Store.CancelScheduledTransfer(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	ctx, done := store.observe(ctx, "CancelStandingOrder")
+	r0, err := store.Store.CancelStandingOrder(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CaptureTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error) {
+	ctx, done := store.observe(ctx, "CaptureTransferTx")
+	r0, err := store.Store.CaptureTransferTx(ctx, transferID)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CompletePaymentBatchItem(ctx context.Context, arg CompletePaymentBatchItemParams) (PaymentBatchItem, error) {
+	ctx, done := store.observe(ctx, "CompletePaymentBatchItem")
+	r0, err := store.Store.CompletePaymentBatchItem(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CompleteScheduledTransfer(ctx context.Context, arg CompleteScheduledTransferParams) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "CompleteScheduledTransfer")
+	r0, err := store.Store.CompleteScheduledTransfer(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CountAccountEntries(ctx context.Context, accountID int64) (int64, error) {
+	ctx, done := store.observe(ctx, "CountAccountEntries")
+	r0, err := store.Store.CountAccountEntries(ctx, accountID)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CountPaymentBatchItems(ctx context.Context, batchID int64) ([]CountPaymentBatchItemsRow, error) {
+	ctx, done := store.observe(ctx, "CountPaymentBatchItems")
+	r0, err := store.Store.CountPaymentBatchItems(ctx, batchID)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	ctx, done := store.observe(ctx, "CreateAccount")
+	r0, err := store.Store.CreateAccount(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateBalanceSnapshots(ctx context.Context, snapshotAt time.Time) (int64, error) {
+	ctx, done := store.observe(ctx, "CreateBalanceSnapshots")
+	r0, err := store.Store.CreateBalanceSnapshots(ctx, snapshotAt)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	ctx, done := store.observe(ctx, "CreateEntry")
+	r0, err := store.Store.CreateEntry(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRate, error) {
+	ctx, done := store.observe(ctx, "CreateExchangeRate")
+	r0, err := store.Store.CreateExchangeRate(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateExchangeRatesTx(ctx context.Context, arg []CreateExchangeRateParams) ([]ExchangeRate, error) {
+	ctx, done := store.observe(ctx, "CreateExchangeRatesTx")
+	r0, err := store.Store.CreateExchangeRatesTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error) {
+	ctx, done := store.observe(ctx, "CreateIdempotencyKey")
+	r0, err := store.Store.CreateIdempotencyKey(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreatePaymentBatch(ctx context.Context, arg CreatePaymentBatchParams) (PaymentBatch, error) {
+	ctx, done := store.observe(ctx, "CreatePaymentBatch")
+	r0, err := store.Store.CreatePaymentBatch(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreatePaymentBatchItem(ctx context.Context, arg CreatePaymentBatchItemParams) (PaymentBatchItem, error) {
+	ctx, done := store.observe(ctx, "CreatePaymentBatchItem")
+	r0, err := store.Store.CreatePaymentBatchItem(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreatePaymentBatchTx(ctx context.Context, arg CreatePaymentBatchTxParams) (CreatePaymentBatchTxResult, error) {
+	ctx, done := store.observe(ctx, "CreatePaymentBatchTx")
+	r0, err := store.Store.CreatePaymentBatchTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateReconciliationReport(ctx context.Context, arg CreateReconciliationReportParams) (ReconciliationReport, error) {
+	ctx, done := store.observe(ctx, "CreateReconciliationReport")
+	r0, err := store.Store.CreateReconciliationReport(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "CreateScheduledTransfer")
+	r0, err := store.Store.CreateScheduledTransfer(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	ctx, done := store.observe(ctx, "CreateSession")
+	r0, err := store.Store.CreateSession(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error) {
+	ctx, done := store.observe(ctx, "CreateStandingOrder")
+	r0, err := store.Store.CreateStandingOrder(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateStandingOrderExecution(ctx context.Context, arg CreateStandingOrderExecutionParams) (StandingOrderExecution, error) {
+	ctx, done := store.observe(ctx, "CreateStandingOrderExecution")
+	r0, err := store.Store.CreateStandingOrderExecution(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
+	ctx, done := store.observe(ctx, "CreateTransfer")
+	r0, err := store.Store.CreateTransfer(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateTransferHold(ctx context.Context, arg CreateTransferHoldParams) (Transfer, error) {
+	ctx, done := store.observe(ctx, "CreateTransferHold")
+	r0, err := store.Store.CreateTransferHold(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
+	ctx, done := store.observe(ctx, "CreateUser")
+	r0, err := store.Store.CreateUser(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) DeleteAccount(ctx context.Context, id int64) error {
+	ctx, done := store.observe(ctx, "DeleteAccount")
+	err := store.Store.DeleteAccount(ctx, id)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) DeleteAccountTx(ctx context.Context, accountID int64) error {
+	ctx, done := store.observe(ctx, "DeleteAccountTx")
+	err := store.Store.DeleteAccountTx(ctx, accountID)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) DeleteEntry(ctx context.Context, id int64) error {
+	ctx, done := store.observe(ctx, "DeleteEntry")
+	err := store.Store.DeleteEntry(ctx, id)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, done := store.observe(ctx, "DeleteExpiredIdempotencyKeys")
+	r0, err := store.Store.DeleteExpiredIdempotencyKeys(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	ctx, done := store.observe(ctx, "DeleteExpiredRevokedTokens")
+	r0, err := store.Store.DeleteExpiredRevokedTokens(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ExecutePaymentBatchItemTx(ctx context.Context) (ExecutePaymentBatchItemTxResult, error) {
+	ctx, done := store.observe(ctx, "ExecutePaymentBatchItemTx")
+	r0, err := store.Store.ExecutePaymentBatchItemTx(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ExecuteScheduledTransferTx(ctx context.Context) (ExecuteScheduledTransferTxResult, error) {
+	ctx, done := store.observe(ctx, "ExecuteScheduledTransferTx")
+	r0, err := store.Store.ExecuteScheduledTransferTx(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ExecuteStandingOrderTx(ctx context.Context) (ExecuteStandingOrderTxResult, error) {
+	ctx, done := store.observe(ctx, "ExecuteStandingOrderTx")
+	r0, err := store.Store.ExecuteStandingOrderTx(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ExpireTransferHoldsTx(ctx context.Context) (int64, error) {
+	ctx, done := store.observe(ctx, "ExpireTransferHoldsTx")
+	r0, err := store.Store.ExpireTransferHoldsTx(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) FailPaymentBatchItem(ctx context.Context, arg FailPaymentBatchItemParams) (PaymentBatchItem, error) {
+	ctx, done := store.observe(ctx, "FailPaymentBatchItem")
+	r0, err := store.Store.FailPaymentBatchItem(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) FailScheduledTransfer(ctx context.Context, arg FailScheduledTransferParams) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "FailScheduledTransfer")
+	r0, err := store.Store.FailScheduledTransfer(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	ctx, done := store.observe(ctx, "GetAccount")
+	r0, err := store.Store.GetAccount(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetAccountBalanceAsOf(ctx context.Context, arg GetAccountBalanceAsOfParams) (GetAccountBalanceAsOfRow, error) {
+	ctx, done := store.observe(ctx, "GetAccountBalanceAsOf")
+	r0, err := store.Store.GetAccountBalanceAsOf(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetAccountForupdate(ctx context.Context, id int64) (Account, error) {
+	ctx, done := store.observe(ctx, "GetAccountForupdate")
+	r0, err := store.Store.GetAccountForupdate(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetDueScheduledTransferForUpdate(ctx context.Context) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "GetDueScheduledTransferForUpdate")
+	r0, err := store.Store.GetDueScheduledTransferForUpdate(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetDueStandingOrderForUpdate(ctx context.Context) (StandingOrder, error) {
+	ctx, done := store.observe(ctx, "GetDueStandingOrderForUpdate")
+	r0, err := store.Store.GetDueStandingOrderForUpdate(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	ctx, done := store.observe(ctx, "GetEntry")
+	r0, err := store.Store.GetEntry(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error) {
+	ctx, done := store.observe(ctx, "GetExchangeRate")
+	r0, err := store.Store.GetExchangeRate(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetExpiredTransferHoldForUpdate(ctx context.Context) (Transfer, error) {
+	ctx, done := store.observe(ctx, "GetExpiredTransferHoldForUpdate")
+	r0, err := store.Store.GetExpiredTransferHoldForUpdate(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error) {
+	ctx, done := store.observe(ctx, "GetIdempotencyKey")
+	r0, err := store.Store.GetIdempotencyKey(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetLastEntryHash(ctx context.Context, accountID int64) (string, error) {
+	ctx, done := store.observe(ctx, "GetLastEntryHash")
+	r0, err := store.Store.GetLastEntryHash(ctx, accountID)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetMigrationVersion(ctx context.Context) (MigrationVersion, error) {
+	ctx, done := store.observe(ctx, "GetMigrationVersion")
+	r0, err := store.Store.GetMigrationVersion(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetPaymentBatch(ctx context.Context, id int64) (PaymentBatch, error) {
+	ctx, done := store.observe(ctx, "GetPaymentBatch")
+	r0, err := store.Store.GetPaymentBatch(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetPendingPaymentBatchItemForUpdate(ctx context.Context) (PaymentBatchItem, error) {
+	ctx, done := store.observe(ctx, "GetPendingPaymentBatchItemForUpdate")
+	r0, err := store.Store.GetPendingPaymentBatchItemForUpdate(ctx)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetReconciliationReport(ctx context.Context, id int64) (ReconciliationReport, error) {
+	ctx, done := store.observe(ctx, "GetReconciliationReport")
+	r0, err := store.Store.GetReconciliationReport(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "GetScheduledTransfer")
+	r0, err := store.Store.GetScheduledTransfer(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetSession(ctx context.Context, id uuid.UUID) (Session, error) {
+	ctx, done := store.observe(ctx, "GetSession")
+	r0, err := store.Store.GetSession(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error) {
+	ctx, done := store.observe(ctx, "GetStandingOrder")
+	r0, err := store.Store.GetStandingOrder(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	ctx, done := store.observe(ctx, "GetTransfer")
+	r0, err := store.Store.GetTransfer(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	ctx, done := store.observe(ctx, "GetTransferForUpdate")
+	r0, err := store.Store.GetTransferForUpdate(ctx, id)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetTransferReversedAmounts(ctx context.Context, reversesTransferID sql.NullInt64) (GetTransferReversedAmountsRow, error) {
+	ctx, done := store.observe(ctx, "GetTransferReversedAmounts")
+	r0, err := store.Store.GetTransferReversedAmounts(ctx, reversesTransferID)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) GetUser(ctx context.Context, username string) (User, error) {
+	ctx, done := store.observe(ctx, "GetUser")
+	r0, err := store.Store.GetUser(ctx, username)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error) {
+	ctx, done := store.observe(ctx, "IsTokenRevoked")
+	r0, err := store.Store.IsTokenRevoked(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error) {
+	ctx, done := store.observe(ctx, "ListAccountEntries")
+	r0, err := store.Store.ListAccountEntries(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListAccountLedgerTotals(ctx context.Context, arg ListAccountLedgerTotalsParams) ([]ListAccountLedgerTotalsRow, error) {
+	ctx, done := store.observe(ctx, "ListAccountLedgerTotals")
+	r0, err := store.Store.ListAccountLedgerTotals(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	ctx, done := store.observe(ctx, "ListAccountTransfers")
+	r0, err := store.Store.ListAccountTransfers(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	ctx, done := store.observe(ctx, "ListAccounts")
+	r0, err := store.Store.ListAccounts(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error) {
+	ctx, done := store.observe(ctx, "ListAccountsAfter")
+	r0, err := store.Store.ListAccountsAfter(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	ctx, done := store.observe(ctx, "ListEntries")
+	r0, err := store.Store.ListEntries(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListEntryChain(ctx context.Context, arg ListEntryChainParams) ([]Entry, error) {
+	ctx, done := store.observe(ctx, "ListEntryChain")
+	r0, err := store.Store.ListEntryChain(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListExchangeRates(ctx context.Context, arg ListExchangeRatesParams) ([]ExchangeRate, error) {
+	ctx, done := store.observe(ctx, "ListExchangeRates")
+	r0, err := store.Store.ListExchangeRates(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListPaymentBatchItems(ctx context.Context, arg ListPaymentBatchItemsParams) ([]PaymentBatchItem, error) {
+	ctx, done := store.observe(ctx, "ListPaymentBatchItems")
+	r0, err := store.Store.ListPaymentBatchItems(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListPaymentBatches(ctx context.Context, arg ListPaymentBatchesParams) ([]PaymentBatch, error) {
+	ctx, done := store.observe(ctx, "ListPaymentBatches")
+	r0, err := store.Store.ListPaymentBatches(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error) {
+	ctx, done := store.observe(ctx, "ListScheduledTransfers")
+	r0, err := store.Store.ListScheduledTransfers(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListStandingOrderExecutions(ctx context.Context, arg ListStandingOrderExecutionsParams) ([]StandingOrderExecution, error) {
+	ctx, done := store.observe(ctx, "ListStandingOrderExecutions")
+	r0, err := store.Store.ListStandingOrderExecutions(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error) {
+	ctx, done := store.observe(ctx, "ListStandingOrders")
+	r0, err := store.Store.ListStandingOrders(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	ctx, done := store.observe(ctx, "ListStatementEntries")
+	r0, err := store.Store.ListStatementEntries(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListTransferEntryTotals(ctx context.Context, arg ListTransferEntryTotalsParams) ([]ListTransferEntryTotalsRow, error) {
+	ctx, done := store.observe(ctx, "ListTransferEntryTotals")
+	r0, err := store.Store.ListTransferEntryTotals(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	ctx, done := store.observe(ctx, "ListTransfers")
+	r0, err := store.Store.ListTransfers(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) Ping(ctx context.Context) error {
+	ctx, done := store.observe(ctx, "Ping")
+	err := store.Store.Ping(ctx)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) ReadTx(ctx context.Context, fn func(Querier) error) error {
+	ctx, done := store.observe(ctx, "ReadTx")
+	err := store.Store.ReadTx(ctx, fn)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	ctx, done := store.observe(ctx, "ReverseTransferTx")
+	r0, err := store.Store.ReverseTransferTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	ctx, done := store.observe(ctx, "RevokeToken")
+	err := store.Store.RevokeToken(ctx, arg)
+	done(err)
+	return err
+}
+
+func (store *InstrumentedStore) RevokeUserTokens(ctx context.Context, username string) (User, error) {
+	ctx, done := store.observe(ctx, "RevokeUserTokens")
+	r0, err := store.Store.RevokeUserTokens(ctx, username)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) SetEntryHash(ctx context.Context, arg SetEntryHashParams) (Entry, error) {
+	ctx, done := store.observe(ctx, "SetEntryHash")
+	r0, err := store.Store.SetEntryHash(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) SetIdempotencyKeyResponse(ctx context.Context, arg SetIdempotencyKeyResponseParams) (IdempotencyKey, error) {
+	ctx, done := store.observe(ctx, "SetIdempotencyKeyResponse")
+	r0, err := store.Store.SetIdempotencyKeyResponse(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+	ctx, done := store.observe(ctx, "TransferTx")
+	r0, err := store.Store.TransferTx(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	ctx, done := store.observe(ctx, "UpdateAccount")
+	r0, err := store.Store.UpdateAccount(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error) {
+	ctx, done := store.observe(ctx, "UpdateAccountOverdraftLimit")
+	r0, err := store.Store.UpdateAccountOverdraftLimit(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	ctx, done := store.observe(ctx, "UpdateTransferStatus")
+	r0, err := store.Store.UpdateTransferStatus(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	ctx, done := store.observe(ctx, "UpdateUserRole")
+	r0, err := store.Store.UpdateUserRole(ctx, arg)
+	done(err)
+	return r0, err
+}
+
+func (store *InstrumentedStore) VoidTransferTx(ctx context.Context, transferID int64) (TransferHoldTxResult, error) {
+	ctx, done := store.observe(ctx, "VoidTransferTx")
+	r0, err := store.Store.VoidTransferTx(ctx, transferID)
+	done(err)
+	return r0, err
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type observerKey struct{}
+
+// recordingObserver logs the calls it observes and tags the context with its name
+type recordingObserver struct {
+	name string
+	log  *[]string
+}
+
+func (observer recordingObserver) ObserveCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	*observer.log = append(*observer.log, observer.name+" start "+method)
+	ctx = context.WithValue(ctx, observerKey{}, observer.name)
+	return ctx, func(err error) {
+		*observer.log = append(*observer.log, observer.name+" done "+err.Error())
+	}
+}
+
+// accountStore only implements GetAccount
+type accountStore struct {
+	Store
+	t *testing.T
+}
+
+func (store accountStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	// the context comes from the last observer
+	require.Equal(store.t, "second", ctx.Value(observerKey{}))
+	return Account{ID: id}, sql.ErrNoRows
+}
+
+func TestInstrumentedStore(t *testing.T) {
+	var log []string
+	store := NewInstrumentedStore(accountStore{t: t},
+		recordingObserver{name: "first", log: &log},
+		recordingObserver{name: "second", log: &log},
+	)
+
+	account, err := store.GetAccount(context.Background(), 7)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.Equal(t, int64(7), account.ID)
+	require.Equal(t, []string{
+		"first start GetAccount",
+		"second start GetAccount",
+		"second done " + sql.ErrNoRows.Error(),
+		"first done " + sql.ErrNoRows.Error(),
+	}, log)
+}
+
+func TestWithTxTrace(t *testing.T) {
+	var calls []string
+	ctx := WithTxTrace(context.Background(), &TxTrace{
+		Rollback: func(error) { calls = append(calls, "first rollback") },
+	})
+	ctx = WithTxTrace(ctx, &TxTrace{})
+	ctx = WithTxTrace(ctx, &TxTrace{
+		Rollback: func(error) { calls = append(calls, "second rollback") },
+	})
+
+	err := errors.New("tx failed")
+	traceRollback(ctx, err)
+	require.Equal(t, []string{"first rollback", "second rollback"}, calls)
+
+	// a context without a trace is fine
+	traceRollback(context.Background(), err)
+}
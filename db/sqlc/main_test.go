@@ -14,6 +14,8 @@ import (
 
 var testQueries *Queries
 var testDB *sql.DB
+// testEntryKey keys the entry hashes of the stores under test
+var testEntryKey string
 func TestMain (m *testing.M){
 	config,err := util.LoadConfig("../..")
 	if err != nil {
@@ -24,5 +26,6 @@ func TestMain (m *testing.M){
 		log.Fatal("cannot connect to db:",err)
 	}
 	testQueries = New(testDB)
+	testEntryKey = config.EntryHashKey
 	os.Exit(m.Run())
 }
@@ -6,7 +6,10 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 type Account struct {
@@ -15,6 +18,19 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// balance less the amounts held by authorized transfers
+	AvailableBalance int64 `json:"available_balance"`
+}
+
+type BalanceSnapshot struct {
+	AccountID int64 `json:"account_id"`
+	// end of the day the snapshot closes, midnight UTC
+	SnapshotAt time.Time `json:"snapshot_at"`
+	// sum of the account entries created up to snapshot_at
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -23,6 +39,143 @@ type Entry struct {
 	// can be negative or postive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// transfer that posted the entry, null for adjustments
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	// hash of the previous entry on the account, empty for the first chained one
+	PrevHash string `json:"prev_hash"`
+	// hex HMAC-SHA256 of the entry and prev_hash, empty for entries posted before the chain
+	Hash string `json:"hash"`
+}
+
+type ExchangeRate struct {
+	ID           int64  `json:"id"`
+	FromCurrency string `json:"from_currency"`
+	ToCurrency   string `json:"to_currency"`
+	// units of to_currency per unit of from_currency
+	Rate          string    `json:"rate"`
+	EffectiveFrom time.Time `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username       string          `json:"username"`
+	Key            string          `json:"key"`
+	RequestHash    string          `json:"request_hash"`
+	ResponseStatus int32           `json:"response_status"`
+	ResponseBody   json.RawMessage `json:"response_body"`
+	CreatedAt      time.Time       `json:"created_at"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+type PaymentBatch struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	// pain001 or csv
+	Format string `json:"format"`
+	// pain.001 GrpHdr/MsgId, empty for csv
+	MessageID string    `json:"message_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PaymentBatchItem struct {
+	ID      int64 `json:"id"`
+	BatchID int64 `json:"batch_id"`
+	// position of the instruction in the uploaded file, from 1
+	Line       int32  `json:"line"`
+	EndToEndID string `json:"end_to_end_id"`
+	// as uploaded, the account may not exist when the item is rejected
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	// pending, completed, rejected on upload or failed on execution
+	Status        string         `json:"status"`
+	TransferID    sql.NullInt64  `json:"transfer_id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+	ProcessedAt   sql.NullTime   `json:"processed_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type ReconciliationReport struct {
+	ID                 int64     `json:"id"`
+	StartedAt          time.Time `json:"started_at"`
+	FinishedAt         time.Time `json:"finished_at"`
+	AccountsChecked    int64     `json:"accounts_checked"`
+	TransfersChecked   int64     `json:"transfers_checked"`
+	AccountDrifts      int64     `json:"account_drifts"`
+	TransferMismatches int64     `json:"transfer_mismatches"`
+	// json, not jsonb, so the signed bytes are kept as they were
+	Report json.RawMessage `json:"report"`
+	// hex HMAC-SHA256 of report
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RevokedToken struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ExpiresAt time.Time `json:"expires_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount    int64     `json:"amount"`
+	ExecuteAt time.Time `json:"execute_at"`
+	// pending, completed, failed or cancelled
+	Status        string         `json:"status"`
+	TransferID    sql.NullInt64  `json:"transfer_id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+	ExecutedAt    sql.NullTime   `json:"executed_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type Session struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	RefreshToken string    `json:"refresh_token"`
+	UserAgent    string    `json:"user_agent"`
+	ClientIp     string    `json:"client_ip"`
+	IsBlocked    bool      `json:"is_blocked"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type StandingOrder struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// RRULE subset, FREQ and INTERVAL
+	Recurrence     string        `json:"recurrence"`
+	StartAt        time.Time     `json:"start_at"`
+	EndAt          sql.NullTime  `json:"end_at"`
+	MaxOccurrences sql.NullInt32 `json:"max_occurrences"`
+	// occurrences run so far, including skipped ones
+	Occurrences int32 `json:"occurrences"`
+	// null once the order has ended
+	NextRunAt sql.NullTime `json:"next_run_at"`
+	// active, completed or cancelled
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type StandingOrderExecution struct {
+	ID              int64     `json:"id"`
+	StandingOrderID int64     `json:"standing_order_id"`
+	Occurrence      int32     `json:"occurrence"`
+	ScheduledFor    time.Time `json:"scheduled_for"`
+	// completed, skipped or failed
+	Status        string         `json:"status"`
+	TransferID    sql.NullInt64  `json:"transfer_id"`
+	FailureReason sql.NullString `json:"failure_reason"`
+	CreatedAt     time.Time      `json:"created_at"`
 }
 
 type Transfer struct {
@@ -32,6 +185,15 @@ type Transfer struct {
 	// most be postive
 	Amount    int64        `json:"amount"`
 	CreatedAt sql.NullTime `json:"created_at"`
+	// amount credited, in the to account currency
+	ToAmount     int64  `json:"to_amount"`
+	ExchangeRate string `json:"exchange_rate"`
+	// transfer this one reverses or refunds, money flows back from its to account
+	ReversesTransferID sql.NullInt64 `json:"reverses_transfer_id"`
+	// authorized, settled, voided or expired
+	Status string `json:"status"`
+	// when an authorized transfer releases its hold
+	ExpiresAt sql.NullTime `json:"expires_at"`
 }
 
 type User struct {
@@ -41,4 +203,6 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	TokensRevokedAt   time.Time `json:"tokens_revoked_at"`
+	Role              string    `json:"role"`
 }
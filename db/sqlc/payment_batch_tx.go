@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const (
+	PaymentBatchPain001 = "pain001"
+	PaymentBatchCSV     = "csv"
+)
+
+const (
+	PaymentBatchItemPending   = "pending"
+	PaymentBatchItemCompleted = "completed"
+	PaymentBatchItemRejected  = "rejected"
+	PaymentBatchItemFailed    = "failed"
+)
+
+type CreatePaymentBatchTxParams struct {
+	Owner     string `json:"owner"`
+	Format    string `json:"format"`
+	MessageID string `json:"message_id"`
+	// Items are stored in order, BatchID is filled in
+	Items []CreatePaymentBatchItemParams `json:"items"`
+}
+
+type CreatePaymentBatchTxResult struct {
+	Batch PaymentBatch       `json:"batch"`
+	Items []PaymentBatchItem `json:"items"`
+}
+
+// CreatePaymentBatchTx stores an uploaded batch with all of its items, or nothing at all
+func (store *SQLStore) CreatePaymentBatchTx(ctx context.Context, arg CreatePaymentBatchTxParams) (CreatePaymentBatchTxResult, error) {
+	var result CreatePaymentBatchTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result.Batch, err = q.CreatePaymentBatch(ctx, CreatePaymentBatchParams{
+			Owner:     arg.Owner,
+			Format:    arg.Format,
+			MessageID: arg.MessageID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Items = make([]PaymentBatchItem, 0, len(arg.Items))
+		for _, params := range arg.Items {
+			params.BatchID = result.Batch.ID
+			item, err := q.CreatePaymentBatchItem(ctx, params)
+			if err != nil {
+				return err
+			}
+			result.Items = append(result.Items, item)
+		}
+		return nil
+	})
+	return result, err
+}
+
+type ExecutePaymentBatchItemTxResult struct {
+	Item PaymentBatchItem `json:"item"`
+	// Transfer is set when the item completed
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// Failure is why the ledger refused the item's transfer, set when the item was recorded as failed
+	Failure error `json:"-"`
+}
+
+// ExecutePaymentBatchItemTx claims the next pending batch item, skipping rows other workers hold,
+// and transfers it. A transfer that fails for good is recorded as failed with the reason, transient
+// errors leave the item pending to be retried. It returns sql.ErrNoRows when nothing is pending
+func (store *SQLStore) ExecutePaymentBatchItemTx(ctx context.Context) (ExecutePaymentBatchItemTxResult, error) {
+	var result ExecutePaymentBatchItemTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		item, err := q.GetPendingPaymentBatchItemForUpdate(ctx)
+		if err != nil {
+			return err
+		}
+
+		transferResult, failure, err := store.tryTransfer(ctx, q, TransferTxParams{
+			FromAccountID: item.FromAccountID,
+			ToAccountID:   item.ToAccountID,
+			Amount:        item.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if failure != nil {
+			result.Failure = failure
+			result.Item, err = q.FailPaymentBatchItem(ctx, FailPaymentBatchItemParams{
+				ID:            item.ID,
+				FailureReason: sql.NullString{String: failureReason(failure), Valid: true},
+			})
+			return err
+		}
+
+		result.Transfer = &transferResult
+		result.Item, err = q.CompletePaymentBatchItem(ctx, CompletePaymentBatchItemParams{
+			ID:         item.ID,
+			TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	return result, err
+}
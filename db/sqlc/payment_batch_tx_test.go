@@ -0,0 +1,150 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPaymentBatch(t *testing.T, store Store, from Account, to Account, amounts ...int64) CreatePaymentBatchTxResult {
+	arg := CreatePaymentBatchTxParams{
+		Owner:     from.Owner,
+		Format:    PaymentBatchPain001,
+		MessageID: util.RandomString(12),
+	}
+	for i, amount := range amounts {
+		arg.Items = append(arg.Items, CreatePaymentBatchItemParams{
+			Line:          int32(i + 1),
+			EndToEndID:    util.RandomString(8),
+			FromAccountID: from.ID,
+			ToAccountID:   to.ID,
+			Amount:        amount,
+			Currency:      from.Currency,
+			Status:        PaymentBatchItemPending,
+		})
+	}
+
+	result, err := store.CreatePaymentBatchTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.Batch.ID)
+	require.Equal(t, arg.MessageID, result.Batch.MessageID)
+	require.Len(t, result.Items, len(amounts))
+	for i, item := range result.Items {
+		require.Equal(t, result.Batch.ID, item.BatchID)
+		require.Equal(t, int32(i+1), item.Line)
+		require.Equal(t, PaymentBatchItemPending, item.Status)
+	}
+	return result
+}
+
+// executePaymentBatch runs pending batch items until none of the given batch's are left
+func executePaymentBatch(t *testing.T, store Store, batchID int64) []PaymentBatchItem {
+	for {
+		_, err := store.ExecutePaymentBatchItemTx(context.Background())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		counts, err := store.CountPaymentBatchItems(context.Background(), batchID)
+		require.NoError(t, err)
+		pending := false
+		for _, count := range counts {
+			pending = pending || count.Status == PaymentBatchItemPending
+		}
+		if !pending {
+			break
+		}
+	}
+	items, err := store.ListPaymentBatchItems(context.Background(), ListPaymentBatchItemsParams{
+		BatchID: batchID,
+		Limit:   100,
+	})
+	require.NoError(t, err)
+	return items
+}
+
+func TestExecutePaymentBatchItemTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	batch := createRandomPaymentBatch(t, store, account1, account2, 10, account1.Balance, 20)
+
+	items := executePaymentBatch(t, store, batch.Batch.ID)
+	require.Len(t, items, 3)
+
+	// lines run in order, so the second one no longer fits
+	require.Equal(t, PaymentBatchItemCompleted, items[0].Status)
+	require.True(t, items[0].TransferID.Valid)
+	require.True(t, items[0].ProcessedAt.Valid)
+
+	require.Equal(t, PaymentBatchItemFailed, items[1].Status)
+	require.False(t, items[1].TransferID.Valid)
+	require.Contains(t, items[1].FailureReason.String, ErrInsufficientFunds.Error())
+
+	require.Equal(t, PaymentBatchItemCompleted, items[2].Status)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-30, updatedAccount1.Balance)
+}
+
+func TestExecutePaymentBatchItemTxLedgerError(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	// crediting a full account overflows its balance, which no retry can fix
+	_, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account2.ID,
+		Balance: math.MaxInt64,
+	})
+	require.NoError(t, err)
+	broken := createRandomPaymentBatch(t, store, account1, account2, 10)
+	behind := createRandomPaymentBatch(t, store, account1, createRandomAccountWithCurrency(t, account1.Currency), 20)
+
+	items := executePaymentBatch(t, store, broken.Batch.ID)
+	require.Len(t, items, 1)
+	require.Equal(t, PaymentBatchItemFailed, items[0].Status)
+	require.False(t, items[0].TransferID.Valid)
+	require.True(t, items[0].FailureReason.Valid)
+
+	// the batches behind it aren't held up
+	items = executePaymentBatch(t, store, behind.Batch.ID)
+	require.Len(t, items, 1)
+	require.Equal(t, PaymentBatchItemCompleted, items[0].Status)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-20, updatedAccount1.Balance)
+}
+
+func TestCreatePaymentBatchTxDuplicateMessageID(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	batch := createRandomPaymentBatch(t, store, account1, account2, 10)
+
+	_, err := store.CreatePaymentBatchTx(context.Background(), CreatePaymentBatchTxParams{
+		Owner:     account1.Owner,
+		Format:    PaymentBatchPain001,
+		MessageID: batch.Batch.MessageID,
+	})
+	require.Error(t, err)
+	require.Equal(t, "unique_violation", err.(*pq.Error).Code.Name())
+
+	// csv uploads have no message id and never clash
+	for i := 0; i < 2; i++ {
+		_, err := store.CreatePaymentBatchTx(context.Background(), CreatePaymentBatchTxParams{
+			Owner:  account1.Owner,
+			Format: PaymentBatchCSV,
+		})
+		require.NoError(t, err)
+	}
+}
@@ -6,25 +6,92 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Querier interface {
+	AddAccountAvailableBalance(ctx context.Context, arg AddAccountAvailableBalanceParams) (Account, error)
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AdvanceStandingOrder(ctx context.Context, arg AdvanceStandingOrderParams) (StandingOrder, error)
+	BlockSession(ctx context.Context, id uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
+	CancelScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	CancelStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
+	CompletePaymentBatchItem(ctx context.Context, arg CompletePaymentBatchItemParams) (PaymentBatchItem, error)
+	CompleteScheduledTransfer(ctx context.Context, arg CompleteScheduledTransferParams) (ScheduledTransfer, error)
+	CountAccountEntries(ctx context.Context, accountID int64) (int64, error)
+	CountPaymentBatchItems(ctx context.Context, batchID int64) ([]CountPaymentBatchItemsRow, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	// each snapshot carries the previous one forward with the entries posted since,
+	// so only one day of entries is read per account
+	CreateBalanceSnapshots(ctx context.Context, snapshotAt time.Time) (int64, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRate, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreatePaymentBatch(ctx context.Context, arg CreatePaymentBatchParams) (PaymentBatch, error)
+	CreatePaymentBatchItem(ctx context.Context, arg CreatePaymentBatchItemParams) (PaymentBatchItem, error)
+	CreateReconciliationReport(ctx context.Context, arg CreateReconciliationReportParams) (ReconciliationReport, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
+	CreateStandingOrder(ctx context.Context, arg CreateStandingOrderParams) (StandingOrder, error)
+	CreateStandingOrderExecution(ctx context.Context, arg CreateStandingOrderExecutionParams) (StandingOrderExecution, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	CreateTransferHold(ctx context.Context, arg CreateTransferHoldParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	DeleteEntry(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	FailPaymentBatchItem(ctx context.Context, arg FailPaymentBatchItemParams) (PaymentBatchItem, error)
+	FailScheduledTransfer(ctx context.Context, arg FailScheduledTransferParams) (ScheduledTransfer, error)
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAsOf(ctx context.Context, arg GetAccountBalanceAsOfParams) (GetAccountBalanceAsOfRow, error)
 	GetAccountForupdate(ctx context.Context, id int64) (Account, error)
+	GetDueScheduledTransferForUpdate(ctx context.Context) (ScheduledTransfer, error)
+	GetDueStandingOrderForUpdate(ctx context.Context) (StandingOrder, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetExchangeRate(ctx context.Context, arg GetExchangeRateParams) (ExchangeRate, error)
+	GetExpiredTransferHoldForUpdate(ctx context.Context) (Transfer, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLastEntryHash(ctx context.Context, accountID int64) (string, error)
+	GetPaymentBatch(ctx context.Context, id int64) (PaymentBatch, error)
+	GetPendingPaymentBatchItemForUpdate(ctx context.Context) (PaymentBatchItem, error)
+	GetReconciliationReport(ctx context.Context, id int64) (ReconciliationReport, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetStandingOrder(ctx context.Context, id int64) (StandingOrder, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
+	GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error)
+	GetTransferReversedAmounts(ctx context.Context, reversesTransferID sql.NullInt64) (GetTransferReversedAmountsRow, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	IsTokenRevoked(ctx context.Context, arg IsTokenRevokedParams) (bool, error)
+	ListAccountEntries(ctx context.Context, arg ListAccountEntriesParams) ([]Entry, error)
+	ListAccountLedgerTotals(ctx context.Context, arg ListAccountLedgerTotalsParams) ([]ListAccountLedgerTotalsRow, error)
+	ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntryChain(ctx context.Context, arg ListEntryChainParams) ([]Entry, error)
+	ListExchangeRates(ctx context.Context, arg ListExchangeRatesParams) ([]ExchangeRate, error)
+	ListPaymentBatchItems(ctx context.Context, arg ListPaymentBatchItemsParams) ([]PaymentBatchItem, error)
+	ListPaymentBatches(ctx context.Context, arg ListPaymentBatchesParams) ([]PaymentBatch, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListStandingOrderExecutions(ctx context.Context, arg ListStandingOrderExecutionsParams) ([]StandingOrderExecution, error)
+	ListStandingOrders(ctx context.Context, arg ListStandingOrdersParams) ([]StandingOrder, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
+	ListTransferEntryTotals(ctx context.Context, arg ListTransferEntryTotalsParams) ([]ListTransferEntryTotalsRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	RevokeToken(ctx context.Context, arg RevokeTokenParams) error
+	RevokeUserTokens(ctx context.Context, username string) (User, error)
+	SetEntryHash(ctx context.Context, arg SetEntryHashParams) (Entry, error)
+	SetIdempotencyKeyResponse(ctx context.Context, arg SetIdempotencyKeyResponseParams) (IdempotencyKey, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
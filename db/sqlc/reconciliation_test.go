@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListLedgerTotals(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	hold, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        5,
+	})
+	require.NoError(t, err)
+
+	accounts, err := store.ListAccountLedgerTotals(context.Background(), ListAccountLedgerTotalsParams{
+		AfterID: account1.ID - 1,
+		Limit:   1,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+	// the funding balance was set directly, so only the transfer has entries
+	require.Equal(t, int64(-10), accounts[0].EntriesTotal)
+	require.Equal(t, int64(5), accounts[0].HeldTotal)
+	require.Equal(t, accounts[0].Balance-accounts[0].HeldTotal, accounts[0].AvailableBalance)
+
+	transfers, err := store.ListTransferEntryTotals(context.Background(), ListTransferEntryTotalsParams{
+		AfterID: result.Transfer.ID - 1,
+		Limit:   2,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, result.Transfer.ID, transfers[0].ID)
+	require.Equal(t, int64(2), transfers[0].EntryCount)
+	require.Equal(t, int64(-10), transfers[0].FromEntriesTotal)
+	require.Equal(t, int64(10), transfers[0].ToEntriesTotal)
+	require.Equal(t, hold.Transfer.ID, transfers[1].ID)
+	require.Equal(t, TransferAuthorized, transfers[1].Status)
+	require.Zero(t, transfers[1].EntryCount)
+}
+
+func TestCreateReconciliationReport(t *testing.T) {
+	arg := CreateReconciliationReportParams{
+		StartedAt:          time.Now().Add(-time.Second),
+		FinishedAt:         time.Now(),
+		AccountsChecked:    3,
+		TransfersChecked:   2,
+		AccountDrifts:      1,
+		TransferMismatches: 0,
+		Report:             json.RawMessage(`{"account_drifts": [{"account_id": 1}]}`),
+		Signature:          "deadbeef",
+	}
+
+	report, err := testQueries.CreateReconciliationReport(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, report.ID)
+	require.Equal(t, arg.AccountsChecked, report.AccountsChecked)
+	require.Equal(t, arg.AccountDrifts, report.AccountDrifts)
+	// json keeps the bytes that were signed
+	require.Equal(t, string(arg.Report), string(report.Report))
+	require.Equal(t, arg.Signature, report.Signature)
+
+	got, err := testQueries.GetReconciliationReport(context.Background(), report.ID)
+	require.NoError(t, err)
+	require.Equal(t, report.ID, got.ID)
+	require.Equal(t, string(report.Report), string(got.Report))
+}
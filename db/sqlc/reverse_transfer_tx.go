@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+var (
+	// ErrReversalExceedsTransfer is returned when a refund or reversal would return more than is left of the transfer
+	ErrReversalExceedsTransfer = errors.New("reversal exceeds the amount left on the transfer")
+	// ErrTransferIsReversal is returned when reversing a transfer that is itself a reversal or refund
+	ErrTransferIsReversal = errors.New("transfer is itself a reversal")
+)
+
+type ReverseTransferTxParams struct {
+	TransferID int64 `json:"transfer_id"`
+	// Amount to send back, in the to account's currency. Zero reverses everything that is left
+	Amount int64 `json:"amount"`
+	// CheckFunds holds the to account to its overdraft limit, privileged reversals skip it
+	CheckFunds bool `json:"check_funds"`
+}
+
+// ReverseTransferTx creates a compensating transfer from the original's to account back to its from account,
+// linked through reverses_transfer_id. Money goes back at the original rate, so reversing the whole
+// transfer returns exactly the original amount. The original is locked while the reversals made so far
+// are summed, so concurrent refunds can't return more than it moved
+func (store *SQLStore) ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		original, err := q.GetTransferForUpdate(ctx, arg.TransferID)
+		if err != nil {
+			return err
+		}
+		if original.Status != TransferSettled {
+			return fmt.Errorf("%w: transfer [%d] is %s", ErrTransferNotSettled, original.ID, original.Status)
+		}
+		if original.ReversesTransferID.Valid {
+			return fmt.Errorf("%w: transfer [%d] reverses transfer [%d]", ErrTransferIsReversal, original.ID, original.ReversesTransferID.Int64)
+		}
+
+		reversed, err := q.GetTransferReversedAmounts(ctx, sql.NullInt64{Int64: original.ID, Valid: true})
+		if err != nil {
+			return err
+		}
+		left := original.ToAmount - reversed.ReversedAmount
+		amount := arg.Amount
+		if amount == 0 {
+			amount = left
+		}
+		if amount <= 0 || amount > left {
+			return fmt.Errorf("%w: transfer [%d] has %d left, asked for %d", ErrReversalExceedsTransfer, original.ID, left, amount)
+		}
+		// the last reversal returns whatever rounding left over
+		returned := original.Amount - reversed.ReturnedAmount
+		if amount < left {
+			returned, err = returnedAmount(original, amount)
+			if err != nil {
+				return err
+			}
+		}
+		if returned <= 0 {
+			return fmt.Errorf("%w: %d of transfer [%d] returns nothing", ErrAmountNotConvertible, amount, original.ID)
+		}
+
+		// money flows back, from the original to account to the original from account
+		fromAccount, _, err := lockAccounts(ctx, q, original.ToAccountID, original.FromAccountID)
+		if err != nil {
+			return err
+		}
+		if arg.CheckFunds {
+			if err := checkFunds(fromAccount, amount); err != nil {
+				return err
+			}
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID:      original.ToAccountID,
+			ToAccountID:        original.FromAccountID,
+			Amount:             amount,
+			ToAmount:           returned,
+			ExchangeRate:       reverseRate(original),
+			ReversesTransferID: sql.NullInt64{Int64: original.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromEntry, err = store.chainEntry(ctx, q, CreateEntryParams{
+			AccountID:  original.ToAccountID,
+			Amount:     -amount,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		result.ToEntry, err = store.chainEntry(ctx, q, CreateEntryParams{
+			AccountID:  original.FromAccountID,
+			Amount:     returned,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if original.ToAccountID < original.FromAccountID {
+			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, original.ToAccountID, -amount, original.FromAccountID, returned)
+		} else {
+			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, original.FromAccountID, returned, original.ToAccountID, -amount)
+		}
+		return err
+	})
+	return result, err
+}
+
+// returnedAmount is what sending back amount of original returns at original's rate, rounded down.
+// The product is taken in big.Int, amount times original.Amount overflows int64 for large transfers
+func returnedAmount(original Transfer, amount int64) (int64, error) {
+	returned := new(big.Int).Mul(big.NewInt(amount), big.NewInt(original.Amount))
+	returned.Quo(returned, big.NewInt(original.ToAmount))
+	if !returned.IsInt64() {
+		return 0, fmt.Errorf("%w: %d of transfer [%d] overflows", ErrAmountNotConvertible, amount, original.ID)
+	}
+	return returned.Int64(), nil
+}
+
+// reverseRate is the rate a reversal of original converts at, the inverse of the rate original used
+func reverseRate(original Transfer) string {
+	if original.Amount == original.ToAmount {
+		return "1"
+	}
+	return big.NewRat(original.Amount, original.ToAmount).FloatString(10)
+}
@@ -0,0 +1,211 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseTransferTxRefunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	// partial refund
+	refund, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     300,
+		CheckFunds: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.ID, refund.Transfer.FromAccountID)
+	require.Equal(t, account1.ID, refund.Transfer.ToAccountID)
+	require.Equal(t, int64(300), refund.Transfer.Amount)
+	require.Equal(t, int64(300), refund.Transfer.ToAmount)
+	require.Equal(t, sql.NullInt64{Int64: original.Transfer.ID, Valid: true}, refund.Transfer.ReversesTransferID)
+	require.Equal(t, int64(-300), refund.FromEntry.Amount)
+	require.Equal(t, int64(300), refund.ToEntry.Amount)
+	require.Equal(t, original.ToAccount.Balance-300, refund.FromAccount.Balance)
+	require.Equal(t, original.FromAccount.Balance+300, refund.ToAccount.Balance)
+
+	// only 700 is left
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     701,
+		CheckFunds: true,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+
+	// a refund can't be refunded in turn
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: refund.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrTransferIsReversal)
+
+	// the rest
+	rest, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		CheckFunds: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(700), rest.Transfer.Amount)
+	require.Equal(t, account1.Balance, rest.ToAccount.Balance)
+	require.Equal(t, account2.Balance, rest.FromAccount.Balance)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+}
+
+func TestReverseTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccountWithCurrency(t, util.USD))
+	account2 := createRandomAccountWithCurrency(t, util.EUR)
+	_, err := store.CreateExchangeRate(context.Background(), CreateExchangeRateParams{
+		FromCurrency:  util.USD,
+		ToCurrency:    util.EUR,
+		Rate:          "0.9250000000",
+		EffectiveFrom: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1001,
+	})
+	require.NoError(t, err)
+
+	// refunds are in the recipient's currency and go back at the original rate
+	refund, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		Amount:     100,
+		CheckFunds: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), refund.Transfer.Amount)
+	require.Equal(t, 100*original.Transfer.Amount/original.Transfer.ToAmount, refund.Transfer.ToAmount)
+
+	// whatever rounding held back comes back with the rest
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestReverseTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	account3 := createRandomAccountWithCurrency(t, account1.Currency)
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	// the recipient spends everything
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account3.ID,
+		Amount:        original.ToAccount.Balance,
+	})
+	require.NoError(t, err)
+
+	_, err = store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+		CheckFunds: true,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// a privileged reversal goes through regardless
+	reversal, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+		TransferID: original.Transfer.ID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-1000), reversal.FromAccount.Balance)
+	require.Equal(t, account1.Balance, reversal.ToAccount.Balance)
+}
+
+func TestReverseTransferTxConcurrentRefunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	original, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	n := 5
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{
+				TransferID: original.Transfer.ID,
+				Amount:     300,
+				CheckFunds: true,
+			})
+			errs <- err
+		}()
+	}
+
+	refunded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			refunded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrReversalExceedsTransfer)
+	}
+	require.Equal(t, 3, refunded)
+
+	reversed, err := store.GetTransferReversedAmounts(context.Background(), sql.NullInt64{Int64: original.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(900), reversed.ReversedAmount)
+}
+
+func TestReturnedAmount(t *testing.T) {
+	// 4e9 times 5e9 doesn't fit in int64
+	returned, err := returnedAmount(Transfer{Amount: 5_000_000_000, ToAmount: 4_600_000_000}, 4_000_000_000)
+	require.NoError(t, err)
+	require.Equal(t, int64(4_347_826_086), returned)
+
+	returned, err = returnedAmount(Transfer{Amount: math.MaxInt64, ToAmount: math.MaxInt64 - 1}, math.MaxInt64-2)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64-2), returned)
+
+	returned, err = returnedAmount(Transfer{Amount: 10, ToAmount: 3}, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), returned)
+
+	// a rate the transfers themselves can't produce, but the result still has to fit
+	_, err = returnedAmount(Transfer{Amount: math.MaxInt64, ToAmount: 1}, 2)
+	require.ErrorIs(t, err, ErrAmountNotConvertible)
+}
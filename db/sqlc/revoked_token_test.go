@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeToken(t *testing.T) {
+	user := createRandomUser(t)
+	arg := RevokeTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       arg.ID,
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = testQueries.RevokeToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	// revoking twice is a no-op
+	err = testQueries.RevokeToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	revoked, err = testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       arg.ID,
+		Username: user.Username,
+		IssuedAt: time.Now(),
+	})
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestRevokeUserTokens(t *testing.T) {
+	user1 := createRandomUser(t)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	user2, err := testQueries.RevokeUserTokens(context.Background(), user1.Username)
+	require.NoError(t, err)
+	require.True(t, user2.TokensRevokedAt.After(issuedAt))
+
+	revoked, err := testQueries.IsTokenRevoked(context.Background(), IsTokenRevokedParams{
+		ID:       uuid.New(),
+		Username: user1.Username,
+		IssuedAt: issuedAt,
+	})
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestDeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+	err := testQueries.RevokeToken(context.Background(), RevokeTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	deleted, err := testQueries.DeleteExpiredRevokedTokens(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+}
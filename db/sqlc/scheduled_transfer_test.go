@@ -0,0 +1,157 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomScheduledTransfer(t *testing.T, fromAccount Account, toAccount Account, amount int64, executeAt time.Time) ScheduledTransfer {
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		ExecuteAt:     executeAt,
+	}
+
+	scheduled, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, scheduled)
+
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.WithinDuration(t, arg.ExecuteAt, scheduled.ExecuteAt, time.Second)
+	require.Equal(t, ScheduledTransferPending, scheduled.Status)
+	require.False(t, scheduled.TransferID.Valid)
+	require.False(t, scheduled.ExecutedAt.Valid)
+
+	require.NotZero(t, scheduled.ID)
+	require.NotZero(t, scheduled.CreatedAt)
+	return scheduled
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	scheduled := createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(time.Hour))
+
+	cancelled, err := testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCancelled, cancelled.Status)
+
+	// only pending transfers can be cancelled
+	_, err = testQueries.CancelScheduledTransfer(context.Background(), scheduled.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+// executeScheduledTransfer runs due scheduled transfers until the given one has been executed
+func executeScheduledTransfer(t *testing.T, store Store, id int64) ScheduledTransfer {
+	for {
+		_, err := store.ExecuteScheduledTransferTx(context.Background())
+		if err == sql.ErrNoRows {
+			break
+		}
+		require.NoError(t, err)
+		scheduled, err := store.GetScheduledTransfer(context.Background(), id)
+		require.NoError(t, err)
+		if scheduled.Status != ScheduledTransferPending {
+			return scheduled
+		}
+	}
+	scheduled, err := store.GetScheduledTransfer(context.Background(), id)
+	require.NoError(t, err)
+	return scheduled
+}
+
+func TestExecuteScheduledTransferTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	notDue := createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(time.Hour))
+	due := createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(-time.Second))
+
+	executed := executeScheduledTransfer(t, store, due.ID)
+	require.Equal(t, ScheduledTransferCompleted, executed.Status)
+	require.True(t, executed.TransferID.Valid)
+	require.True(t, executed.ExecutedAt.Valid)
+	require.False(t, executed.FailureReason.Valid)
+
+	transfer, err := store.GetTransfer(context.Background(), executed.TransferID.Int64)
+	require.NoError(t, err)
+	require.Equal(t, due.Amount, transfer.Amount)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-due.Amount, updatedAccount1.Balance)
+
+	notDue, err = store.GetScheduledTransfer(context.Background(), notDue.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferPending, notDue.Status)
+}
+
+func TestExecuteScheduledTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	due := createRandomScheduledTransfer(t, account1, account2, account1.Balance+1, time.Now().Add(-time.Second))
+
+	executed := executeScheduledTransfer(t, store, due.ID)
+	require.Equal(t, ScheduledTransferFailed, executed.Status)
+	require.False(t, executed.TransferID.Valid)
+	require.True(t, executed.FailureReason.Valid)
+	require.Contains(t, executed.FailureReason.String, ErrInsufficientFunds.Error())
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestExecuteScheduledTransferTxLedgerError(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	// crediting a full account overflows its balance, which no retry can fix
+	account2, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account2.ID,
+		Balance: math.MaxInt64,
+	})
+	require.NoError(t, err)
+	broken := createRandomScheduledTransfer(t, account1, account2, 10, time.Now().Add(-time.Minute))
+	behind := createRandomScheduledTransfer(t, account1, fundAccount(t, createRandomAccountWithCurrency(t, account1.Currency)), 10, time.Now().Add(-time.Second))
+
+	executed := executeScheduledTransfer(t, store, broken.ID)
+	require.Equal(t, ScheduledTransferFailed, executed.Status)
+	require.False(t, executed.TransferID.Valid)
+	require.True(t, executed.FailureReason.Valid)
+
+	// the row behind it isn't held up
+	executed = executeScheduledTransfer(t, store, behind.ID)
+	require.Equal(t, ScheduledTransferCompleted, executed.Status)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-behind.Amount, updatedAccount1.Balance)
+}
+
+func TestIsTransientError(t *testing.T) {
+	require.True(t, isTransientError(context.Canceled))
+	require.True(t, isTransientError(fmt.Errorf("transfer: %w", sql.ErrConnDone)))
+	require.True(t, isTransientError(&pq.Error{Code: "40P01"}))
+	require.True(t, isTransientError(&pq.Error{Code: "57P01"}))
+
+	require.False(t, isTransientError(&pq.Error{Code: "22003"}))
+	require.False(t, isTransientError(&pq.Error{Code: "23514"}))
+	require.False(t, isTransientError(ErrInsufficientFunds))
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/joekings2k/gobank/logging"
+	"github.com/lib/pq"
+)
+
+const (
+	ScheduledTransferPending   = "pending"
+	ScheduledTransferCompleted = "completed"
+	ScheduledTransferFailed    = "failed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+type ExecuteScheduledTransferTxResult struct {
+	ScheduledTransfer ScheduledTransfer `json:"scheduled_transfer"`
+	// Transfer is set when the scheduled transfer completed
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// Failure is why the ledger refused the transfer, set when the scheduled transfer was recorded as failed
+	Failure error `json:"-"`
+}
+
+// ExecuteScheduledTransferTx claims one due scheduled transfer, skipping rows other workers hold,
+// and runs it. A transfer that fails for good is recorded as failed with the reason, transient errors
+// leave it pending to be retried. It returns sql.ErrNoRows when nothing is due
+func (store *SQLStore) ExecuteScheduledTransferTx(ctx context.Context) (ExecuteScheduledTransferTxResult, error) {
+	var result ExecuteScheduledTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		scheduled, err := q.GetDueScheduledTransferForUpdate(ctx)
+		if err != nil {
+			return err
+		}
+
+		transferResult, failure, err := store.tryTransfer(ctx, q, TransferTxParams{
+			FromAccountID: scheduled.FromAccountID,
+			ToAccountID:   scheduled.ToAccountID,
+			Amount:        scheduled.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if failure != nil {
+			result.Failure = failure
+			result.ScheduledTransfer, err = q.FailScheduledTransfer(ctx, FailScheduledTransferParams{
+				ID:            scheduled.ID,
+				FailureReason: sql.NullString{String: failureReason(failure), Valid: true},
+			})
+			return err
+		}
+
+		result.Transfer = &transferResult
+		result.ScheduledTransfer, err = q.CompleteScheduledTransfer(ctx, CompleteScheduledTransferParams{
+			ID:         scheduled.ID,
+			TransferID: sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true},
+		})
+		return err
+	})
+	return result, err
+}
+
+// tryTransfer runs a transfer for a worker under a savepoint. When the transfer fails for good, on a business
+// rule or on data the ledger refuses, the savepoint is rolled back and the error is returned as failure,
+// for the caller to record in the same transaction. Otherwise the row would stay due and be picked first
+// again on every tick, holding up the rows behind it. Transient errors are returned as err, to roll back
+// the whole transaction and leave the row to the next tick
+func (store *SQLStore) tryTransfer(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, failure error, err error) {
+	if _, err := q.db.ExecContext(ctx, "SAVEPOINT worker_transfer"); err != nil {
+		return result, nil, err
+	}
+	result, err = store.transfer(ctx, q, arg)
+	if err == nil {
+		return result, nil, nil
+	}
+	if isTransientError(err) {
+		return result, nil, err
+	}
+	if _, rbErr := q.db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT worker_transfer"); rbErr != nil {
+		return result, nil, fmt.Errorf("transfer err: %w, rb err: %v", err, rbErr)
+	}
+	if !isTransferRejection(err) {
+		logging.FromContext(ctx).WarnContext(ctx, "ledger refused transfer", "error", err)
+	}
+	return TransferTxResult{}, err, nil
+}
+
+// isTransferRejection reports whether a transfer failed on a business rule rather than an infrastructure error
+func isTransferRejection(err error) bool {
+	return errors.Is(err, ErrInsufficientFunds) || errors.Is(err, ErrNoExchangeRate) || errors.Is(err, ErrAmountNotConvertible) ||
+		errors.Is(err, sql.ErrNoRows)
+}
+
+// failureReason is the reason recorded for a transfer that failed. Rejections explain themselves,
+// other errors come from the database and are only logged
+func failureReason(err error) string {
+	if isTransferRejection(err) {
+		return err.Error()
+	}
+	return "the ledger refused the transfer"
+}
+
+// isTransientError reports whether err may go away on its own: a lost connection, a cancelled context,
+// or Postgres aborting the transaction or running short of resources
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, sql.ErrConnDone) || errors.Is(err, sql.ErrTxDone) || errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		// connection exception, transaction rollback, insufficient resources, operator intervention, system error
+		case "08", "40", "53", "57", "58":
+			return true
+		}
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomStandingOrder(t *testing.T, fromAccount Account, toAccount Account, amount int64, startAt time.Time, maxOccurrences int32) StandingOrder {
+	arg := CreateStandingOrderParams{
+		Owner:          fromAccount.Owner,
+		FromAccountID:  fromAccount.ID,
+		ToAccountID:    toAccount.ID,
+		Amount:         amount,
+		Recurrence:     "FREQ=DAILY;INTERVAL=1",
+		StartAt:        startAt,
+		MaxOccurrences: sql.NullInt32{Int32: maxOccurrences, Valid: maxOccurrences > 0},
+	}
+
+	order, err := testQueries.CreateStandingOrder(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, order)
+
+	require.Equal(t, arg.Owner, order.Owner)
+	require.Equal(t, arg.FromAccountID, order.FromAccountID)
+	require.Equal(t, arg.ToAccountID, order.ToAccountID)
+	require.Equal(t, arg.Amount, order.Amount)
+	require.Equal(t, arg.Recurrence, order.Recurrence)
+	require.WithinDuration(t, arg.StartAt, order.StartAt, time.Second)
+	require.Equal(t, arg.MaxOccurrences, order.MaxOccurrences)
+	require.Equal(t, StandingOrderActive, order.Status)
+	require.Zero(t, order.Occurrences)
+	// the first occurrence runs at the start
+	require.True(t, order.NextRunAt.Valid)
+	require.WithinDuration(t, arg.StartAt, order.NextRunAt.Time, time.Second)
+
+	require.NotZero(t, order.ID)
+	require.NotZero(t, order.CreatedAt)
+	return order
+}
+
+func TestCancelStandingOrder(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	order := createRandomStandingOrder(t, account1, account2, 10, time.Now().Add(time.Hour), 0)
+
+	cancelled, err := testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.NoError(t, err)
+	require.Equal(t, StandingOrderCancelled, cancelled.Status)
+	require.False(t, cancelled.NextRunAt.Valid)
+
+	// only active orders can be cancelled
+	_, err = testQueries.CancelStandingOrder(context.Background(), order.ID)
+	require.EqualError(t, err, sql.ErrNoRows.Error())
+}
+
+// executeStandingOrder runs due standing orders until the given one has no occurrence due
+func executeStandingOrder(t *testing.T, store Store, id int64) StandingOrder {
+	for {
+		order, err := store.GetStandingOrder(context.Background(), id)
+		require.NoError(t, err)
+		if order.Status != StandingOrderActive || order.NextRunAt.Time.After(time.Now()) {
+			return order
+		}
+		_, err = store.ExecuteStandingOrderTx(context.Background())
+		require.NoError(t, err)
+	}
+}
+
+func TestExecuteStandingOrderTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	// three daily occurrences, the last one a day ago, so all of them are due.
+	// Only the first can be funded
+	startAt := time.Now().AddDate(0, 0, -2).Add(-time.Minute)
+	amount := account1.Balance/2 + 1
+	order := createRandomStandingOrder(t, account1, account2, amount, startAt, 3)
+
+	executed := executeStandingOrder(t, store, order.ID)
+	require.Equal(t, StandingOrderCompleted, executed.Status)
+	require.Equal(t, int32(3), executed.Occurrences)
+	require.False(t, executed.NextRunAt.Valid)
+
+	executions, err := store.ListStandingOrderExecutions(context.Background(), ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, executions, 3)
+
+	// newest first
+	for i, execution := range executions {
+		occurrence := int32(3 - i)
+		require.Equal(t, occurrence, execution.Occurrence)
+		require.WithinDuration(t, startAt.AddDate(0, 0, int(occurrence-1)), execution.ScheduledFor, time.Second)
+	}
+	require.Equal(t, ExecutionCompleted, executions[2].Status)
+	require.True(t, executions[2].TransferID.Valid)
+	for _, execution := range executions[:2] {
+		require.Equal(t, ExecutionSkipped, execution.Status)
+		require.False(t, execution.TransferID.Valid)
+		require.Contains(t, execution.FailureReason.String, ErrInsufficientFunds.Error())
+	}
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-amount, updatedAccount1.Balance)
+}
+
+func TestExecuteStandingOrderTxNotDue(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	// one occurrence due now, the next tomorrow
+	order := createRandomStandingOrder(t, account1, account2, 10, time.Now().Add(-time.Minute), 0)
+
+	executed := executeStandingOrder(t, store, order.ID)
+	require.Equal(t, StandingOrderActive, executed.Status)
+	require.Equal(t, int32(1), executed.Occurrences)
+	require.True(t, executed.NextRunAt.Valid)
+	require.WithinDuration(t, order.StartAt.AddDate(0, 0, 1), executed.NextRunAt.Time, time.Second)
+}
+
+func TestExecuteStandingOrderTxLedgerError(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	// crediting a full account overflows its balance, which no retry can fix
+	_, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account2.ID,
+		Balance: math.MaxInt64,
+	})
+	require.NoError(t, err)
+	order := createRandomStandingOrder(t, account1, account2, 10, time.Now().Add(-time.Minute), 0)
+
+	// the occurrence is recorded as failed and the series moves on, instead of staying due
+	executed := executeStandingOrder(t, store, order.ID)
+	require.Equal(t, StandingOrderActive, executed.Status)
+	require.Equal(t, int32(1), executed.Occurrences)
+
+	executions, err := store.ListStandingOrderExecutions(context.Background(), ListStandingOrderExecutionsParams{
+		StandingOrderID: order.ID,
+		Limit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, executions, 1)
+	require.Equal(t, ExecutionFailed, executions[0].Status)
+	require.False(t, executions[0].TransferID.Valid)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestNextOccurrence(t *testing.T) {
+	recurrence, err := util.ParseRecurrence("FREQ=MONTHLY")
+	require.NoError(t, err)
+	startAt := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	order := StandingOrder{ID: 1, StartAt: startAt, Occurrences: 0}
+	arg := nextOccurrence(order, recurrence)
+	require.Equal(t, StandingOrderActive, arg.Status)
+	require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), arg.NextRunAt.Time)
+
+	// the series keeps the start day after a short month
+	order.Occurrences = 1
+	arg = nextOccurrence(order, recurrence)
+	require.Equal(t, time.Date(2024, time.March, 31, 9, 0, 0, 0, time.UTC), arg.NextRunAt.Time)
+
+	order.MaxOccurrences = sql.NullInt32{Int32: 2, Valid: true}
+	arg = nextOccurrence(order, recurrence)
+	require.Equal(t, StandingOrderCompleted, arg.Status)
+	require.False(t, arg.NextRunAt.Valid)
+
+	order.MaxOccurrences = sql.NullInt32{}
+	order.EndAt = sql.NullTime{Time: time.Date(2024, time.March, 30, 0, 0, 0, 0, time.UTC), Valid: true}
+	arg = nextOccurrence(order, recurrence)
+	require.Equal(t, StandingOrderCompleted, arg.Status)
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/joekings2k/gobank/util"
+)
+
+const (
+	StandingOrderActive    = "active"
+	StandingOrderCompleted = "completed"
+	StandingOrderCancelled = "cancelled"
+)
+
+const (
+	ExecutionCompleted = "completed"
+	ExecutionSkipped   = "skipped"
+	ExecutionFailed    = "failed"
+)
+
+type ExecuteStandingOrderTxResult struct {
+	StandingOrder StandingOrder          `json:"standing_order"`
+	Execution     StandingOrderExecution `json:"execution"`
+	// Transfer is set when the occurrence completed
+	Transfer *TransferTxResult `json:"transfer,omitempty"`
+	// Failure is why the ledger refused the occurrence's transfer, set when it was recorded as skipped or failed
+	Failure error `json:"-"`
+}
+
+// ExecuteStandingOrderTx claims one standing order with a due occurrence, skipping rows other workers hold,
+// runs the occurrence and moves the order on to the next one. An occurrence the account can't fund is
+// recorded as skipped and other failures as failed; either way the series carries on. An order whose
+// recurrence can't be read is cancelled, as it has no next occurrence. Transient errors leave the order
+// due to be retried. It returns sql.ErrNoRows when nothing is due
+func (store *SQLStore) ExecuteStandingOrderTx(ctx context.Context) (ExecuteStandingOrderTxResult, error) {
+	var result ExecuteStandingOrderTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		order, err := q.GetDueStandingOrderForUpdate(ctx)
+		if err != nil {
+			return err
+		}
+		execution := CreateStandingOrderExecutionParams{
+			StandingOrderID: order.ID,
+			Occurrence:      order.Occurrences + 1,
+			ScheduledFor:    order.NextRunAt.Time,
+			Status:          ExecutionCompleted,
+		}
+		recurrence, err := util.ParseRecurrence(order.Recurrence)
+		if err != nil {
+			execution.Status = ExecutionFailed
+			execution.FailureReason = sql.NullString{String: err.Error(), Valid: true}
+			result.Execution, err = q.CreateStandingOrderExecution(ctx, execution)
+			if err != nil {
+				return err
+			}
+			result.StandingOrder, err = q.AdvanceStandingOrder(ctx, AdvanceStandingOrderParams{
+				ID:     order.ID,
+				Status: StandingOrderCancelled,
+			})
+			return err
+		}
+
+		transferResult, failure, err := store.tryTransfer(ctx, q, TransferTxParams{
+			FromAccountID: order.FromAccountID,
+			ToAccountID:   order.ToAccountID,
+			Amount:        order.Amount,
+		})
+		if err != nil {
+			return err
+		}
+		if failure != nil {
+			result.Failure = failure
+			execution.Status = ExecutionFailed
+			if errors.Is(failure, ErrInsufficientFunds) {
+				execution.Status = ExecutionSkipped
+			}
+			execution.FailureReason = sql.NullString{String: failureReason(failure), Valid: true}
+		} else {
+			result.Transfer = &transferResult
+			execution.TransferID = sql.NullInt64{Int64: transferResult.Transfer.ID, Valid: true}
+		}
+
+		result.Execution, err = q.CreateStandingOrderExecution(ctx, execution)
+		if err != nil {
+			return err
+		}
+
+		result.StandingOrder, err = q.AdvanceStandingOrder(ctx, nextOccurrence(order, recurrence))
+		return err
+	})
+	return result, err
+}
+
+// nextOccurrence returns the update moving order past its current occurrence,
+// completing it once the end date or the number of occurrences is reached
+func nextOccurrence(order StandingOrder, recurrence util.Recurrence) AdvanceStandingOrderParams {
+	arg := AdvanceStandingOrderParams{
+		ID:     order.ID,
+		Status: StandingOrderActive,
+	}
+	occurrences := order.Occurrences + 1
+	// occurrences are always counted from the start, so monthly series don't drift on short months
+	next := recurrence.Occurrence(order.StartAt, int(occurrences))
+	if (order.MaxOccurrences.Valid && occurrences >= order.MaxOccurrences.Int32) ||
+		(order.EndAt.Valid && next.After(order.EndAt.Time)) {
+		arg.Status = StandingOrderCompleted
+		return arg
+	}
+	arg.NextRunAt = sql.NullTime{Time: next, Valid: true}
+	return arg
+}
@@ -3,48 +3,117 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/joekings2k/gobank/util"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned when another live request already holds the idempotency key
+	ErrIdempotencyKeyInUse = errors.New("idempotency key is already in use")
+	// ErrInsufficientFunds is returned when a debit would take an account past its overdraft limit
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrNoExchangeRate is returned when a cross-currency transfer has no effective rate to convert with
+	ErrNoExchangeRate = errors.New("no exchange rate available")
+	// ErrAmountNotConvertible is returned when an amount converts to nothing or to more than an amount can hold
+	ErrAmountNotConvertible = errors.New("amount can't be converted, it rounds to zero or overflows")
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams ) (TransferTxResult,error)
+	AdjustBalanceTx(ctx context.Context, arg AdjustBalanceTxParams) (AdjustBalanceTxResult, error)
+	DeleteAccountTx(ctx context.Context, accountID int64) error
+	CreateExchangeRatesTx(ctx context.Context, arg []CreateExchangeRateParams) ([]ExchangeRate, error)
+	ExecuteScheduledTransferTx(ctx context.Context) (ExecuteScheduledTransferTxResult, error)
+	ExecuteStandingOrderTx(ctx context.Context) (ExecuteStandingOrderTxResult, error)
+	ReverseTransferTx(ctx context.Context, arg ReverseTransferTxParams) (TransferTxResult, error)
+	AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (TransferHoldTxResult, error)
+	CaptureTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error)
+	VoidTransferTx(ctx context.Context, transferID int64) (TransferHoldTxResult, error)
+	ExpireTransferHoldsTx(ctx context.Context) (int64, error)
+	CreatePaymentBatchTx(ctx context.Context, arg CreatePaymentBatchTxParams) (CreatePaymentBatchTxResult, error)
+	ExecutePaymentBatchItemTx(ctx context.Context) (ExecutePaymentBatchItemTxResult, error)
+	Ping(ctx context.Context) error
+	GetMigrationVersion(ctx context.Context) (MigrationVersion, error)
+	ReadTx(ctx context.Context, fn func(Querier) error) error
 }
 
 type SQLStore struct {
 	*Queries
 	db *sql.DB
+	// entryKey keys the hashes that chain each account's entries
+	entryKey string
 }
 
 
-func NewStore(db *sql.DB) Store{
+func NewStore(db *sql.DB, entryKey string) Store{
 	return &SQLStore{
 		db: db,
 		Queries: New(db),
+		entryKey: entryKey,
 	}
 }
 
 
+// execTx runs fn in a transaction, rolled back when fn fails
 func (store *SQLStore)execTx(ctx context.Context ,fn func(*Queries)error)error{
-	tx,err := store.db.BeginTx(ctx,nil)
+	ctx, span := startTxSpan(ctx)
+	err := store.runTx(ctx,span,nil,fn)
+	endTxSpan(span,err)
+	return err
+}
+
+// ReadTx runs fn in a read-only repeatable read transaction, so every query fn makes reads
+// the same snapshot however many transactions commit meanwhile
+func (store *SQLStore) ReadTx(ctx context.Context, fn func(Querier) error) error {
+	ctx, span := startTxSpan(ctx)
+	err := store.runTx(ctx, span, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, func(q *Queries) error {
+		return fn(q)
+	})
+	endTxSpan(span, err)
+	return err
+}
+
+func (store *SQLStore)runTx(ctx context.Context ,span trace.Span ,opts *sql.TxOptions ,fn func(*Queries)error)error{
+	tx,err := store.db.BeginTx(ctx,opts)
 	if err !=nil{
 		return err
 	}
-	q := New(tx)
+	q := New(tracedTx{tx: tx, span: span})
 	err =fn(q)
 	if err != nil{
+		traceRollback(ctx,err)
 		if rbErr := tx.Rollback(); rbErr !=nil{
-			return fmt.Errorf("tx err: %v, rb err:%v", err ,rbErr)
+			return fmt.Errorf("tx err: %w, rb err:%v", err ,rbErr)
 		}
 		return err
 	}
-	return tx.Commit()
+	err = tx.Commit()
+	if err != nil {
+		traceRollback(ctx,err)
+	}
+	return err
 }
 
 type TransferTxParams struct{
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID int64 `json:"to_account_id"`
 	Amount int64 `json:"amount"`
+	// IdempotencyKey, when set, is claimed and stored with the result in the same transaction as the transfer
+	IdempotencyKey *IdempotencyKeyParams `json:"-"`
+}
+
+type IdempotencyKeyParams struct {
+	Username string
+	Key string
+	RequestHash string
+	TTL time.Duration
 }
 
 type TransferTxResult struct{
@@ -61,50 +130,112 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams ) (T
 
 	err := store.execTx(ctx,func(q *Queries)error {
 		var err error
-		// txName :=ctx.Value(txKey)
-		// fmt.Println(txName,"Create transfer")
-		result.Transfer,err = q.CreateTransfer(ctx,CreateTransferParams(arg))
-		if err !=nil{
-			return err
-		}
-	
-		result.FromEntry, err= q.CreateEntry(ctx,CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount: -arg.Amount,
-		})
-		if err != nil{
-			return  err
-		}
-	
-		result.ToEntry, err= q.CreateEntry(ctx,CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount: arg.Amount,
+		result, err = store.transfer(ctx, q, arg)
+		return err
+	})
+	return result,err
+} 
+
+// transfer runs a transfer with q, so it can be part of a bigger transaction
+func (store *SQLStore) transfer(ctx context.Context, q *Queries, arg TransferTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
+	// txName :=ctx.Value(txKey)
+	// fmt.Println(txName,"Create transfer")
+	if arg.IdempotencyKey != nil {
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username: arg.IdempotencyKey.Username,
+			Key: arg.IdempotencyKey.Key,
+			RequestHash: arg.IdempotencyKey.RequestHash,
+			ExpiresAt: time.Now().Add(arg.IdempotencyKey.TTL),
 		})
-		if err != nil{
-			return  err
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return result, ErrIdempotencyKeyInUse
+			}
+			return result, err
 		}
+	}
+
+	// lock both accounts, lower id first, before reading the balance
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return result, err
+	}
+	if err := checkFunds(fromAccount, arg.Amount); err != nil {
+		return result, err
+	}
+
+	// the to account is credited in its own currency
+	toAmount, rate, err := convert(ctx, q, fromAccount.Currency, toAccount.Currency, arg.Amount)
+	if err != nil {
+		return result, err
+	}
 
-		// update accounts balance
+	result.Transfer,err = q.CreateTransfer(ctx,CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount: arg.Amount,
+		ToAmount: toAmount,
+		ExchangeRate: rate,
+	})
+	if err !=nil{
+		return result, err
+	}
 
-		//getAccount from DB update its balance
-		
-		
-		if arg.FromAccountID< arg.ToAccountID  { //let the lower id update first
-		result.FromAccount, result.ToAccount,err =addMoney(ctx,q,arg.FromAccountID,-arg.Amount,arg.ToAccountID,arg.Amount)
+	result.FromEntry, err= store.chainEntry(ctx, q, CreateEntryParams{
+		AccountID: arg.FromAccountID,
+		Amount: -arg.Amount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil{
+		return result, err
+	}
+
+	result.ToEntry, err= store.chainEntry(ctx, q, CreateEntryParams{
+		AccountID: arg.ToAccountID,
+		Amount: toAmount,
+		TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+	})
+	if err != nil{
+		return result, err
+	}
+
+	// update accounts balance
+
+	//getAccount from DB update its balance
+	
+	
+	if arg.FromAccountID< arg.ToAccountID  { //let the lower id update first
+		result.FromAccount, result.ToAccount,err =addMoney(ctx,q,arg.FromAccountID,-arg.Amount,arg.ToAccountID,toAmount)
 		if err != nil {
-			return err
+			return result, err
 		}
 	}else {
-		result.ToAccount, result.FromAccount,err =addMoney(ctx,q,arg.ToAccountID,arg.Amount,arg.FromAccountID,-arg.Amount)
+		result.ToAccount, result.FromAccount,err =addMoney(ctx,q,arg.ToAccountID,toAmount,arg.FromAccountID,-arg.Amount)
 		if err != nil {
-			return err
+			return result, err
 		}
 	}
 
-		return nil
-	})
-	return result,err
-} 
+	if arg.IdempotencyKey != nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			return result, err
+		}
+		_, err = q.SetIdempotencyKeyResponse(ctx, SetIdempotencyKeyResponseParams{
+			ResponseStatus: http.StatusOK,
+			ResponseBody: body,
+			Username: arg.IdempotencyKey.Username,
+			Key: arg.IdempotencyKey.Key,
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
 
 
 func addMoney (ctx context.Context ,q *Queries ,accountID1 int64, amount1 int64, accountID2 int64, amount2 int64) (account1 Account, account2 Account,err error) {
@@ -120,4 +251,59 @@ func addMoney (ctx context.Context ,q *Queries ,accountID1 int64, amount1 int64,
 		Amount: amount2,
 	})
 	return
-}
\ No newline at end of file
+}
+
+// checkFunds makes sure debiting amount keeps what is available on account within its overdraft limit.
+// Funds held by authorized transfers aren't available
+func checkFunds(account Account, amount int64) error {
+	if account.AvailableBalance-amount < -account.OverdraftLimit {
+		return fmt.Errorf("%w: account [%d] available balance %d, overdraft limit %d, amount %d",
+			ErrInsufficientFunds, account.ID, account.AvailableBalance, account.OverdraftLimit, amount)
+	}
+	return nil
+}
+
+// lockAccounts locks fromAccountID and toAccountID for update in id order, so concurrent
+// transfers in opposite directions can't deadlock
+func lockAccounts(ctx context.Context, q *Queries, fromAccountID int64, toAccountID int64) (fromAccount Account, toAccount Account, err error) {
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForupdate(ctx, fromAccountID)
+		if err != nil {
+			return
+		}
+		toAccount, err = q.GetAccountForupdate(ctx, toAccountID)
+		return
+	}
+	toAccount, err = q.GetAccountForupdate(ctx, toAccountID)
+	if err != nil {
+		return
+	}
+	fromAccount, err = q.GetAccountForupdate(ctx, fromAccountID)
+	return
+}
+
+// convert returns amount in toCurrency and the rate used, at the rate currently in effect
+func convert(ctx context.Context, q *Queries, fromCurrency string, toCurrency string, amount int64) (int64, string, error) {
+	if fromCurrency == toCurrency {
+		return amount, "1", nil
+	}
+	exchangeRate, err := q.GetExchangeRate(ctx, GetExchangeRateParams{
+		FromCurrency: fromCurrency,
+		ToCurrency: toCurrency,
+		At: time.Now(),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, "", fmt.Errorf("%w: %s to %s", ErrNoExchangeRate, fromCurrency, toCurrency)
+		}
+		return 0, "", err
+	}
+	converted, err := util.ConvertAmount(amount, exchangeRate.Rate)
+	if err != nil {
+		if errors.Is(err, util.ErrAmountTooSmall) || errors.Is(err, util.ErrAmountOverflow) {
+			return 0, "", fmt.Errorf("%w: %w", ErrAmountNotConvertible, err)
+		}
+		return 0, "", err
+	}
+	return converted, exchangeRate.Rate, nil
+}
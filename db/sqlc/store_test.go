@@ -2,16 +2,30 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"testing"
+	"time"
 
+	"github.com/joekings2k/gobank/util"
 	"github.com/stretchr/testify/require"
 )
+// fundAccount sets a balance large enough for the transfers a test makes
+func fundAccount(t *testing.T, account Account) Account {
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: 10000,
+	})
+	require.NoError(t, err)
+	return account
+}
+
 func TestTransferTx (t *testing.T){
-	store := NewStore(testDB)
+	store := NewStore(testDB, testEntryKey)
 
-	account1:= createRandomAccount(t)
-	account2:= createRandomAccount(t)
+	account1:= fundAccount(t, createRandomAccount(t))
+	account2:= fundAccount(t, createRandomAccountWithCurrency(t, account1.Currency))
 	fmt.Println("@before:", account1.Balance ,account2.Balance)
 	n :=5
 	amount := int64(10)
@@ -108,10 +122,10 @@ func TestTransferTx (t *testing.T){
 }
 
 func TestTransferTxDeadLock (t *testing.T){
-	store := NewStore(testDB)
+	store := NewStore(testDB, testEntryKey)
 
-	account1:= createRandomAccount(t)
-	account2:= createRandomAccount(t)
+	account1:= fundAccount(t, createRandomAccount(t))
+	account2:= fundAccount(t, createRandomAccountWithCurrency(t, account1.Currency))
 	fmt.Println("@before:", account1.Balance ,account2.Balance)
 	n :=10
 	amount := int64(10)
@@ -152,4 +166,163 @@ func TestTransferTxDeadLock (t *testing.T){
 	require.Equal(t,account2.Balance,updateAccount2.Balance)
 
 
-}
\ No newline at end of file
+}
+func TestTransferTxIdempotencyKey(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		IdempotencyKey: &IdempotencyKeyParams{
+			Username:    account1.Owner,
+			Key:         util.RandomString(16),
+			RequestHash: util.RandomString(64),
+			TTL:         time.Minute,
+		},
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyInUse)
+
+	stored, err := store.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: arg.IdempotencyKey.Username,
+		Key:      arg.IdempotencyKey.Key,
+	})
+	require.NoError(t, err)
+	require.Equal(t, arg.IdempotencyKey.RequestHash, stored.RequestHash)
+	require.Equal(t, int32(http.StatusOK), stored.ResponseStatus)
+
+	var storedResult TransferTxResult
+	err = json.Unmarshal(stored.ResponseBody, &storedResult)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, storedResult.Transfer.ID)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	account1, err := testQueries.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 50,
+	})
+	require.NoError(t, err)
+
+	// the overdraft limit can be used up exactly
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 50,
+	})
+	require.NoError(t, err)
+
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(-50), updatedAccount1.Balance)
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccountWithCurrency(t, util.USD))
+	account2 := createRandomAccountWithCurrency(t, util.EUR)
+
+	_, err := store.CreateExchangeRate(context.Background(), CreateExchangeRateParams{
+		FromCurrency:  util.USD,
+		ToCurrency:    util.EUR,
+		Rate:          "0.9250000000",
+		EffectiveFrom: time.Now().Add(-time.Second),
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1000,
+	})
+	require.NoError(t, err)
+
+	rate, err := store.GetExchangeRate(context.Background(), GetExchangeRateParams{
+		FromCurrency: util.USD,
+		ToCurrency:   util.EUR,
+		At:           time.Now(),
+	})
+	require.NoError(t, err)
+	toAmount, err := util.ConvertAmount(1000, rate.Rate)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(1000), result.Transfer.Amount)
+	require.Equal(t, toAmount, result.Transfer.ToAmount)
+	require.Equal(t, rate.Rate, result.Transfer.ExchangeRate)
+	require.Equal(t, int64(-1000), result.FromEntry.Amount)
+	require.Equal(t, toAmount, result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-1000, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+toAmount, result.ToAccount.Balance)
+}
+
+func TestTransferTxNoExchangeRate(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccountWithCurrency(t, util.USD))
+	account2 := createRandomAccountWithCurrency(t, util.CAD)
+
+	// no test loads a USD to CAD rate
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrNoExchangeRate)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestReadTxReadsOneSnapshot(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	err := store.ReadTx(context.Background(), func(q Querier) error {
+		before, err := q.GetAccount(context.Background(), account1.ID)
+		require.NoError(t, err)
+
+		// a transfer committing meanwhile isn't seen by the rest of the transaction
+		_, err = store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+
+		after, err := q.GetAccount(context.Background(), account1.ID)
+		require.NoError(t, err)
+		require.Equal(t, before.Balance, after.Balance)
+		return nil
+	})
+	require.NoError(t, err)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-10, updatedAccount1.Balance)
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/joekings2k/gobank/db/sqlc"
+
+// startTxSpan opens the span of a transaction run by execTx. The queries the transaction runs are its children
+func startTxSpan(ctx context.Context) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "execTx",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemNamePostgreSQL),
+	)
+}
+
+// endTxSpan closes the span of a transaction, marking it failed when it didn't commit
+func endTxSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// tracedTx runs the queries of a transaction, each in a span under the transaction's.
+// The queries get the context of the store method, which doesn't carry that span
+type tracedTx struct {
+	tx   *sql.Tx
+	span trace.Span
+}
+
+func (tx tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := tx.startQuery(ctx, query)
+	result, err := tx.tx.ExecContext(ctx, query, args...)
+	endQuery(span, err)
+	return result, err
+}
+
+func (tx tracedTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := tx.startQuery(ctx, query)
+	stmt, err := tx.tx.PrepareContext(ctx, query)
+	endQuery(span, err)
+	return stmt, err
+}
+
+func (tx tracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := tx.startQuery(ctx, query)
+	rows, err := tx.tx.QueryContext(ctx, query, args...)
+	endQuery(span, err)
+	return rows, err
+}
+
+// QueryRowContext runs the query right away, its error only shows when the row is scanned
+func (tx tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := tx.startQuery(ctx, query)
+	row := tx.tx.QueryRowContext(ctx, query, args...)
+	endQuery(span, row.Err())
+	return row
+}
+
+func (tx tracedTx) startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := queryName(query)
+	return otel.Tracer(tracerName).Start(trace.ContextWithSpan(ctx, tx.span), name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+		),
+	)
+}
+
+func endQuery(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// queryName reads the name sqlc gives a query in its first line: -- name: GetAccount :one
+func queryName(query string) string {
+	line, _, _ := strings.Cut(query, "\n")
+	if name, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ = strings.Cut(name, " ")
+		return name
+	}
+	return "query"
+}
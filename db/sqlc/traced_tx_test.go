@@ -0,0 +1,13 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccountForupdate", queryName(getAccountForupdate))
+	require.Equal(t, "CreateTransfer", queryName(createTransfer))
+	require.Equal(t, "query", queryName("SELECT 1"))
+}
@@ -7,24 +7,78 @@ package db
 
 import (
 	"context"
+	"database/sql"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
-  from_account_id, to_account_id, amount
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, reverses_transfer_id
  ) VALUES (
-    $1, $2, $3)
-RETURNING id, from_account_id, to_account_id, amount, created_at
+    $1, $2, $3, $4, $5, $6)
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID      int64         `json:"from_account_id"`
+	ToAccountID        int64         `json:"to_account_id"`
+	Amount             int64         `json:"amount"`
+	ToAmount           int64         `json:"to_amount"`
+	ExchangeRate       string        `json:"exchange_rate"`
+	ReversesTransferID sql.NullInt64 `json:"reverses_transfer_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ReversesTransferID,
+	)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const createTransferHold = `-- name: CreateTransferHold :one
+INSERT INTO transfers (
+  from_account_id, to_account_id, amount, to_amount, exchange_rate, status, expires_at
+) VALUES (
+  $1, $2, $3, $4, $5, 'authorized', $6
+)
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at
+`
+
+type CreateTransferHoldParams struct {
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        int64        `json:"amount"`
+	ToAmount      int64        `json:"to_amount"`
+	ExchangeRate  string       `json:"exchange_rate"`
+	ExpiresAt     sql.NullTime `json:"expires_at"`
+}
+
+func (q *Queries) CreateTransferHold(ctx context.Context, arg CreateTransferHoldParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, createTransferHold,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.ExpiresAt,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -32,12 +86,43 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getExpiredTransferHoldForUpdate = `-- name: GetExpiredTransferHoldForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at FROM transfers
+WHERE status = 'authorized' AND expires_at <= now()
+ORDER BY expires_at, id
+LIMIT 1
+FOR NO KEY UPDATE SKIP LOCKED
+`
+
+func (q *Queries) GetExpiredTransferHoldForUpdate(ctx context.Context) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getExpiredTransferHoldForUpdate)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers 
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at FROM transfers 
 WHERE id = $1 LIMIT 1
 `
 
@@ -50,12 +135,139 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
 	)
 	return i, err
 }
 
+const getTransferForUpdate = `-- name: GetTransferForUpdate :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at FROM transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetTransferForUpdate(ctx context.Context, id int64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferForUpdate, id)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
+
+const getTransferReversedAmounts = `-- name: GetTransferReversedAmounts :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS reversed_amount,
+  COALESCE(SUM(to_amount), 0)::bigint AS returned_amount
+FROM transfers
+WHERE reverses_transfer_id = $1
+`
+
+type GetTransferReversedAmountsRow struct {
+	ReversedAmount int64 `json:"reversed_amount"`
+	ReturnedAmount int64 `json:"returned_amount"`
+}
+
+func (q *Queries) GetTransferReversedAmounts(ctx context.Context, reversesTransferID sql.NullInt64) (GetTransferReversedAmountsRow, error) {
+	row := q.db.QueryRowContext(ctx, getTransferReversedAmounts, reversesTransferID)
+	var i GetTransferReversedAmountsRow
+	err := row.Scan(&i.ReversedAmount, &i.ReturnedAmount)
+	return i, err
+}
+
+const listAccountTransfers = `-- name: ListAccountTransfers :many
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at FROM transfers
+WHERE (
+  (from_account_id = $1 AND $2::bool)
+  OR (to_account_id = $1 AND $3::bool)
+)
+AND ($4::timestamptz IS NULL OR created_at >= $4)
+AND ($5::timestamptz IS NULL OR created_at < $5)
+AND ($6::bigint IS NULL
+  OR (CASE WHEN from_account_id = $1 THEN amount ELSE to_amount END) >= $6)
+AND ($7::bigint IS NULL
+  OR (CASE WHEN from_account_id = $1 THEN amount ELSE to_amount END) <= $7)
+AND ($8::timestamptz IS NULL
+  OR (created_at, id) < ($8, $9::bigint))
+ORDER BY created_at DESC, id DESC
+LIMIT $10
+OFFSET $11
+`
+
+type ListAccountTransfersParams struct {
+	AccountID       int64         `json:"account_id"`
+	Outgoing        bool          `json:"outgoing"`
+	Incoming        bool          `json:"incoming"`
+	StartTime       sql.NullTime  `json:"start_time"`
+	EndTime         sql.NullTime  `json:"end_time"`
+	MinAmount       sql.NullInt64 `json:"min_amount"`
+	MaxAmount       sql.NullInt64 `json:"max_amount"`
+	BeforeCreatedAt sql.NullTime  `json:"before_created_at"`
+	BeforeID        sql.NullInt64 `json:"before_id"`
+	Limit           int32         `json:"limit"`
+	Offset          int32         `json:"offset"`
+}
+
+func (q *Queries) ListAccountTransfers(ctx context.Context, arg ListAccountTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountTransfers,
+		arg.AccountID,
+		arg.Outgoing,
+		arg.Incoming,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversesTransferID,
+			&i.Status,
+			&i.ExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at FROM transfers
 WHERE from_account_id =$1 OR to_account_id = $2
 ORDER BY id 
 LIMIT $3
@@ -89,6 +301,11 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.ReversesTransferID,
+			&i.Status,
+			&i.ExpiresAt,
 		); err != nil {
 			return nil, err
 		}
@@ -102,3 +319,33 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 	}
 	return items, nil
 }
+
+const updateTransferStatus = `-- name: UpdateTransferStatus :one
+UPDATE transfers
+SET status = $2
+WHERE id = $1
+RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, reverses_transfer_id, status, expires_at
+`
+
+type UpdateTransferStatusParams struct {
+	ID     int64  `json:"id"`
+	Status string `json:"status"`
+}
+
+func (q *Queries) UpdateTransferStatus(ctx context.Context, arg UpdateTransferStatusParams) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, updateTransferStatus, arg.ID, arg.Status)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.ReversesTransferID,
+		&i.Status,
+		&i.ExpiresAt,
+	)
+	return i, err
+}
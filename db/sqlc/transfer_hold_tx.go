@@ -0,0 +1,209 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// a transfer is authorized while it holds funds, and settled once the money has moved.
+// Transfers made in one step are settled straight away
+const (
+	TransferAuthorized = "authorized"
+	TransferSettled    = "settled"
+	TransferVoided     = "voided"
+	TransferExpired    = "expired"
+)
+
+var (
+	// ErrTransferNotAuthorized is returned when capturing or voiding a transfer that holds no funds
+	ErrTransferNotAuthorized = errors.New("transfer is not authorized")
+	// ErrTransferNotSettled is returned when reversing a transfer whose money hasn't moved
+	ErrTransferNotSettled = errors.New("transfer is not settled")
+	// ErrHoldExpired is returned when capturing an authorized transfer past its expiry
+	ErrHoldExpired = errors.New("transfer hold has expired")
+)
+
+type AuthorizeTransferTxParams struct {
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+type TransferHoldTxResult struct {
+	Transfer    Transfer `json:"transfer"`
+	FromAccount Account  `json:"from_account"`
+}
+
+// AuthorizeTransferTx reserves the amount on the from account, taking it off the available balance
+// but leaving the ledger balance alone until the transfer is captured. The rate is fixed now
+func (store *SQLStore) AuthorizeTransferTx(ctx context.Context, arg AuthorizeTransferTxParams) (TransferHoldTxResult, error) {
+	var result TransferHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		if err := checkFunds(fromAccount, arg.Amount); err != nil {
+			return err
+		}
+		toAmount, rate, err := convert(ctx, q, fromAccount.Currency, toAccount.Currency, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.CreateTransferHold(ctx, CreateTransferHoldParams{
+			FromAccountID: arg.FromAccountID,
+			ToAccountID:   arg.ToAccountID,
+			Amount:        arg.Amount,
+			ToAmount:      toAmount,
+			ExchangeRate:  rate,
+			ExpiresAt:     sql.NullTime{Time: arg.ExpiresAt, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromAccount, err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+			ID:     arg.FromAccountID,
+			Amount: -arg.Amount,
+		})
+		return err
+	})
+	return result, err
+}
+
+// CaptureTransferTx settles an authorized transfer: the entries are written, the balances move
+// by the amounts fixed at authorization and the hold is released
+func (store *SQLStore) CaptureTransferTx(ctx context.Context, transferID int64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := lockHold(ctx, q, transferID)
+		if err != nil {
+			return err
+		}
+		if hold.ExpiresAt.Valid && !hold.ExpiresAt.Time.After(time.Now()) {
+			return fmt.Errorf("%w: transfer [%d] expired at %s", ErrHoldExpired, hold.ID, hold.ExpiresAt.Time.Format(time.RFC3339))
+		}
+		if _, _, err = lockAccounts(ctx, q, hold.FromAccountID, hold.ToAccountID); err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+			ID:     hold.ID,
+			Status: TransferSettled,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.FromEntry, err = store.chainEntry(ctx, q, CreateEntryParams{
+			AccountID:  hold.FromAccountID,
+			Amount:     -hold.Amount,
+			TransferID: sql.NullInt64{Int64: hold.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		result.ToEntry, err = store.chainEntry(ctx, q, CreateEntryParams{
+			AccountID:  hold.ToAccountID,
+			Amount:     hold.ToAmount,
+			TransferID: sql.NullInt64{Int64: hold.ID, Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		if hold.FromAccountID < hold.ToAccountID {
+			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, hold.FromAccountID, -hold.Amount, hold.ToAccountID, hold.ToAmount)
+		} else {
+			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, hold.ToAccountID, hold.ToAmount, hold.FromAccountID, -hold.Amount)
+		}
+		if err != nil {
+			return err
+		}
+
+		// the debit took the amount off the available balance a second time
+		result.FromAccount, err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+			ID:     hold.FromAccountID,
+			Amount: hold.Amount,
+		})
+		return err
+	})
+	return result, err
+}
+
+// VoidTransferTx cancels an authorized transfer and releases its hold
+func (store *SQLStore) VoidTransferTx(ctx context.Context, transferID int64) (TransferHoldTxResult, error) {
+	var result TransferHoldTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		hold, err := lockHold(ctx, q, transferID)
+		if err != nil {
+			return err
+		}
+		result, err = releaseHold(ctx, q, hold, TransferVoided)
+		return err
+	})
+	return result, err
+}
+
+// ExpireTransferHoldsTx releases every authorized transfer past its expiry, each in its own
+// transaction and skipping holds another worker or a capture has locked. It returns how many expired
+func (store *SQLStore) ExpireTransferHoldsTx(ctx context.Context) (int64, error) {
+	var expired int64
+	for ctx.Err() == nil {
+		err := store.execTx(ctx, func(q *Queries) error {
+			hold, err := q.GetExpiredTransferHoldForUpdate(ctx)
+			if err != nil {
+				return err
+			}
+			_, err = releaseHold(ctx, q, hold, TransferExpired)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// lockHold locks a transfer that must still be authorized
+func lockHold(ctx context.Context, q *Queries, transferID int64) (Transfer, error) {
+	hold, err := q.GetTransferForUpdate(ctx, transferID)
+	if err != nil {
+		return hold, err
+	}
+	if hold.Status != TransferAuthorized {
+		return hold, fmt.Errorf("%w: transfer [%d] is %s", ErrTransferNotAuthorized, hold.ID, hold.Status)
+	}
+	return hold, nil
+}
+
+// releaseHold moves hold to status and gives the amount back to the available balance
+func releaseHold(ctx context.Context, q *Queries, hold Transfer, status string) (TransferHoldTxResult, error) {
+	var result TransferHoldTxResult
+	var err error
+
+	result.Transfer, err = q.UpdateTransferStatus(ctx, UpdateTransferStatusParams{
+		ID:     hold.ID,
+		Status: status,
+	})
+	if err != nil {
+		return result, err
+	}
+	result.FromAccount, err = q.AddAccountAvailableBalance(ctx, AddAccountAvailableBalanceParams{
+		ID:     hold.FromAccountID,
+		Amount: hold.Amount,
+	})
+	return result, err
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func authorizeRandomTransfer(t *testing.T, store Store, fromAccount Account, toAccount Account, amount int64, expiresAt time.Time) TransferHoldTxResult {
+	result, err := store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        amount,
+		ExpiresAt:     expiresAt,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, TransferAuthorized, result.Transfer.Status)
+	require.Equal(t, amount, result.Transfer.Amount)
+	require.WithinDuration(t, expiresAt, result.Transfer.ExpiresAt.Time, time.Second)
+	// the hold only reduces the available balance
+	require.Equal(t, fromAccount.Balance, result.FromAccount.Balance)
+	require.Equal(t, fromAccount.AvailableBalance-amount, result.FromAccount.AvailableBalance)
+
+	count, err := store.CountAccountEntries(context.Background(), fromAccount.ID)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	return result
+}
+
+func TestCaptureTransferTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	hold := authorizeRandomTransfer(t, store, account1, account2, 100, time.Now().Add(time.Hour))
+
+	result, err := store.CaptureTransferTx(context.Background(), hold.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferSettled, result.Transfer.Status)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(100), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, account1.Balance-100, result.FromAccount.AvailableBalance)
+	require.Equal(t, account2.Balance+100, result.ToAccount.Balance)
+	require.Equal(t, account2.Balance+100, result.ToAccount.AvailableBalance)
+
+	// settled transfers can't be captured or voided again
+	_, err = store.CaptureTransferTx(context.Background(), hold.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferNotAuthorized)
+	_, err = store.VoidTransferTx(context.Background(), hold.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferNotAuthorized)
+}
+
+func TestVoidTransferTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	hold := authorizeRandomTransfer(t, store, account1, account2, 100, time.Now().Add(time.Hour))
+
+	// only settled transfers can be reversed
+	_, err := store.ReverseTransferTx(context.Background(), ReverseTransferTxParams{TransferID: hold.Transfer.ID})
+	require.ErrorIs(t, err, ErrTransferNotSettled)
+
+	result, err := store.VoidTransferTx(context.Background(), hold.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferVoided, result.Transfer.Status)
+	require.Equal(t, account1.Balance, result.FromAccount.Balance)
+	require.Equal(t, account1.AvailableBalance, result.FromAccount.AvailableBalance)
+
+	_, err = store.CaptureTransferTx(context.Background(), hold.Transfer.ID)
+	require.ErrorIs(t, err, ErrTransferNotAuthorized)
+}
+
+func TestAuthorizeTransferTxHoldsFunds(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	authorizeRandomTransfer(t, store, account1, account2, account1.AvailableBalance, time.Now().Add(time.Hour))
+
+	// held funds can't be spent or held twice
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = store.AuthorizeTransferTx(context.Background(), AuthorizeTransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+		ExpiresAt:     time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestExpireTransferHoldsTx(t *testing.T) {
+	store := NewStore(testDB, testEntryKey)
+
+	account1 := fundAccount(t, createRandomAccount(t))
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+	stale := authorizeRandomTransfer(t, store, account1, account2, 100, time.Now().Add(-time.Second))
+	live := authorizeRandomTransfer(t, store, stale.FromAccount, account2, 100, time.Now().Add(time.Hour))
+
+	// a stale hold can't be captured even before the sweeper gets to it
+	_, err := store.CaptureTransferTx(context.Background(), stale.Transfer.ID)
+	require.ErrorIs(t, err, ErrHoldExpired)
+
+	expired, err := store.ExpireTransferHoldsTx(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, expired, int64(1))
+
+	transfer, err := store.GetTransfer(context.Background(), stale.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferExpired, transfer.Status)
+	transfer, err = store.GetTransfer(context.Background(), live.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, TransferAuthorized, transfer.Status)
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account1.AvailableBalance-100, updatedAccount1.AvailableBalance)
+}
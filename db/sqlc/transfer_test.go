@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"database/sql"
 	"testing"
 	"time"
 
@@ -16,10 +17,13 @@ func createTwoRandomAccounts(t *testing.T)(Account,Account){
 }
 func createRandomTransfer(t *testing.T)Transfer{
 	account1,account2 :=createTwoRandomAccounts(t)
+	amount := util.RandomMoney()
 	arg:= CreateTransferParams{
 		FromAccountID: account1.ID,
 		ToAccountID: account2.ID,
-		Amount: util.RandomMoney(),
+		Amount: amount,
+		ToAmount: amount,
+		ExchangeRate: "1",
 	}
 	transfer,err := testQueries.CreateTransfer(context.Background(),arg)
 	require.NoError(t,err)
@@ -27,16 +31,20 @@ func createRandomTransfer(t *testing.T)Transfer{
 	require.Equal(t , arg.FromAccountID ,transfer.FromAccountID)
 	require.Equal(t , arg.ToAccountID ,transfer.ToAccountID)
 	require.Equal(t , arg.Amount ,transfer.Amount)
+	require.Equal(t , arg.ToAmount ,transfer.ToAmount)
 	require.NotZero(t,transfer.ID)
 	require.NotZero(t,transfer.CreatedAt)
 	return transfer
 }
 
 func createMultipleTransferswithSameId(accountId1 int64, accoutId2 int64 ) Transfer{
+	amount := util.RandomMoney()
 	arg:= CreateTransferParams{
 		FromAccountID: accountId1,
 		ToAccountID: accoutId2,
-		Amount: util.RandomMoney(),
+		Amount: amount,
+		ToAmount: amount,
+		ExchangeRate: "1",
 	}
 	transfer,err := testQueries.CreateTransfer(context.Background(),arg)
 	if (err != nil){
@@ -78,4 +86,58 @@ func TestListTransfers(t *testing.T){
 	for _,transfer := range transfers{
 		require.NotEmpty(t,transfer)
 	}
-}
\ No newline at end of file
+}
+
+func TestListAccountTransfers(t *testing.T) {
+	account1, account2 := createTwoRandomAccounts(t)
+
+	var transfers []Transfer
+	for _, arg := range []CreateTransferParams{
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 100, ToAmount: 90, ExchangeRate: "0.9"},
+		{FromAccountID: account2.ID, ToAccountID: account1.ID, Amount: 50, ToAmount: 55, ExchangeRate: "1.1"},
+		{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: 300, ToAmount: 270, ExchangeRate: "0.9"},
+	} {
+		transfer, err := testQueries.CreateTransfer(context.Background(), arg)
+		require.NoError(t, err)
+		transfers = append(transfers, transfer)
+	}
+
+	arg := ListAccountTransfersParams{
+		AccountID: account1.ID,
+		Outgoing:  true,
+		Incoming:  true,
+		Limit:     10,
+		Offset:    0,
+	}
+	all, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	// newest first
+	require.Equal(t, transfers[2].ID, all[0].ID)
+	require.Equal(t, transfers[0].ID, all[2].ID)
+
+	arg.Outgoing = false
+	incoming, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	require.Equal(t, transfers[1].ID, incoming[0].ID)
+
+	// incoming transfers are compared by the amount credited to the account
+	arg.MinAmount = sql.NullInt64{Int64: 55, Valid: true}
+	incoming, err = testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+
+	arg.Outgoing = true
+	arg.MinAmount = sql.NullInt64{}
+	arg.MaxAmount = sql.NullInt64{Int64: 100, Valid: true}
+	small, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, small, 2)
+
+	arg.MaxAmount = sql.NullInt64{}
+	arg.EndTime = sql.NullTime{Time: transfers[0].CreatedAt.Time.Add(-time.Second), Valid: true}
+	none, err := testQueries.ListAccountTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
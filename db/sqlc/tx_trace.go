@@ -0,0 +1,48 @@
+package db
+
+import "context"
+
+// TxTrace is told about the rollbacks of the transactions run with its context,
+// the way httptrace.ClientTrace follows an HTTP request. Its funcs may be nil
+type TxTrace struct {
+	// Rollback is called when a transaction is rolled back because of err
+	Rollback func(err error)
+}
+
+type txTraceKey struct{}
+
+// WithTxTrace returns a context whose transactions report to trace,
+// and to any trace ctx already carried
+func WithTxTrace(ctx context.Context, trace *TxTrace) context.Context {
+	if old := txTraceFrom(ctx); old != nil {
+		trace = &TxTrace{
+			Rollback: composeTxHook(old.Rollback, trace.Rollback),
+		}
+	}
+	return context.WithValue(ctx, txTraceKey{}, trace)
+}
+
+func txTraceFrom(ctx context.Context) *TxTrace {
+	trace, _ := ctx.Value(txTraceKey{}).(*TxTrace)
+	return trace
+}
+
+func composeTxHook(first func(err error), second func(err error)) func(err error) {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+	return func(err error) {
+		first(err)
+		second(err)
+	}
+}
+
+// traceRollback tells the trace of ctx, if any, that a transaction was rolled back
+func traceRollback(ctx context.Context, err error) {
+	if trace := txTraceFrom(ctx); trace != nil && trace.Rollback != nil {
+		trace.Rollback(err)
+	}
+}
@@ -18,7 +18,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4
 ) 
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, tokens_revoked_at, role
 `
 
 type CreateUserParams struct {
@@ -43,12 +43,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, tokens_revoked_at, role FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -62,6 +64,59 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :one
+UPDATE users
+SET tokens_revoked_at = now()
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, tokens_revoked_at, role
+`
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRowContext(ctx, revokeUserTokens, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE users
+SET role = $2
+WHERE username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, tokens_revoked_at, role
+`
+
+type UpdateUserRoleParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserRole, arg.Username, arg.Role)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.TokensRevokedAt,
+		&i.Role,
 	)
 	return i, err
 }
@@ -0,0 +1,91 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// publicMethods can be called without a token
+var publicMethods = map[string]bool{
+	pb.GoBank_CreateUser_FullMethodName: true,
+	pb.GoBank_LoginUser_FullMethodName:  true,
+}
+
+// methodRoles restricts methods to tokens carrying one of the roles, the rest are open to any role
+var methodRoles = map[string][]string{
+	pb.GoBank_GetUser_FullMethodName: {util.AdminRole},
+}
+
+type authPayloadKey struct{}
+
+// authInterceptor does for gRPC what authMiddleware does for Gin: it verifies the bearer token in the
+// authorization metadata, rejects revoked tokens and checks the method's roles
+func (server *Server) authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+	payload, err := server.authorizeUser(ctx, methodRoles[info.FullMethod]...)
+	if err != nil {
+		return nil, err
+	}
+	return handler(context.WithValue(ctx, authPayloadKey{}, payload), req)
+}
+
+func (server *Server) authorizeUser(ctx context.Context, accessibleRoles ...string) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "missing metadata")
+	}
+	values := md.Get(authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, status.Errorf(codes.Unauthenticated, "authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid authorization format")
+	}
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return nil, status.Errorf(codes.Unauthenticated, "unsupported authorization type %s", authorizationType)
+	}
+	payload, err := server.tokenMaker.VerifyToken(fields[1], token.AccessToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+	}
+	revoked, err := server.store.IsTokenRevoked(ctx, db.IsTokenRevokedParams{
+		ID:       payload.ID,
+		Username: payload.Username,
+		IssuedAt: payload.IssuedAt,
+	})
+	if err != nil {
+		return nil, internalError(ctx, "cannot check token", err)
+	}
+	if revoked {
+		return nil, status.Errorf(codes.Unauthenticated, "%s", token.ErrRevokedToken)
+	}
+	if len(accessibleRoles) > 0 && !util.HasRole(payload.Role, accessibleRoles...) {
+		return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("role %s is not allowed to access this resource", payload.Role))
+	}
+	return payload, nil
+}
+
+// authPayload returns the payload the interceptor stored for an authenticated method
+func authPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authPayloadKey{}).(*token.Payload)
+}
@@ -0,0 +1,177 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	username := util.RandomOwner()
+	user := db.User{Username: username, Role: util.DepositorRole, FullName: util.RandomOwner(), Email: util.RandomEmail()}
+
+	testCases := []struct {
+		name       string
+		buildCtx   func(t *testing.T, tokenMaker token.Maker) context.Context
+		buildStubs func(store *mockdb.MockStore)
+		call       func(ctx context.Context, client pb.GoBankClient) error
+		code       codes.Code
+	}{
+		{
+			name: "OK",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, username, util.AdminRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectTokenNotRevoked(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).Return(user, nil)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetUser(ctx, &pb.GetUserRequest{Username: username})
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "NoAuthorization",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "UnsupportedAuthorization",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				accessToken, _, err := tokenMaker.CreateToken(username, util.DepositorRole, token.AccessToken, time.Minute)
+				require.NoError(t, err)
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, "basic "+accessToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "ExpiredToken",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, username, util.DepositorRole, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RefreshToken",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				refreshToken, _, err := tokenMaker.CreateToken(username, util.DepositorRole, token.RefreshToken, time.Minute)
+				require.NoError(t, err)
+				return metadata.AppendToOutgoingContext(context.Background(), authorizationHeaderKey, authorizationTypeBearer+" "+refreshToken)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RevokedToken",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, username, util.DepositorRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(1).Return(true, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: 1})
+				return err
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name: "RoleNotAllowed",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, username, util.BankerRole, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				expectTokenNotRevoked(store)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.GetUser(ctx, &pb.GetUserRequest{Username: username})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "PublicMethod",
+			buildCtx: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+			},
+			call: func(ctx context.Context, client pb.GoBankClient) error {
+				_, err := client.CreateUser(ctx, &pb.CreateUserRequest{
+					Username: username,
+					Password: "secret",
+					FullName: user.FullName,
+					Email:    user.Email,
+				})
+				return err
+			},
+			code: codes.OK,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, client := newTestServer(t, store)
+			err := tc.call(tc.buildCtx(t, server.tokenMaker), client)
+			if tc.code == codes.OK {
+				require.NoError(t, err)
+				return
+			}
+			requireCode(t, tc.code, err)
+		})
+	}
+}
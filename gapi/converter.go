@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func convertUser(user db.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		Role:              user.Role,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		AvailableBalance: account.AvailableBalance,
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	converted := &pb.Transfer{
+		Id:                 transfer.ID,
+		FromAccountId:      transfer.FromAccountID,
+		ToAccountId:        transfer.ToAccountID,
+		Amount:             transfer.Amount,
+		ToAmount:           transfer.ToAmount,
+		ExchangeRate:       transfer.ExchangeRate,
+		Status:             transfer.Status,
+		ReversesTransferId: transfer.ReversesTransferID.Int64,
+	}
+	if transfer.CreatedAt.Valid {
+		converted.CreatedAt = timestamppb.New(transfer.CreatedAt.Time)
+	}
+	return converted
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:         entry.ID,
+		AccountId:  entry.AccountID,
+		Amount:     entry.Amount,
+		TransferId: entry.TransferID.Int64,
+		CreatedAt:  timestamppb.New(entry.CreatedAt),
+	}
+}
@@ -0,0 +1,83 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+
+	"github.com/joekings2k/gobank/logging"
+	"github.com/joekings2k/gobank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// violations collects the invalid fields of a request, the same rules the HTTP API binds with
+type violations []*errdetails.BadRequest_FieldViolation
+
+func (v *violations) add(field string, err error) {
+	*v = append(*v, &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: err.Error(),
+	})
+}
+
+// err is an InvalidArgument status listing every violation, nil when there are none
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, "invalid parameters")
+	detailed, err := st.WithDetails(&errdetails.BadRequest{FieldViolations: v})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// internalError logs err and returns an Internal status with message alone,
+// so what the database or a library said never reaches the client
+func internalError(ctx context.Context, message string, err error) error {
+	logging.FromContext(ctx).ErrorContext(ctx, message, "error", err)
+	return status.Error(codes.Internal, message)
+}
+
+func validateUsername(value string) error {
+	if value == "" {
+		return fmt.Errorf("is required")
+	}
+	for _, c := range value {
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9') {
+			return fmt.Errorf("must contain only letters and digits")
+		}
+	}
+	return nil
+}
+
+func validatePassword(value string) error {
+	if len(value) < 6 {
+		return fmt.Errorf("must be at least 6 characters")
+	}
+	return nil
+}
+
+func validateEmail(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return fmt.Errorf("is not a valid email address")
+	}
+	return nil
+}
+
+func validateCurrency(value string) error {
+	if !util.IsSuppoertedCurrency(value) {
+		return fmt.Errorf("unsupported currency %q", value)
+	}
+	return nil
+}
+
+func validateID(value int64) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
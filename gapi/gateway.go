@@ -0,0 +1,32 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/joekings2k/gobank/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGatewayHandler serves the gRPC API as HTTP/JSON under /v1 by proxying to the gRPC server at
+// grpcAddress, so calls go through the same interceptor. JSON uses the proto field names and
+// includes zero values
+func NewGatewayHandler(ctx context.Context, grpcAddress string) (http.Handler, error) {
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames:   true,
+			EmitUnpopulated: true,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: true,
+		},
+	}))
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if err := pb.RegisterGoBankHandlerFromEndpoint(ctx, mux, grpcAddress, opts); err != nil {
+		return nil, err
+	}
+	return mux, nil
+}
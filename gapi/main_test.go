@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestServer serves a Server over an in-memory connection, so calls go through the interceptor
+func newTestServer(t *testing.T, store db.Store) (*Server, pb.GoBankClient) {
+	config := util.Config{
+		TokenSymmeticKey:     util.RandomString(32),
+		AccessTokenDuration:  time.Minute,
+		RefreshTokenDuration: time.Hour,
+	}
+	server, err := NewServer(config, store)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := NewGRPCServer(server)
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return server, pb.NewGoBankClient(conn)
+}
+
+// newContextWithBearerToken returns a context carrying an access token in the authorization metadata
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, token.AccessToken, duration)
+	require.NoError(t, err)
+	md := metadata.Pairs(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+	return metadata.NewOutgoingContext(context.Background(), md)
+}
+
+func expectTokenNotRevoked(store *mockdb.MockStore) {
+	store.EXPECT().IsTokenRevoked(gomock.Any(), gomock.Any()).AnyTimes().Return(false, nil)
+}
+
+func requireCode(t *testing.T, code codes.Code, err error) {
+	require.Error(t, err)
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code(), st.Message())
+}
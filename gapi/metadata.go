@@ -0,0 +1,41 @@
+package gapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	grpcGatewayUserAgentHeader = "grpcgateway-user-agent"
+	userAgentHeader            = "user-agent"
+	xForwardedForHeader        = "x-forwarded-for"
+)
+
+type requestMetadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+// extractMetadata reads the client's user agent and address, from the headers the gateway forwards
+// when the call came through it
+func extractMetadata(ctx context.Context) requestMetadata {
+	var mtdt requestMetadata
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		} else if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
+			mtdt.ClientIP = clientIPs[0]
+		}
+	}
+	if mtdt.ClientIP == "" {
+		if p, ok := peer.FromContext(ctx); ok {
+			mtdt.ClientIP = p.Addr.String()
+		}
+	}
+	return mtdt
+}
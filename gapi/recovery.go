@@ -0,0 +1,25 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/joekings2k/gobank/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryInterceptor does for gRPC what the Gin router's recovery middleware does: a panicking
+// handler is logged with its stack and answered with an Internal status instead of killing the process
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			logging.FromContext(ctx).ErrorContext(ctx, "handler panicked",
+				"method", info.FullMethod, "panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+			resp, err = nil, status.Error(codes.Internal, "internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
@@ -0,0 +1,33 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/util"
+	"google.golang.org/grpc/codes"
+)
+
+func TestRecoveryInterceptor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(2).
+		DoAndReturn(func(_ context.Context, _ string) (db.User, error) {
+			panic("store exploded")
+		})
+	_, client := newTestServer(t, store)
+
+	req := &pb.LoginUserRequest{Username: util.RandomOwner(), Password: util.RandomString(6)}
+	_, err := client.LoginUser(context.Background(), req)
+	requireCode(t, codes.Internal, err)
+
+	// the server survived the panic and keeps answering
+	_, err = client.LoginUser(context.Background(), req)
+	requireCode(t, codes.Internal, err)
+}
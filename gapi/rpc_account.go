@@ -0,0 +1,124 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	var v violations
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		v.add("currency", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	account, err := server.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    authPayload(ctx).Username,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code.Name() {
+			case "unique_violation":
+				return nil, status.Error(codes.AlreadyExists, "the user already has an account in this currency")
+			case "foreign_key_violation":
+				return nil, status.Error(codes.FailedPrecondition, "the account owner doesn't exist")
+			}
+		}
+		return nil, internalError(ctx, "cannot create account", err)
+	}
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	var v violations
+	if err := validateID(req.GetId()); err != nil {
+		v.add("id", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	account, err := server.accessibleAccount(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	var v violations
+	if req.GetOwner() != "" {
+		if err := validateUsername(req.GetOwner()); err != nil {
+			v.add("owner", err)
+		}
+	}
+	if req.GetPageId() < 1 {
+		v.add("page_id", errors.New("must be at least 1"))
+	}
+	if req.GetPageSize() < 5 || req.GetPageSize() > 10 {
+		v.add("page_size", errors.New("must be between 5 and 10"))
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	payload := authPayload(ctx)
+	owner := payload.Username
+	if req.GetOwner() != "" && req.GetOwner() != payload.Username {
+		if !util.IsPrivilegedRole(payload.Role) {
+			return nil, status.Errorf(codes.PermissionDenied, "only bankers can list other users' accounts")
+		}
+		owner = req.GetOwner()
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:  owner,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, internalError(ctx, "cannot list accounts", err)
+	}
+	rsp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, len(accounts))}
+	for i, account := range accounts {
+		rsp.Accounts[i] = convertAccount(account)
+	}
+	return rsp, nil
+}
+
+// accessibleAccount gets an account the authenticated user owns, or any account for bankers and admins
+func (server *Server) accessibleAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	account, err := server.loadAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+	payload := authPayload(ctx)
+	if account.Owner != payload.Username && !util.IsPrivilegedRole(payload.Role) {
+		return account, status.Errorf(codes.PermissionDenied, "account doesn`t belong to the authenticated user")
+	}
+	return account, nil
+}
+
+// loadAccount gets the account, as a NotFound or Internal status when it can't
+func (server *Server) loadAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, internalError(ctx, "cannot get account", err)
+	}
+	return account, nil
+}
@@ -0,0 +1,215 @@
+package gapi
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func randomAccount(owner string) db.Account {
+	return db.Account{
+		ID:        util.RandomInt(1, 1000),
+		Owner:     owner,
+		Balance:   util.RandomMoney(),
+		Currency:  util.RandomCurrency(),
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func TestGetAccount(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+
+	testCases := []struct {
+		name          string
+		username      string
+		role          string
+		id            int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.GetAccountResponse, err error)
+	}{
+		{
+			name:     "OK",
+			username: owner,
+			role:     util.DepositorRole,
+			id:       account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, rsp.GetAccount().GetId())
+				require.Equal(t, account.Owner, rsp.GetAccount().GetOwner())
+				require.Equal(t, account.Balance, rsp.GetAccount().GetBalance())
+				require.Equal(t, account.Currency, rsp.GetAccount().GetCurrency())
+				require.True(t, account.CreatedAt.Equal(rsp.GetAccount().GetCreatedAt().AsTime()))
+			},
+		},
+		{
+			name:     "Banker",
+			username: util.RandomOwner(),
+			role:     util.BankerRole,
+			id:       account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: util.RandomOwner(),
+			role:     util.DepositorRole,
+			id:       account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				requireCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: owner,
+			role:     util.DepositorRole,
+			id:       account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				requireCode(t, codes.NotFound, err)
+			},
+		},
+		{
+			name:     "InternalError",
+			username: owner,
+			role:     util.DepositorRole,
+			id:       account.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				requireCode(t, codes.Internal, err)
+				// what the driver said is logged, not sent
+				require.Equal(t, "cannot get account", status.Convert(err).Message())
+			},
+		},
+		{
+			name:     "InvalidID",
+			username: owner,
+			role:     util.DepositorRole,
+			id:       0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.GetAccountResponse, err error) {
+				requireCode(t, codes.InvalidArgument, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server, client := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, tc.role, time.Minute)
+			rsp, err := client.GetAccount(ctx, &pb.GetAccountRequest{Id: tc.id})
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	owner := util.RandomOwner()
+	accounts := []db.Account{randomAccount(owner), randomAccount(owner)}
+
+	testCases := []struct {
+		name          string
+		role          string
+		req           *pb.ListAccountsRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.ListAccountsResponse, err error)
+	}{
+		{
+			name: "OK",
+			role: util.DepositorRole,
+			req:  &pb.ListAccountsRequest{PageId: 2, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{Owner: owner, Limit: 5, Offset: 5}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, rsp.GetAccounts(), 2)
+			},
+		},
+		{
+			name: "OtherOwner",
+			role: util.DepositorRole,
+			req:  &pb.ListAccountsRequest{Owner: "someoneelse", PageId: 1, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				requireCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name: "BankerOtherOwner",
+			role: util.BankerRole,
+			req:  &pb.ListAccountsRequest{Owner: "someoneelse", PageId: 1, PageSize: 5},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsParams{Owner: "someoneelse", Limit: 5, Offset: 0}
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InvalidPageSize",
+			role: util.DepositorRole,
+			req:  &pb.ListAccountsRequest{PageId: 1, PageSize: 50},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAccounts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.ListAccountsResponse, err error) {
+				requireCode(t, codes.InvalidArgument, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server, client := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, owner, tc.role, time.Minute)
+			rsp, err := client.ListAccounts(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateTransfer moves money like POST /transfers. Idempotency keys are only supported over HTTP
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	var v violations
+	if err := validateID(req.GetFromAccountId()); err != nil {
+		v.add("from_account_id", err)
+	}
+	if err := validateID(req.GetToAccountId()); err != nil {
+		v.add("to_account_id", err)
+	}
+	if req.GetAmount() <= 0 {
+		v.add("amount", errors.New("must be positive"))
+	}
+	if err := validateCurrency(req.GetCurrency()); err != nil {
+		v.add("currency", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	fromAccount, err := server.loadAccount(ctx, req.GetFromAccountId())
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s",
+			fromAccount.ID, fromAccount.Currency, req.GetCurrency())
+	}
+	if fromAccount.Owner != authPayload(ctx).Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn`t belong to the authenticated user")
+	}
+	// the to account may hold another currency, TransferTx converts at the current rate
+	if _, err := server.loadAccount(ctx, req.GetToAccountId()); err != nil {
+		return nil, err
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrNoExchangeRate) || errors.Is(err, db.ErrAmountNotConvertible) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, internalError(ctx, "cannot transfer", err)
+	}
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}, nil
+}
+
+// GetTransfer returns a transfer to the owner of either account, or to bankers and admins
+func (server *Server) GetTransfer(ctx context.Context, req *pb.GetTransferRequest) (*pb.GetTransferResponse, error) {
+	var v violations
+	if err := validateID(req.GetId()); err != nil {
+		v.add("id", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	transfer, err := server.store.GetTransfer(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "transfer not found")
+		}
+		return nil, internalError(ctx, "cannot get transfer", err)
+	}
+
+	payload := authPayload(ctx)
+	if !util.IsPrivilegedRole(payload.Role) {
+		owner := false
+		for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+			account, err := server.loadAccount(ctx, accountID)
+			if err != nil {
+				return nil, err
+			}
+			if account.Owner == payload.Username {
+				owner = true
+				break
+			}
+		}
+		if !owner {
+			return nil, status.Errorf(codes.PermissionDenied, "transfer doesn`t belong to the authenticated user")
+		}
+	}
+	return &pb.GetTransferResponse{Transfer: convertTransfer(transfer)}, nil
+}
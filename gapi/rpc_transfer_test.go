@@ -0,0 +1,134 @@
+package gapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	owner := util.RandomOwner()
+	account1 := randomAccount(owner)
+	account2 := randomAccount(util.RandomOwner())
+	account3 := randomAccount(util.RandomOwner())
+	account1.Currency = util.USD
+	account2.Currency = util.USD
+	account3.Currency = util.EUR
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				arg := db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount}
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(db.TransferTxResult{
+					Transfer: db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount, ToAmount: amount},
+				}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), rsp.GetTransfer().GetId())
+				require.Equal(t, amount, rsp.GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "CrossCurrency",
+			req:  &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account3.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account3.ID)).Times(1).Return(account3, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NotOwner",
+			req:  &pb.CreateTransferRequest{FromAccountId: account2.ID, ToAccountId: account1.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireCode(t, codes.PermissionDenied, err)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			req:  &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireCode(t, codes.InvalidArgument, err)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			req:  &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireCode(t, codes.FailedPrecondition, err)
+			},
+		},
+		{
+			name: "InvalidArguments",
+			req:  &pb.CreateTransferRequest{FromAccountId: account1.ID, Amount: -1, Currency: "XYZ"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireCode(t, codes.InvalidArgument, err)
+				// every invalid field is reported
+				var fields []string
+				for _, detail := range status.Convert(err).Details() {
+					for _, violation := range detail.(*errdetails.BadRequest).GetFieldViolations() {
+						fields = append(fields, violation.GetField())
+					}
+				}
+				require.Equal(t, []string{"to_account_id", "amount", "currency"}, fields)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			expectTokenNotRevoked(store)
+
+			server, client := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, owner, util.DepositorRole, time.Minute)
+			rsp, err := client.CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/metrics"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	var v violations
+	if err := validateUsername(req.GetUsername()); err != nil {
+		v.add("username", err)
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		v.add("password", err)
+	}
+	if req.GetFullName() == "" {
+		v.add("full_name", errors.New("is required"))
+	}
+	if err := validateEmail(req.GetEmail()); err != nil {
+		v.add("email", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := util.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, internalError(ctx, "cannot hash password", err)
+	}
+	user, err := server.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "unique_violation" {
+			if pqErr.Constraint == "users_email_key" {
+				return nil, status.Error(codes.AlreadyExists, "email is already registered")
+			}
+			return nil, status.Error(codes.AlreadyExists, "username is already taken")
+		}
+		return nil, internalError(ctx, "cannot create user", err)
+	}
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	var v violations
+	if err := validateUsername(req.GetUsername()); err != nil {
+		v.add("username", err)
+	}
+	if err := validatePassword(req.GetPassword()); err != nil {
+		v.add("password", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			metrics.LoginFailed(metrics.LoginUnknownUser)
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, internalError(ctx, "cannot get user", err)
+	}
+	if err := util.CheckPassword(req.GetPassword(), user.HashedPassword); err != nil {
+		metrics.LoginFailed(metrics.LoginWrongPassword)
+		return nil, status.Errorf(codes.Unauthenticated, "incorrect password")
+	}
+
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.AccessToken, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, internalError(ctx, "cannot create access token", err)
+	}
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, token.RefreshToken, server.config.RefreshTokenDuration)
+	if err != nil {
+		return nil, internalError(ctx, "cannot create refresh token", err)
+	}
+
+	mtdt := extractMetadata(ctx)
+	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+	})
+	if err != nil {
+		return nil, internalError(ctx, "cannot create session", err)
+	}
+
+	return &pb.LoginUserResponse{
+		User:                  convertUser(user),
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+	}, nil
+}
+
+// GetUser is only routed for admins by the interceptor
+func (server *Server) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	var v violations
+	if err := validateUsername(req.GetUsername()); err != nil {
+		v.add("username", err)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, internalError(ctx, "cannot get user", err)
+	}
+	return &pb.GetUserResponse{User: convertUser(user)}, nil
+}
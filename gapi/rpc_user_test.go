@@ -0,0 +1,172 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+func randomUser(t *testing.T) (db.User, string) {
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashPassword(password)
+	require.NoError(t, err)
+	return db.User{
+		Username:       util.RandomOwner(),
+		Role:           util.DepositorRole,
+		HashedPassword: hashedPassword,
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}, password
+}
+
+func TestCreateUser(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		req        *pb.CreateUserRequest
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name: "OK",
+			req:  &pb.CreateUserRequest{Username: user.Username, Password: password, FullName: user.FullName, Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateUserParams) (db.User, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.NoError(t, util.CheckPassword(password, arg.HashedPassword))
+						return user, nil
+					})
+			},
+			code: codes.OK,
+		},
+		{
+			name: "DuplicateUsername",
+			req:  &pb.CreateUserRequest{Username: user.Username, Password: password, FullName: user.FullName, Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, &pq.Error{Code: "23505"})
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.CreateUserRequest{Username: user.Username, Password: password, FullName: user.FullName, Email: "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "InvalidUsername",
+			req:  &pb.CreateUserRequest{Username: "user#1", Password: password, FullName: user.FullName, Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			_, client := newTestServer(t, store)
+			rsp, err := client.CreateUser(context.Background(), tc.req)
+			if tc.code != codes.OK {
+				requireCode(t, tc.code, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, user.Username, rsp.GetUser().GetUsername())
+			require.Equal(t, user.Email, rsp.GetUser().GetEmail())
+		})
+	}
+}
+
+func TestLoginUser(t *testing.T) {
+	user, password := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		password   string
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name:     "OK",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ interface{}, arg db.CreateSessionParams) (db.Session, error) {
+						require.Equal(t, user.Username, arg.Username)
+						// the gateway forwards the HTTP client's user agent
+						require.Equal(t, "curl/8.0", arg.UserAgent)
+						return db.Session{ID: arg.ID, Username: arg.Username}, nil
+					})
+			},
+			code: codes.OK,
+		},
+		{
+			name:     "IncorrectPassword",
+			password: "incorrect",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:     "UserNotFound",
+			password: password,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+			},
+			code: codes.NotFound,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server, client := newTestServer(t, store)
+			ctx := metadata.AppendToOutgoingContext(context.Background(), grpcGatewayUserAgentHeader, "curl/8.0")
+			rsp, err := client.LoginUser(ctx, &pb.LoginUserRequest{Username: user.Username, Password: tc.password})
+			if tc.code != codes.OK {
+				requireCode(t, tc.code, err)
+				return
+			}
+			require.NoError(t, err)
+			payload, err := server.tokenMaker.VerifyToken(rsp.GetAccessToken(), token.AccessToken)
+			require.NoError(t, err)
+			require.Equal(t, user.Username, payload.Username)
+			refreshPayload, err := server.tokenMaker.VerifyToken(rsp.GetRefreshToken(), token.RefreshToken)
+			require.NoError(t, err)
+			require.Equal(t, user.Username, refreshPayload.Username)
+			require.NotEmpty(t, rsp.GetSessionId())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package gapi
+
+import (
+	"fmt"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/pb"
+	"github.com/joekings2k/gobank/token"
+	"github.com/joekings2k/gobank/util"
+	"google.golang.org/grpc"
+)
+
+// Server serves gRPC requests for our banking service, backed by the same store and tokens as the HTTP API
+type Server struct {
+	pb.UnimplementedGoBankServer
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+}
+
+func NewServer(config util.Config, store db.Store) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmeticKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+	}
+	return server, nil
+}
+
+// NewGRPCServer creates a grpc.Server serving server behind the recovery and auth interceptors.
+// Recovery runs first so it also catches panics in the auth interceptor
+func NewGRPCServer(server *Server) *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(recoveryInterceptor, server.authInterceptor))
+	pb.RegisterGoBankServer(grpcServer, server)
+	return grpcServer
+}
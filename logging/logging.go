@@ -0,0 +1,69 @@
+// Package logging builds the JSON logger of the bank and carries request scoped loggers in contexts
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// redacted replaces the values of sensitive attributes
+const redacted = "[REDACTED]"
+
+// New returns a logger writing JSON lines to w from level up, e.g. "info".
+// Attributes named like passwords, tokens and secrets are redacted, at any depth
+func New(w io.Writer, level string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if level != "" {
+		if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q: %w", level, err)
+		}
+	}
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       minLevel,
+		ReplaceAttr: redact,
+	})
+	return slog.New(handler), nil
+}
+
+// redact hides the value of sensitive attributes
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitive(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// sensitive reports whether an attribute named key may hold a credential
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range []string{"password", "token", "secret", "authorization"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// Milliseconds gives a duration as fractional milliseconds, easier to read and chart than nanoseconds
+func Milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying logger, for the code handling a request to log with
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger ctx carries, or the default logger
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newTestLogger logs JSON lines into a buffer
+func newTestLogger(t *testing.T, level string) (*slog.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, level)
+	require.NoError(t, err)
+	return logger, &buf
+}
+
+// lastLine decodes the last JSON line logged into buf
+func lastLine(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[len(lines)-1], &line))
+	return line
+}
+
+func TestRedaction(t *testing.T) {
+	logger, buf := newTestLogger(t, "info")
+
+	logger.Info("login",
+		"username", "alice",
+		"password", "secret123",
+		slog.Group("tokens", "access_token", "v2.local.abc", "refresh_token", "v2.local.def"),
+		"Authorization", "Bearer v2.local.abc",
+	)
+	require.NotContains(t, buf.String(), "secret123")
+	require.NotContains(t, buf.String(), "v2.local")
+
+	line := lastLine(t, buf)
+	require.Equal(t, "alice", line["username"])
+	require.Equal(t, redacted, line["password"])
+	require.Equal(t, redacted, line["Authorization"])
+	require.Equal(t, map[string]interface{}{"access_token": redacted, "refresh_token": redacted}, line["tokens"])
+}
+
+func TestLevel(t *testing.T) {
+	logger, buf := newTestLogger(t, "warn")
+	logger.Info("dropped")
+	require.Empty(t, buf.String())
+	logger.Warn("kept")
+	require.Equal(t, "kept", lastLine(t, buf)["msg"])
+
+	_, err := New(&bytes.Buffer{}, "loud")
+	require.ErrorContains(t, err, `invalid log level "loud"`)
+}
+
+func TestFromContext(t *testing.T) {
+	require.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	logger, _ := newTestLogger(t, "")
+	require.Equal(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
+
+func TestSlowQueryObserver(t *testing.T) {
+	logger, buf := newTestLogger(t, "info")
+	observer := NewSlowQueryObserver(logger, 10*time.Millisecond)
+
+	_, done := observer.ObserveCall(context.Background(), "GetAccount")
+	done(nil)
+	require.Empty(t, buf.String())
+
+	// calls made for a request are logged with its logger
+	ctx := WithLogger(context.Background(), logger.With("request_id", "req-1"))
+	ctx, done = observer.ObserveCall(ctx, "TransferTx")
+	time.Sleep(15 * time.Millisecond)
+	done(nil)
+
+	line := lastLine(t, buf)
+	require.Equal(t, "WARN", line["level"])
+	require.Equal(t, "slow query", line["msg"])
+	require.Equal(t, "TransferTx", line["method"])
+	require.Equal(t, "req-1", line["request_id"])
+	require.GreaterOrEqual(t, line["duration_ms"], 10.0)
+}
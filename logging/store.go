@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlowQueryObserver warns about the calls made through a db.InstrumentedStore that take longer than a threshold
+type SlowQueryObserver struct {
+	logger    *slog.Logger
+	threshold time.Duration
+}
+
+// NewSlowQueryObserver warns with logger about store calls slower than threshold.
+// Calls made for a request are logged with the request's logger, so they carry its id
+func NewSlowQueryObserver(logger *slog.Logger, threshold time.Duration) *SlowQueryObserver {
+	return &SlowQueryObserver{
+		logger:    logger,
+		threshold: threshold,
+	}
+}
+
+// ObserveCall implements db.StoreObserver
+func (observer *SlowQueryObserver) ObserveCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	return ctx, func(err error) {
+		duration := time.Since(start)
+		if duration < observer.threshold {
+			return
+		}
+		logger := observer.logger
+		if reqLogger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			logger = reqLogger
+		}
+		attrs := []slog.Attr{
+			slog.String("method", method),
+			slog.Float64("duration_ms", Milliseconds(duration)),
+			slog.Float64("threshold_ms", Milliseconds(observer.threshold)),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, slog.LevelWarn, "slow query", attrs...)
+	}
+}
@@ -1,32 +1,287 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/joekings2k/gobank/api"
 	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/gapi"
+	"github.com/joekings2k/gobank/logging"
+	"github.com/joekings2k/gobank/metrics"
+	"github.com/joekings2k/gobank/tracing"
 	"github.com/joekings2k/gobank/util"
+	"github.com/joekings2k/gobank/worker"
 	_ "github.com/lib/pq"
+	"google.golang.org/grpc/reflection"
 )
 
 
 func main (){
+	os.Exit(run())
+}
+
+// run starts the service, or the command named by the first argument, and returns the exit code.
+// Errors return rather than exit so the deferred shutdowns, tracing's flush among them, still run
+func run() int {
+	// until the config says otherwise, log JSON from info up
+	logger, _ := logging.New(os.Stderr, "")
+	slog.SetDefault(logger)
+
 	config,err :=util.LoadConfig(".")//path to config file
 	if err != nil {
-		log.Fatal("cannot log configs")
+		return failed("cannot load config", err)
 	}
+	// without keys anyone could rehash an edited ledger or sign a forged report
+	if config.EntryHashKey == "" || config.ReportSigningKey == "" {
+		return failed("cannot load config", errors.New("ENTRY_HASH_KEY and REPORT_SIGNING_KEY must be set"))
+	}
+	logger, err = logging.New(os.Stderr, config.LogLevel)
+	if err != nil {
+		return failed("cannot create logger", err)
+	}
+	// the log package and the workers log through the default logger
+	slog.SetDefault(logger)
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+
 	conn,err := sql.Open(config.DBDriver,config.DBSource)
 	if err != nil{
-		log.Fatal("cannot connect to db:",err)
+		return failed("cannot connect to db", err)
 	}
-	store := db.NewStore(conn)
-	server ,err := api.NewServer(config ,store)
+	shutdownTracing, err := tracing.Setup(context.Background(), config)
+	if err != nil {
+		return failed("cannot set up tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+	observers := []db.StoreObserver{tracing.StoreObserver{}, metrics.StoreObserver{}}
+	if config.SlowQueryThreshold > 0 {
+		observers = append(observers, logging.NewSlowQueryObserver(logger, config.SlowQueryThreshold))
+	}
+	store := metrics.CountTransfers(db.NewInstrumentedStore(db.NewStore(conn, config.EntryHashKey), observers...))
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			return reconcile(store, config, os.Args[2:])
+		case "verify-entries":
+			return verifyEntries(store, config, os.Args[2:])
+		}
+	}
+
+	// SIGTERM, sent by Kubernetes before it kills the pod, stops the servers and workers gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	for _, w := range []interface{ Run(context.Context) }{
+		worker.NewRevocationSweeper(store, config.RevocationSweepInterval),
+		worker.NewIdempotencyKeySweeper(store, config.IdempotencyKeySweepInterval),
+		worker.NewHoldSweeper(store, config.HoldExpiryInterval),
+		worker.NewScheduler(store, config.ScheduledTransferInterval),
+		worker.NewSnapshotter(store, config.BalanceSnapshotInterval),
+	} {
+		workers.Go(func() { w.Run(ctx) })
+	}
+
+	var servers sync.WaitGroup
+	if config.GRPCServerAddress != "" {
+		servers.Go(func() { runGrpcServer(ctx, config, store) })
+		if config.GatewayServerAddress != "" {
+			servers.Go(func() { runGatewayServer(ctx, config) })
+		}
+	}
+
+	server ,err := api.NewServer(config ,store ,logger)
 	if err !=nil {
-		log.Fatal("cannot create server:", err)
+		return failed("cannot create server", err)
 	}
-	err= server.Start(config.ServerAddress)
+	slog.Info("serving HTTP", "address", config.ServerAddress)
+	err= server.Start(ctx, config.ServerAddress)
 	if err !=nil {
-		log.Fatal("cannot start server:", err)
+		return failed("cannot serve HTTP", err)
+	}
+
+	slog.Info("shutting down")
+	servers.Wait()
+	if !waitTimeout(&workers, config.ShutdownTimeout) {
+		slog.Warn("workers didn't stop in time", "timeout", config.ShutdownTimeout)
+	}
+	slog.Info("stopped")
+	return 0
+}
+
+// runGrpcServer serves the gRPC API on its own port next to the Gin server, until ctx is cancelled
+func runGrpcServer(ctx context.Context, config util.Config, store db.Store) {
+	server, err := gapi.NewServer(config, store)
+	if err != nil {
+		fatal("cannot create gRPC server", err)
+	}
+	grpcServer := gapi.NewGRPCServer(server)
+	reflection.Register(grpcServer)
+
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		fatal("cannot listen for gRPC", err)
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		// GracefulStop waits for the calls in flight, Stop cuts them once the timeout is up
+		shutdownCtx, cancel := shutdownContext(config.ShutdownTimeout)
+		defer cancel()
+		drained := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}()
+	slog.Info("serving gRPC", "address", listener.Addr().String())
+	if err := grpcServer.Serve(listener); err != nil {
+		fatal("cannot serve gRPC", err)
 	}
-}
\ No newline at end of file
+	<-stopped
+}
+
+// runGatewayServer serves the gRPC API as HTTP/JSON, proxying to the gRPC server, until ctx is cancelled
+func runGatewayServer(ctx context.Context, config util.Config) {
+	handler, err := gapi.NewGatewayHandler(ctx, config.GRPCServerAddress)
+	if err != nil {
+		fatal("cannot create gateway", err)
+	}
+	httpServer := &http.Server{
+		Addr:    config.GatewayServerAddress,
+		Handler: handler,
+	}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := shutdownContext(config.ShutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("cannot drain HTTP gateway", "error", err)
+		}
+	}()
+	slog.Info("serving HTTP gateway", "address", config.GatewayServerAddress)
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fatal("cannot serve HTTP gateway", err)
+	}
+	<-stopped
+}
+
+// shutdownContext bounds a shutdown by timeout, 0 waits as long as it takes
+func shutdownContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// waitTimeout waits for wg, for at most timeout when it is set, and reports whether it finished
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	ctx, cancel := shutdownContext(timeout)
+	defer cancel()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reconcile checks the ledger once, prints the report and returns the exit code, 1 when anything is off.
+// usage: main reconcile [-save] [-batch-size N]
+func reconcile(store db.Store, config util.Config, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	save := flags.Bool("save", false, "store the signed report")
+	batchSize := flags.Int("batch-size", int(config.ReconciliationBatchSize), "rows read per query")
+	flags.Parse(args)
+
+	reconciler := worker.NewReconciler(store, config.ReportSigningKey, int32(*batchSize))
+	report, err := reconciler.Reconcile(context.Background())
+	if err != nil {
+		return failed("cannot reconcile", err)
+	}
+	if *save {
+		saved, err := reconciler.Save(context.Background(), report)
+		if err != nil {
+			return failed("cannot save reconciliation report", err)
+		}
+		slog.Info("saved reconciliation report", "id", saved.ID)
+	}
+
+	if err := printReport(report); err != nil {
+		return failed("cannot print report", err)
+	}
+	if !report.Balanced() {
+		return 1
+	}
+	return 0
+}
+
+// verifyEntries walks the entry hash chain once, prints the breaks and returns the exit code, 1 when there are any.
+// usage: main verify-entries [-batch-size N]
+func verifyEntries(store db.Store, config util.Config, args []string) int {
+	flags := flag.NewFlagSet("verify-entries", flag.ExitOnError)
+	batchSize := flags.Int("batch-size", int(config.ReconciliationBatchSize), "entries read per query")
+	flags.Parse(args)
+
+	verifier := worker.NewEntryChainVerifier(store, config.EntryHashKey, int32(*batchSize))
+	report, err := verifier.Verify(context.Background())
+	if err != nil {
+		return failed("cannot verify entries", err)
+	}
+
+	if err := printReport(report); err != nil {
+		return failed("cannot print report", err)
+	}
+	if !report.Intact() {
+		return 1
+	}
+	return 0
+}
+
+func printReport(report interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// failed logs err as a structured line and returns the exit code for it
+func failed(msg string, err error) int {
+	slog.Error(msg, "error", err)
+	return 1
+}
+
+// fatal logs err and exits, like log.Fatal but as a structured line. It is for the server goroutines,
+// run returns failed instead so its deferred calls run
+func fatal(msg string, err error) {
+	os.Exit(failed(msg, err))
+}
@@ -0,0 +1,88 @@
+// Package metrics defines the Prometheus metrics of the bank and serves them for scraping
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "gobank"
+
+// reasons a login fails for, the label of login_failures_total
+const (
+	LoginUnknownUser   = "unknown_user"
+	LoginWrongPassword = "wrong_password"
+)
+
+var (
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to answer HTTP requests, by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	storeCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "call_duration_seconds",
+		Help:      "Time taken by store methods, by method and outcome.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"method", "outcome"})
+
+	storeTxRollbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "store",
+		Name:      "tx_rollbacks_total",
+		Help:      "Transactions rolled back, by the store method that ran them.",
+	}, []string{"method"})
+
+	transfersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Transfers settled, by the currency they were sent in and the store method that settled them.",
+	}, []string{"currency", "method"})
+
+	transferAmount = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfer_amount_total",
+		Help:      "Amount sent by settled transfers in minor units, by currency.",
+	}, []string{"currency"})
+
+	transfersRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_rejected_total",
+		Help:      "Transfers refused by the ledger, by reason.",
+	}, []string{"reason"})
+
+	loginFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Failed logins, by reason.",
+	}, []string{"reason"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveHTTPRequest records an answered HTTP request. route is the pattern that matched,
+// not the path, so ids don't explode the number of series
+func ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// LoginFailed counts a failed login, reason is LoginUnknownUser or LoginWrongPassword
+func LoginFailed(reason string) {
+	loginFailures.WithLabelValues(reason).Inc()
+}
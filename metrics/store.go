@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+// outcomes of a store call, the label of call_duration_seconds
+const (
+	outcomeOK       = "ok"
+	outcomeNotFound = "not_found"
+	outcomeError    = "error"
+)
+
+// StoreObserver times every call made through a db.InstrumentedStore,
+// and counts the rollbacks of the transactions they run
+type StoreObserver struct{}
+
+// ObserveCall implements db.StoreObserver
+func (StoreObserver) ObserveCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	// only the Tx methods run transactions
+	if strings.HasSuffix(method, "Tx") {
+		ctx = db.WithTxTrace(ctx, &db.TxTrace{
+			Rollback: func(error) {
+				storeTxRollbacks.WithLabelValues(method).Inc()
+			},
+		})
+	}
+	return ctx, func(err error) {
+		storeCallDuration.WithLabelValues(method, outcome(err)).Observe(time.Since(start).Seconds())
+	}
+}
+
+// outcome sorts a store error, a missing row is how many lookups normally end
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return outcomeOK
+	case errors.Is(err, sql.ErrNoRows):
+		return outcomeNotFound
+	}
+	return outcomeError
+}
+
+// transferStore counts the transfers settled through a store
+type transferStore struct {
+	db.Store
+}
+
+// CountTransfers wraps store to count the transfers it settles and the volume they move per currency,
+// whether they come from the API, the gRPC server or the workers
+func CountTransfers(store db.Store) db.Store {
+	return transferStore{Store: store}
+}
+
+func (store transferStore) TransferTx(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+	result, err := store.Store.TransferTx(ctx, arg)
+	countTransfer("TransferTx", &result, err)
+	return result, err
+}
+
+func (store transferStore) CaptureTransferTx(ctx context.Context, transferID int64) (db.TransferTxResult, error) {
+	result, err := store.Store.CaptureTransferTx(ctx, transferID)
+	countTransfer("CaptureTransferTx", &result, err)
+	return result, err
+}
+
+func (store transferStore) ReverseTransferTx(ctx context.Context, arg db.ReverseTransferTxParams) (db.TransferTxResult, error) {
+	result, err := store.Store.ReverseTransferTx(ctx, arg)
+	countTransfer("ReverseTransferTx", &result, err)
+	return result, err
+}
+
+func (store transferStore) ExecuteScheduledTransferTx(ctx context.Context) (db.ExecuteScheduledTransferTxResult, error) {
+	result, err := store.Store.ExecuteScheduledTransferTx(ctx)
+	countExecution("ExecuteScheduledTransferTx", result.Transfer, result.Failure, err)
+	return result, err
+}
+
+func (store transferStore) ExecuteStandingOrderTx(ctx context.Context) (db.ExecuteStandingOrderTxResult, error) {
+	result, err := store.Store.ExecuteStandingOrderTx(ctx)
+	countExecution("ExecuteStandingOrderTx", result.Transfer, result.Failure, err)
+	return result, err
+}
+
+func (store transferStore) ExecutePaymentBatchItemTx(ctx context.Context) (db.ExecutePaymentBatchItemTxResult, error) {
+	result, err := store.Store.ExecutePaymentBatchItemTx(ctx)
+	countExecution("ExecutePaymentBatchItemTx", result.Transfer, result.Failure, err)
+	return result, err
+}
+
+// countTransfer counts a settled transfer, or the reason the ledger refused it
+func countTransfer(method string, result *db.TransferTxResult, err error) {
+	if err != nil {
+		if reason := rejectionReason(err); reason != "" {
+			transfersRejected.WithLabelValues(reason).Inc()
+		}
+		return
+	}
+	if result == nil {
+		return
+	}
+	currency := result.FromAccount.Currency
+	transfersTotal.WithLabelValues(currency, method).Inc()
+	transferAmount.WithLabelValues(currency).Add(float64(result.Transfer.Amount))
+}
+
+// countExecution counts what a worker did with a due transfer. A refused transfer isn't returned as an error,
+// the worker records it as failed, so it is counted from the failure. Nothing is counted when nothing was due
+func countExecution(method string, result *db.TransferTxResult, failure error, err error) {
+	if err == nil && failure != nil {
+		reason := rejectionReason(failure)
+		if reason == "" {
+			reason = "ledger_refused"
+		}
+		transfersRejected.WithLabelValues(reason).Inc()
+		return
+	}
+	countTransfer(method, result, err)
+}
+
+// rejectionReason is the transfers_rejected_total label of a business rule the transfer broke, "" for other errors
+func rejectionReason(err error) string {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return "insufficient_funds"
+	case errors.Is(err, db.ErrNoExchangeRate):
+		return "no_exchange_rate"
+	case errors.Is(err, db.ErrAmountNotConvertible):
+		return "amount_not_convertible"
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+)
+
+// sampleCount is how many observations a histogram holds
+func sampleCount(t *testing.T, observer prometheus.Observer) uint64 {
+	var metric dto.Metric
+	require.NoError(t, observer.(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestStoreObserver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	account := db.Account{ID: util.RandomInt(1, 1000), Currency: util.USD}
+	mockStore := mockdb.NewMockStore(ctrl)
+	mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	mockStore.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
+
+	ok := storeCallDuration.WithLabelValues("GetAccount", outcomeOK)
+	notFound := storeCallDuration.WithLabelValues("GetUser", outcomeNotFound)
+	okBefore, notFoundBefore := sampleCount(t, ok), sampleCount(t, notFound)
+
+	store := db.NewInstrumentedStore(mockStore, StoreObserver{})
+	_, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	_, err = store.GetUser(context.Background(), util.RandomOwner())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.Equal(t, okBefore+1, sampleCount(t, ok))
+	require.Equal(t, notFoundBefore+1, sampleCount(t, notFound))
+}
+
+func TestCountTransfers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	result := db.TransferTxResult{
+		Transfer:    db.Transfer{ID: 1, Amount: 150},
+		FromAccount: db.Account{Currency: util.CAD},
+	}
+	mockStore := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(result, nil),
+		mockStore.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds),
+	)
+	mockStore.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ExecuteScheduledTransferTxResult{}, nil)
+
+	count := transfersTotal.WithLabelValues(util.CAD, "TransferTx")
+	amount := transferAmount.WithLabelValues(util.CAD)
+	rejected := transfersRejected.WithLabelValues("insufficient_funds")
+	countBefore, amountBefore, rejectedBefore := testutil.ToFloat64(count), testutil.ToFloat64(amount), testutil.ToFloat64(rejected)
+
+	store := CountTransfers(mockStore)
+	_, err := store.TransferTx(context.Background(), db.TransferTxParams{Amount: 150})
+	require.NoError(t, err)
+	_, err = store.TransferTx(context.Background(), db.TransferTxParams{Amount: 150})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+	// a worker result with neither a transfer nor a failure counts nothing
+	_, err = store.ExecuteScheduledTransferTx(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, countBefore+1, testutil.ToFloat64(count))
+	require.Equal(t, amountBefore+150, testutil.ToFloat64(amount))
+	require.Equal(t, rejectedBefore+1, testutil.ToFloat64(rejected))
+}
+
+func TestCountWorkerFailures(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the workers record refused transfers as failed and return no error
+	mockStore := mockdb.NewMockStore(ctrl)
+	mockStore.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).
+		Return(db.ExecuteScheduledTransferTxResult{Failure: fmt.Errorf("%w: account [1]", db.ErrInsufficientFunds)}, nil)
+	mockStore.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(1).
+		Return(db.ExecuteStandingOrderTxResult{Failure: db.ErrNoExchangeRate}, nil)
+	gomock.InOrder(
+		mockStore.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Times(1).
+			Return(db.ExecutePaymentBatchItemTxResult{Failure: &pq.Error{Code: "22003"}}, nil),
+		// nothing pending
+		mockStore.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Times(1).
+			Return(db.ExecutePaymentBatchItemTxResult{}, sql.ErrNoRows),
+	)
+
+	insufficientFunds := transfersRejected.WithLabelValues("insufficient_funds")
+	noExchangeRate := transfersRejected.WithLabelValues("no_exchange_rate")
+	ledgerRefused := transfersRejected.WithLabelValues("ledger_refused")
+	insufficientFundsBefore := testutil.ToFloat64(insufficientFunds)
+	noExchangeRateBefore := testutil.ToFloat64(noExchangeRate)
+	ledgerRefusedBefore := testutil.ToFloat64(ledgerRefused)
+
+	store := CountTransfers(mockStore)
+	_, err := store.ExecuteScheduledTransferTx(context.Background())
+	require.NoError(t, err)
+	_, err = store.ExecuteStandingOrderTx(context.Background())
+	require.NoError(t, err)
+	_, err = store.ExecutePaymentBatchItemTx(context.Background())
+	require.NoError(t, err)
+	_, err = store.ExecutePaymentBatchItemTx(context.Background())
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.Equal(t, insufficientFundsBefore+1, testutil.ToFloat64(insufficientFunds))
+	require.Equal(t, noExchangeRateBefore+1, testutil.ToFloat64(noExchangeRate))
+	require.Equal(t, ledgerRefusedBefore+1, testutil.ToFloat64(ledgerRefused))
+}
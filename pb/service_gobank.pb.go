@@ -0,0 +1,108 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: service_gobank.proto
+
+package pb
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var File_service_gobank_proto protoreflect.FileDescriptor
+
+const file_service_gobank_proto_rawDesc = "" +
+	"\n" +
+	"\x14service_gobank.proto\x12\x02pb\x1a\raccount.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x0etransfer.proto\x1a\n" +
+	"user.proto2\xd2\x05\n" +
+	"\x06GoBank\x12Q\n" +
+	"\n" +
+	"CreateUser\x12\x15.pb.CreateUserRequest\x1a\x16.pb.CreateUserResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/users\x12T\n" +
+	"\tLoginUser\x12\x14.pb.LoginUserRequest\x1a\x15.pb.LoginUserResponse\"\x1a\x82\xd3\xe4\x93\x02\x14:\x01*\"\x0f/v1/users/login\x12P\n" +
+	"\aGetUser\x12\x12.pb.GetUserRequest\x1a\x13.pb.GetUserResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/v1/users/{username}\x12]\n" +
+	"\rCreateAccount\x12\x18.pb.CreateAccountRequest\x1a\x19.pb.CreateAccountResponse\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/accounts\x12V\n" +
+	"\n" +
+	"GetAccount\x12\x15.pb.GetAccountRequest\x1a\x16.pb.GetAccountResponse\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/accounts/{id}\x12W\n" +
+	"\fListAccounts\x12\x17.pb.ListAccountsRequest\x1a\x18.pb.ListAccountsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/accounts\x12a\n" +
+	"\x0eCreateTransfer\x12\x19.pb.CreateTransferRequest\x1a\x1a.pb.CreateTransferResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/v1/transfers\x12Z\n" +
+	"\vGetTransfer\x12\x16.pb.GetTransferRequest\x1a\x17.pb.GetTransferResponse\"\x1a\x82\xd3\xe4\x93\x02\x14\x12\x12/v1/transfers/{id}B!Z\x1fgithub.com/joekings2k/gobank/pbb\x06proto3"
+
+var file_service_gobank_proto_goTypes = []any{
+	(*CreateUserRequest)(nil),      // 0: pb.CreateUserRequest
+	(*LoginUserRequest)(nil),       // 1: pb.LoginUserRequest
+	(*GetUserRequest)(nil),         // 2: pb.GetUserRequest
+	(*CreateAccountRequest)(nil),   // 3: pb.CreateAccountRequest
+	(*GetAccountRequest)(nil),      // 4: pb.GetAccountRequest
+	(*ListAccountsRequest)(nil),    // 5: pb.ListAccountsRequest
+	(*CreateTransferRequest)(nil),  // 6: pb.CreateTransferRequest
+	(*GetTransferRequest)(nil),     // 7: pb.GetTransferRequest
+	(*CreateUserResponse)(nil),     // 8: pb.CreateUserResponse
+	(*LoginUserResponse)(nil),      // 9: pb.LoginUserResponse
+	(*GetUserResponse)(nil),        // 10: pb.GetUserResponse
+	(*CreateAccountResponse)(nil),  // 11: pb.CreateAccountResponse
+	(*GetAccountResponse)(nil),     // 12: pb.GetAccountResponse
+	(*ListAccountsResponse)(nil),   // 13: pb.ListAccountsResponse
+	(*CreateTransferResponse)(nil), // 14: pb.CreateTransferResponse
+	(*GetTransferResponse)(nil),    // 15: pb.GetTransferResponse
+}
+var file_service_gobank_proto_depIdxs = []int32{
+	0,  // 0: pb.GoBank.CreateUser:input_type -> pb.CreateUserRequest
+	1,  // 1: pb.GoBank.LoginUser:input_type -> pb.LoginUserRequest
+	2,  // 2: pb.GoBank.GetUser:input_type -> pb.GetUserRequest
+	3,  // 3: pb.GoBank.CreateAccount:input_type -> pb.CreateAccountRequest
+	4,  // 4: pb.GoBank.GetAccount:input_type -> pb.GetAccountRequest
+	5,  // 5: pb.GoBank.ListAccounts:input_type -> pb.ListAccountsRequest
+	6,  // 6: pb.GoBank.CreateTransfer:input_type -> pb.CreateTransferRequest
+	7,  // 7: pb.GoBank.GetTransfer:input_type -> pb.GetTransferRequest
+	8,  // 8: pb.GoBank.CreateUser:output_type -> pb.CreateUserResponse
+	9,  // 9: pb.GoBank.LoginUser:output_type -> pb.LoginUserResponse
+	10, // 10: pb.GoBank.GetUser:output_type -> pb.GetUserResponse
+	11, // 11: pb.GoBank.CreateAccount:output_type -> pb.CreateAccountResponse
+	12, // 12: pb.GoBank.GetAccount:output_type -> pb.GetAccountResponse
+	13, // 13: pb.GoBank.ListAccounts:output_type -> pb.ListAccountsResponse
+	14, // 14: pb.GoBank.CreateTransfer:output_type -> pb.CreateTransferResponse
+	15, // 15: pb.GoBank.GetTransfer:output_type -> pb.GetTransferResponse
+	8,  // [8:16] is the sub-list for method output_type
+	0,  // [0:8] is the sub-list for method input_type
+	0,  // [0:0] is the sub-list for extension type_name
+	0,  // [0:0] is the sub-list for extension extendee
+	0,  // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_service_gobank_proto_init() }
+func file_service_gobank_proto_init() {
+	if File_service_gobank_proto != nil {
+		return
+	}
+	file_account_proto_init()
+	file_transfer_proto_init()
+	file_user_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_service_gobank_proto_rawDesc), len(file_service_gobank_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_service_gobank_proto_goTypes,
+		DependencyIndexes: file_service_gobank_proto_depIdxs,
+	}.Build()
+	File_service_gobank_proto = out.File
+	file_service_gobank_proto_goTypes = nil
+	file_service_gobank_proto_depIdxs = nil
+}
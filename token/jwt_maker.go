@@ -21,17 +21,18 @@ func NewJWTMaker(secretKey string)(Maker,error){
 }
 
 // createToken creates a new token for a specific username and duration
-	func (maker *JWTMaker)  CreateToken(username string, duration time.Duration) (string, error){
-		payload,err := NewPayload(username,duration)
+	func (maker *JWTMaker)  CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error){
+		payload,err := NewPayload(username,role,tokenType,duration)
 		if err != nil {
-			return "", err
+			return "", payload, err
 		}
 		jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256,payload)
-		return  jwtToken.SignedString([]byte(maker.secretKey))
+		token, err := jwtToken.SignedString([]byte(maker.secretKey))
+		return token, payload, err
 	}
 
 	// check if token is valid
-	func (maker *JWTMaker) VerifyToken(token string) (*Payload, error ) {
+	func (maker *JWTMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error ) {
 		Keyfunc :=  func(token *jwt.Token) (interface{}, error){
 			_,ok := token.Method.(*jwt.SigningMethodHMAC)
 			if !ok {
@@ -51,5 +52,8 @@ func NewJWTMaker(secretKey string)(Maker,error){
 		if !ok {
 			return nil ,ErrInvalidToken
 		}
+		if payload.Type != tokenType {
+			return nil, ErrWrongTokenType
+		}
 		return payload, nil
 	}
\ No newline at end of file
@@ -14,20 +14,24 @@ func TestJWTMaker(t *testing.T){
 	maker, err:= NewJWTMaker(util.RandomString(32))
 	require.NoError(t,err)
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username,duration)
+	token, payload, err := maker.CreateToken(username,role,AccessToken,duration)
 	require.NoError(t,err)
 	require.NotEmpty(t,token)
+	require.NotEmpty(t,payload)
 
-	payload ,err := maker.VerifyToken(token)
+	payload ,err = maker.VerifyToken(token, AccessToken)
 	require.NoError(t,err)
 	require.NotEmpty(t,payload)
 
 	require.NotZero(t,payload.ID)
 	require.Equal(t,username, payload.Username)
+	require.Equal(t,role, payload.Role)
+	require.Equal(t,AccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t,expiredAt, payload.ExpiredAt,time.Second)
 }
@@ -36,18 +40,19 @@ func TestExpiredJWTToken(t *testing.T) {
 	maker,err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t,err)
 
-	token ,err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, -time.Minute)
 	require.NoError(t,err)
 	require.NotEmpty(t,token)
+	require.NotEmpty(t,payload)
 
-	payload,err := maker.VerifyToken(token)
+	payload,err = maker.VerifyToken(token, AccessToken)
 	require.Error(t,err)
 	require.EqualError(t,err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload ,err := NewPayload(util.RandomOwner(), time.Minute)
+	payload ,err := NewPayload(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
 	require.NoError(t,err)
 	
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
@@ -57,7 +62,7 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	maker,err := NewJWTMaker(util.RandomString(32))
 	require.NoError(t,err)
 
-	payload,err = maker.VerifyToken(token)
+	payload,err = maker.VerifyToken(token, AccessToken)
 	require.Error(t,err)
 	require.EqualError(t,err,ErrInvalidToken.Error())
 	require.Nil(t,payload)
@@ -66,4 +71,14 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 func TestSecretkeyLength(t *testing.T) {
 	_, err := NewJWTMaker(util.RandomString(12))
 	require.Error(t,err)
-}
\ No newline at end of file
+}
+func TestJWTWrongTokenType(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	refreshToken, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, RefreshToken, time.Minute)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(refreshToken, AccessToken)
+	require.ErrorIs(t, err, ErrWrongTokenType)
+	require.Nil(t, payload)
+}
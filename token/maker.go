@@ -4,9 +4,9 @@ import "time"
 
 // maker is an interface for managing tokens
 type Maker interface {
-	// createToken creates a new token for a specific username and duration
-	CreateToken(username string, duration time.Duration) (string, error)
+	// createToken creates a new token of a type for a specific username, role and duration
+	CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error)
 
-	// check if token is valid
-	VerifyToken(token string) (*Payload, error )
+	// check if token is valid and of the expected type
+	VerifyToken(token string, tokenType TokenType) (*Payload, error )
 }
\ No newline at end of file
@@ -24,16 +24,17 @@ func NewPasetoMaker(symmetricKey string) (Maker,error) {
 	return maker, nil
 }
 
-func (maker *PasetoMaker) CreateToken(username string, duration time.Duration) (string, error) {
-	payload ,err := NewPayload(username, duration)
+func (maker *PasetoMaker) CreateToken(username string, role string, tokenType TokenType, duration time.Duration) (string, *Payload, error) {
+	payload ,err := NewPayload(username, role, tokenType, duration)
 	if err != nil {
-		return "",err
+		return "", payload, err
 	}
-	return maker.paseto.Encrypt(maker.symmetricKey,payload,nil)
+	token, err := maker.paseto.Encrypt(maker.symmetricKey,payload,nil)
+	return token, payload, err
 }
 
 	// check if token is valid
-func (maker *PasetoMaker)VerifyToken(token string) (*Payload, error ){
+func (maker *PasetoMaker)VerifyToken(token string, tokenType TokenType) (*Payload, error ){
 	payload  := &Payload{}
 
 	err := maker.paseto.Decrypt(token, maker.symmetricKey, payload, nil)
@@ -44,5 +45,8 @@ func (maker *PasetoMaker)VerifyToken(token string) (*Payload, error ){
 	if err != nil {
 		return nil, err
 	}
+	if payload.Type != tokenType {
+		return nil, ErrWrongTokenType
+	}
 	return payload, nil
 }
\ No newline at end of file
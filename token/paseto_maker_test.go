@@ -12,20 +12,24 @@ func TestPasteoMaker (t *testing.T){
 	maker, err:= NewPasetoMaker(util.RandomString(32))
 	require.NoError(t,err)
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, err := maker.CreateToken(username,duration)
+	token, payload, err := maker.CreateToken(username,role,AccessToken,duration)
 	require.NoError(t,err)
 	require.NotEmpty(t,token)
+	require.NotEmpty(t,payload)
 
-	payload ,err := maker.VerifyToken(token)
+	payload ,err = maker.VerifyToken(token, AccessToken)
 	require.NoError(t,err)
 	require.NotEmpty(t,payload)
 
 	require.NotZero(t,payload.ID)
 	require.Equal(t,username, payload.Username)
+	require.Equal(t,role, payload.Role)
+	require.Equal(t,AccessToken, payload.Type)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t,expiredAt, payload.ExpiredAt,time.Second)
 }
@@ -34,11 +38,12 @@ func TestExpiredPasetoToken(t *testing.T) {
 	maker,err := NewPasetoMaker(util.RandomString(32))
 	require.NoError(t,err)
 
-	token ,err := maker.CreateToken(util.RandomOwner(), -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, -time.Minute)
 	require.NoError(t,err)
 	require.NotEmpty(t,token)
+	require.NotEmpty(t,payload)
 
-	payload,err := maker.VerifyToken(token)
+	payload,err = maker.VerifyToken(token, AccessToken)
 	require.Error(t,err)
 	require.EqualError(t,err, ErrExpiredToken.Error())
 	require.Nil(t, payload)
@@ -53,15 +58,34 @@ func TestPasteoInvalidToken(t *testing.T) {
 	maker, err:= NewPasetoMaker(util.RandomString(32))
 	require.NoError(t,err)
 	username := util.RandomOwner()
+	role := util.DepositorRole
 	duration := time.Minute
 	
 	
 
-	token, err := maker.CreateToken(username,duration)
+	token, payload, err := maker.CreateToken(username,role,AccessToken,duration)
 	require.NoError(t,err)
 	require.NotEmpty(t,token)
+	require.NotEmpty(t,payload)
 
-	payload ,err := maker.VerifyToken("")
+	payload ,err = maker.VerifyToken("", AccessToken)
 	require.Error(t,err)
 	require.Empty(t,payload)
-}
\ No newline at end of file
+}
+func TestPasetoWrongTokenType(t *testing.T) {
+	maker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	// a refresh token can't be used as an access token, nor the other way around
+	refreshToken, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, RefreshToken, time.Minute)
+	require.NoError(t, err)
+	payload, err := maker.VerifyToken(refreshToken, AccessToken)
+	require.ErrorIs(t, err, ErrWrongTokenType)
+	require.Nil(t, payload)
+
+	accessToken, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, AccessToken, time.Minute)
+	require.NoError(t, err)
+	payload, err = maker.VerifyToken(accessToken, RefreshToken)
+	require.ErrorIs(t, err, ErrWrongTokenType)
+	require.Nil(t, payload)
+}
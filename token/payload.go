@@ -9,22 +9,37 @@ import (
 var( 
 	ErrInvalidToken = errors.New("token is invalid")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrRevokedToken = errors.New("token has been revoked")
+	ErrWrongTokenType = errors.New("token is of the wrong type")
 )
+
+// TokenType tells access tokens, sent with every request, from refresh tokens, only good for renewing them
+type TokenType string
+
+const (
+	AccessToken  TokenType = "access"
+	RefreshToken TokenType = "refresh"
+)
+
 type Payload struct {
 	ID 				uuid.UUID 	`json:"id"`
+	Type 			TokenType 	`json:"token_type"`
 	Username 	string 			`json:"username"`
+	Role 			string 			`json:"role"`
 	IssuedAt 	time.Time 	`json:"issued_at"`
 	ExpiredAt time.Time 	`json:"expired_at"`
 }
 
-func NewPayload(username string ,duration time.Duration) (*Payload, error) {
+func NewPayload(username string, role string, tokenType TokenType, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 	payload := &Payload{
 		ID: tokenID,
+		Type: tokenType,
 		Username: username,
+		Role: role,
 		IssuedAt: time.Now(),
 		ExpiredAt: time.Now().Add(duration),
 	}
@@ -0,0 +1,37 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/joekings2k/gobank/tracing"
+
+// StoreObserver opens a span for every call made through a db.InstrumentedStore.
+// The transactions and queries the call runs are traced by the store as children of it
+type StoreObserver struct{}
+
+// ObserveCall implements db.StoreObserver
+func (StoreObserver) ObserveCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "store."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(method),
+		),
+	)
+	return ctx, func(err error) {
+		// a missing row is how many lookups normally end
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/joekings2k/gobank/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider keeping the spans ended during the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func TestStoreObserver(t *testing.T) {
+	recorder := recordSpans(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	mockStore := mockdb.NewMockStore(ctrl)
+	mockStore.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.Account, error) {
+			// the store runs under the span of the call
+			require.True(t, trace.SpanContextFromContext(ctx).IsValid())
+			require.NotEqual(t, parent.SpanContext().SpanID(), trace.SpanContextFromContext(ctx).SpanID())
+			return db.Account{}, sql.ErrNoRows
+		})
+	mockStore.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, errors.New("connection reset"))
+
+	store := db.NewInstrumentedStore(mockStore, StoreObserver{})
+	_, err := store.GetAccount(ctx, util.RandomInt(1, 1000))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = store.GetUser(ctx, util.RandomOwner())
+	require.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	getAccount := spans[0]
+	require.Equal(t, "store.GetAccount", getAccount.Name())
+	require.Equal(t, parent.SpanContext().SpanID(), getAccount.Parent().SpanID())
+	// a missing row isn't a failure
+	require.Equal(t, codes.Unset, getAccount.Status().Code)
+
+	getUser := spans[1]
+	require.Equal(t, "store.GetUser", getUser.Name())
+	require.Equal(t, codes.Error, getUser.Status().Code)
+	require.Equal(t, "connection reset", getUser.Status().Description)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), util.Config{})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), util.Config{TraceExporter: "jaeger"})
+	require.ErrorContains(t, err, `unknown trace exporter "jaeger"`)
+}
@@ -0,0 +1,65 @@
+// Package tracing sets up OpenTelemetry tracing for the bank and traces the calls made to the store
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/joekings2k/gobank/util"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+const (
+	serviceName = "gobank"
+
+	// exporters TRACE_EXPORTER selects
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Setup installs the global tracer provider and the W3C trace-context and baggage propagators.
+// Spans go to the exporter config names, without one they are not recorded but the trace context
+// of incoming requests is still passed on. The returned func flushes the spans left and stops the provider
+func Setup(ctx context.Context, config util.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.TraceExporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracegrpc.New(ctx, otlptracegrpc.WithEndpointURL(config.OTLPEndpoint))
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q, use %s or %s", config.TraceExporter, ExporterOTLP, ExporterStdout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot create %s trace exporter: %w", config.TraceExporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe the service: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
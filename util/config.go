@@ -11,8 +11,35 @@ type Config struct {
 	DBDriver string `mapstructure:"DB_DRIVER"`
 	DBSource string `mapstructure:"DB_SOURCE"`
 	ServerAddress string `mapstructure:"SERVER_ADDRESS"`
+	// GRPCServerAddress serves the gRPC API, empty disables it
+	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+	// GatewayServerAddress serves the gRPC API as HTTP/JSON, empty disables it
+	GatewayServerAddress string `mapstructure:"GATEWAY_SERVER_ADDRESS"`
 	TokenSymmeticKey string `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	// EntryHashKey keys the hashes chaining ledger entries, it must never change once entries are hashed with it
+	EntryHashKey string `mapstructure:"ENTRY_HASH_KEY"`
+	// ReportSigningKey signs saved reconciliation reports, changing it fails the check of every report signed before
+	ReportSigningKey string `mapstructure:"REPORT_SIGNING_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	RevocationSweepInterval time.Duration `mapstructure:"REVOCATION_SWEEP_INTERVAL"`
+	IdempotencyKeyTTL time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	IdempotencyKeySweepInterval time.Duration `mapstructure:"IDEMPOTENCY_KEY_SWEEP_INTERVAL"`
+	ScheduledTransferInterval time.Duration `mapstructure:"SCHEDULED_TRANSFER_INTERVAL"`
+	HoldDuration time.Duration `mapstructure:"HOLD_DURATION"`
+	HoldExpiryInterval time.Duration `mapstructure:"HOLD_EXPIRY_INTERVAL"`
+	ReconciliationBatchSize int32 `mapstructure:"RECONCILIATION_BATCH_SIZE"`
+	BalanceSnapshotInterval time.Duration `mapstructure:"BALANCE_SNAPSHOT_INTERVAL"`
+	// TraceExporter sends spans to an OTLP collector with "otlp" or prints them with "stdout", empty disables tracing
+	TraceExporter string `mapstructure:"TRACE_EXPORTER"`
+	// OTLPEndpoint is the URL of the collector the otlp exporter sends spans to over gRPC
+	OTLPEndpoint string `mapstructure:"OTLP_ENDPOINT"`
+	// LogLevel is the lowest level logged: debug, info, warn or error
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// SlowQueryThreshold is how long a store call may take before it is logged as slow, 0 disables the warning
+	SlowQueryThreshold time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+	// ShutdownTimeout is how long the servers and workers get to finish their work once asked to stop, 0 waits for them
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
 }
 
 
@@ -1,6 +1,12 @@
 package util
 
-
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
 
 const (
 	USD = "USD"
@@ -8,10 +14,105 @@ const (
 	EUR = "EUR"
 )
 
+var (
+	// ErrAmountTooSmall is returned when an amount converts to less than half a minor unit
+	ErrAmountTooSmall = errors.New("converted amount rounds to zero")
+	// ErrAmountOverflow is returned when a converted amount doesn't fit in 64 bits
+	ErrAmountOverflow = errors.New("converted amount overflows")
+)
+
 func IsSuppoertedCurrency(currency string) bool {
 	switch currency{
 	case USD, EUR, CAD:
 		return true
 	}
 	return false
-}
\ No newline at end of file
+}
+
+// rates are stored as numeric(20,10), which takes up to 10 digits on either side of the point
+const (
+	rateIntegerDigits  = 10
+	rateFractionDigits = 10
+)
+
+// ParseRate parses a positive decimal exchange rate such as "1.0825". Only plain decimals
+// Postgres stores exactly are accepted, no fractions, exponents or more than 10 decimal places
+func ParseRate(rate string) (*big.Rat, error) {
+	whole, fraction, hasPoint := strings.Cut(rate, ".")
+	invalid := whole == "" || len(whole) > rateIntegerDigits || len(fraction) > rateFractionDigits || hasPoint && fraction == ""
+	for _, c := range whole + fraction {
+		if c < '0' || c > '9' {
+			invalid = true
+		}
+	}
+	if invalid {
+		return nil, fmt.Errorf("invalid exchange rate %q, it must be a decimal with at most %d digits before and %d after the point",
+			rate, rateIntegerDigits, rateFractionDigits)
+	}
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid exchange rate %q, it must be greater than zero", rate)
+	}
+	return r, nil
+}
+
+// ConvertAmount converts an amount in minor units at the given rate,
+// rounding half away from zero to the nearest minor unit.
+// A non-zero amount never converts to zero, that is ErrAmountTooSmall
+func ConvertAmount(amount int64, rate string) (int64, error) {
+	r, err := ParseRate(rate)
+	if err != nil {
+		return 0, err
+	}
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r)
+
+	num := new(big.Int).Abs(converted.Num())
+	quo, rem := new(big.Int).QuoRem(num, converted.Denom(), new(big.Int))
+	if new(big.Int).Mul(rem, big.NewInt(2)).Cmp(converted.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if !quo.IsInt64() {
+		return 0, fmt.Errorf("%w: %d at %s", ErrAmountOverflow, amount, rate)
+	}
+	if quo.Sign() == 0 && amount != 0 {
+		return 0, fmt.Errorf("%w: %d at %s", ErrAmountTooSmall, amount, rate)
+	}
+	if converted.Sign() < 0 {
+		return -quo.Int64(), nil
+	}
+	return quo.Int64(), nil
+}
+
+// FormatAmount formats an amount in minor units as a decimal, all supported currencies have two decimals
+func FormatAmount(amount int64) string {
+	sign := ""
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = uint64(-amount)
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, abs/100, abs%100)
+}
+
+// ParseAmount parses a positive decimal amount such as "12.50" into minor units,
+// all supported currencies have two decimals
+func ParseAmount(amount string) (int64, error) {
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || len(fraction) > 2 || strings.Contains(amount, ".") && fraction == "" {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	digits := whole + fraction
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid amount %q", amount)
+		}
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || minor == 0 {
+		return 0, fmt.Errorf("invalid amount %q", amount)
+	}
+	return minor, nil
+}
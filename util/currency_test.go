@@ -0,0 +1,116 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		rate     string
+		expected int64
+	}{
+		{amount: 1000, rate: "1", expected: 1000},
+		{amount: 1000, rate: "1.0825", expected: 1083},
+		{amount: 1000, rate: "0.9238", expected: 924},
+		{amount: 3, rate: "0.5", expected: 2},
+		{amount: -3, rate: "0.5", expected: -2},
+	}
+	for _, tc := range testCases {
+		converted, err := ConvertAmount(tc.amount, tc.rate)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted)
+	}
+
+	_, err := ConvertAmount(1000, "0")
+	require.Error(t, err)
+	// a cent at a low rate would credit nothing
+	_, err = ConvertAmount(1, "0.4")
+	require.ErrorIs(t, err, ErrAmountTooSmall)
+	_, err = ConvertAmount(math.MaxInt64, "2")
+	require.ErrorIs(t, err, ErrAmountOverflow)
+	_, err = ConvertAmount(1000, "abc")
+	require.Error(t, err)
+}
+
+func TestFormatAmount(t *testing.T) {
+	testCases := []struct {
+		amount   int64
+		expected string
+	}{
+		{amount: 0, expected: "0.00"},
+		{amount: 5, expected: "0.05"},
+		{amount: 1234, expected: "12.34"},
+		{amount: -5, expected: "-0.05"},
+		{amount: -100000, expected: "-1000.00"},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, FormatAmount(tc.amount))
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	testCases := []struct {
+		rate  string
+		valid bool
+	}{
+		{rate: "1.0825", valid: true},
+		{rate: "2", valid: true},
+		{rate: "0.0000000001", valid: true},
+		{rate: "9999999999.9999999999", valid: true},
+		{rate: "0"},
+		{rate: "0.0000"},
+		{rate: "-1.5"},
+		{rate: "+1.5"},
+		{rate: "1/3"},
+		{rate: "1e3"},
+		{rate: "1.5E-2"},
+		{rate: "0.00000000001"},
+		{rate: "10000000000"},
+		{rate: "1."},
+		{rate: ".5"},
+		{rate: "1,5"},
+		{rate: ""},
+	}
+	for _, tc := range testCases {
+		_, err := ParseRate(tc.rate)
+		if tc.valid {
+			require.NoError(t, err, tc.rate)
+		} else {
+			require.Error(t, err, tc.rate)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	testCases := []struct {
+		amount   string
+		expected int64
+		valid    bool
+	}{
+		{amount: "12.34", expected: 1234, valid: true},
+		{amount: "12.5", expected: 1250, valid: true},
+		{amount: "7", expected: 700, valid: true},
+		{amount: "0.01", expected: 1, valid: true},
+		{amount: "0.00"},
+		{amount: "1.234"},
+		{amount: "-5"},
+		{amount: "1."},
+		{amount: ".50"},
+		{amount: "1,50"},
+		{amount: ""},
+		{amount: "99999999999999999999"},
+	}
+	for _, tc := range testCases {
+		amount, err := ParseAmount(tc.amount)
+		if !tc.valid {
+			require.Error(t, err, tc.amount)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, amount)
+	}
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Daily   = "DAILY"
+	Weekly  = "WEEKLY"
+	Monthly = "MONTHLY"
+	Yearly  = "YEARLY"
+)
+
+const maxRecurrenceInterval = 366
+
+// Recurrence is the subset of an iCalendar RRULE used by standing orders: FREQ and INTERVAL.
+// The end of the series is kept separately as an end date or a number of occurrences
+type Recurrence struct {
+	Frequency string
+	Interval  int
+}
+
+// ParseRecurrence parses a rule such as "FREQ=MONTHLY;INTERVAL=1", with or without the "RRULE:" prefix
+func ParseRecurrence(rule string) (Recurrence, error) {
+	recurrence := Recurrence{Interval: 1}
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return recurrence, fmt.Errorf("empty recurrence rule")
+	}
+
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return recurrence, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			value = strings.ToUpper(value)
+			switch value {
+			case Daily, Weekly, Monthly, Yearly:
+				recurrence.Frequency = value
+			default:
+				return recurrence, fmt.Errorf("unsupported recurrence frequency %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > maxRecurrenceInterval {
+				return recurrence, fmt.Errorf("invalid recurrence interval %q", value)
+			}
+			recurrence.Interval = interval
+		default:
+			return recurrence, fmt.Errorf("unsupported recurrence rule part %q", key)
+		}
+	}
+	if recurrence.Frequency == "" {
+		return recurrence, fmt.Errorf("recurrence rule %q has no FREQ", rule)
+	}
+	return recurrence, nil
+}
+
+// Occurrence returns the n-th occurrence of the series starting at start, the first being n = 0.
+// Monthly and yearly series keep the start day, or the last day of shorter months
+func (recurrence Recurrence) Occurrence(start time.Time, n int) time.Time {
+	steps := n * recurrence.Interval
+	switch recurrence.Frequency {
+	case Daily:
+		return start.AddDate(0, 0, steps)
+	case Weekly:
+		return start.AddDate(0, 0, 7*steps)
+	case Yearly:
+		return addMonths(start, 12*steps)
+	default:
+		return addMonths(start, steps)
+	}
+}
+
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	if lastDay := first.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
@@ -0,0 +1,45 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	recurrence, err := ParseRecurrence("RRULE:FREQ=WEEKLY;INTERVAL=2")
+	require.NoError(t, err)
+	require.Equal(t, Recurrence{Frequency: Weekly, Interval: 2}, recurrence)
+
+	recurrence, err = ParseRecurrence("freq=monthly")
+	require.NoError(t, err)
+	require.Equal(t, Recurrence{Frequency: Monthly, Interval: 1}, recurrence)
+
+	for _, rule := range []string{"", "FREQ=HOURLY", "INTERVAL=2", "FREQ=DAILY;INTERVAL=0", "FREQ=DAILY;BYDAY=MO", "FREQ"} {
+		_, err = ParseRecurrence(rule)
+		require.Error(t, err, rule)
+	}
+}
+
+func TestRecurrenceOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 31, 9, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		recurrence Recurrence
+		n          int
+		expected   time.Time
+	}{
+		{Recurrence{Daily, 1}, 0, start},
+		{Recurrence{Daily, 3}, 2, time.Date(2024, time.February, 6, 9, 30, 0, 0, time.UTC)},
+		{Recurrence{Weekly, 2}, 1, time.Date(2024, time.February, 14, 9, 30, 0, 0, time.UTC)},
+		// the 31st falls back to the last day of shorter months, without drifting
+		{Recurrence{Monthly, 1}, 1, time.Date(2024, time.February, 29, 9, 30, 0, 0, time.UTC)},
+		{Recurrence{Monthly, 1}, 2, time.Date(2024, time.March, 31, 9, 30, 0, 0, time.UTC)},
+		{Recurrence{Monthly, 3}, 1, time.Date(2024, time.April, 30, 9, 30, 0, 0, time.UTC)},
+		{Recurrence{Yearly, 1}, 1, time.Date(2025, time.January, 31, 9, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, tc.recurrence.Occurrence(start, tc.n))
+	}
+}
@@ -0,0 +1,30 @@
+package util
+
+const (
+	DepositorRole = "depositor"
+	BankerRole    = "banker"
+	AdminRole     = "admin"
+)
+
+func IsSupportedRole(role string) bool {
+	switch role {
+	case DepositorRole, BankerRole, AdminRole:
+		return true
+	}
+	return false
+}
+
+// HasRole reports whether role is one of roles
+func HasRole(role string, roles ...string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// IsPrivilegedRole reports whether role may act on accounts it doesn't own
+func IsPrivilegedRole(role string) bool {
+	return HasRole(role, BankerRole, AdminRole)
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRole(t *testing.T) {
+	require.True(t, HasRole(BankerRole, DepositorRole, BankerRole))
+	require.False(t, HasRole(AdminRole, DepositorRole, BankerRole))
+	require.False(t, HasRole(AdminRole))
+}
+
+func TestIsPrivilegedRole(t *testing.T) {
+	require.False(t, IsPrivilegedRole(DepositorRole))
+	require.True(t, IsPrivilegedRole(BankerRole))
+	require.True(t, IsPrivilegedRole(AdminRole))
+	require.False(t, IsPrivilegedRole(""))
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+// EntryChainVerifier walks each account's entries in order, checking every entry still hashes
+// to what it was written with and still follows the entry it was chained to. Deleting an account's
+// last entry leaves no break in the chain, reconciliation catches that as balance drift instead
+type EntryChainVerifier struct {
+	store db.Store
+	// key must be the one the store hashes entries with
+	key       string
+	batchSize int32
+}
+
+func NewEntryChainVerifier(store db.Store, key string, batchSize int32) *EntryChainVerifier {
+	if batchSize <= 0 {
+		batchSize = defaultReconciliationBatchSize
+	}
+	return &EntryChainVerifier{
+		store:     store,
+		key:       key,
+		batchSize: batchSize,
+	}
+}
+
+// EntryChainBreak is an entry where the chain no longer holds
+type EntryChainBreak struct {
+	EntryID   int64  `json:"entry_id"`
+	AccountID int64  `json:"account_id"`
+	Problem   string `json:"problem"`
+}
+
+type EntryChainReport struct {
+	EntriesChecked int64 `json:"entries_checked"`
+	// UnchainedEntries were posted before the chain existed, so nothing can be said about them
+	UnchainedEntries int64             `json:"unchained_entries"`
+	Breaks           []EntryChainBreak `json:"breaks"`
+}
+
+// Intact reports whether no break was found
+func (report EntryChainReport) Intact() bool {
+	return len(report.Breaks) == 0
+}
+
+func (verifier *EntryChainVerifier) Verify(ctx context.Context) (EntryChainReport, error) {
+	report := EntryChainReport{Breaks: []EntryChainBreak{}}
+
+	var accountID, afterID int64
+	// prevHash is the hash of the last entry seen on accountID, chained tells whether any was hashed
+	var prevHash string
+	var chained bool
+	for {
+		entries, err := verifier.store.ListEntryChain(ctx, db.ListEntryChainParams{
+			AfterAccountID: accountID,
+			AfterID:        afterID,
+			Limit:          verifier.batchSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("cannot list entries: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.AccountID != accountID {
+				accountID = entry.AccountID
+				prevHash = ""
+				chained = false
+			}
+			report.EntriesChecked++
+
+			if problem := chainProblem(verifier.key, entry, prevHash, chained); problem != "" {
+				report.Breaks = append(report.Breaks, EntryChainBreak{
+					EntryID:   entry.ID,
+					AccountID: entry.AccountID,
+					Problem:   problem,
+				})
+			}
+			if entry.Hash == "" && !chained {
+				report.UnchainedEntries++
+			}
+			if entry.Hash != "" {
+				chained = true
+			}
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+		if len(entries) < int(verifier.batchSize) {
+			break
+		}
+	}
+	return report, nil
+}
+
+// chainProblem describes how entry breaks the chain, or returns "" when it doesn't.
+// prevHash is the hash of the entry before it on the account, chained whether any earlier entry was hashed
+func chainProblem(key string, entry db.Entry, prevHash string, chained bool) string {
+	switch {
+	case entry.Hash == "":
+		if chained {
+			return "entry was posted outside the chain"
+		}
+	case entry.PrevHash != prevHash:
+		return "previous entry is missing or was changed"
+	case db.EntryHash(key, entry.PrevHash, entry) != entry.Hash:
+		return "entry was changed"
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+// chainEntries hashes entries with key the way the store writes them, each chained to the one before
+func chainEntries(key string, entries ...db.Entry) []db.Entry {
+	prevHash := ""
+	for i := range entries {
+		entries[i].CreatedAt = time.Date(2024, 3, 10, 12, 0, i, 0, time.UTC)
+		entries[i].PrevHash = prevHash
+		entries[i].Hash = db.EntryHash(key, prevHash, entries[i])
+		prevHash = entries[i].Hash
+	}
+	return entries
+}
+
+func TestEntryChainVerifierVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	legacy := db.Entry{ID: 1, AccountID: 1, Amount: 100}
+	account1 := chainEntries("secret",
+		db.Entry{ID: 2, AccountID: 1, Amount: -10, TransferID: sql.NullInt64{Int64: 1, Valid: true}},
+		db.Entry{ID: 4, AccountID: 1, Amount: 5},
+	)
+	account2 := chainEntries("secret",
+		db.Entry{ID: 3, AccountID: 2, Amount: 10, TransferID: sql.NullInt64{Int64: 1, Valid: true}},
+		db.Entry{ID: 5, AccountID: 2, Amount: 7},
+		db.Entry{ID: 6, AccountID: 2, Amount: 8},
+	)
+	// entry 5 was edited, entry 6 still chains to what 5 was
+	account2[1].Amount = 700
+
+	gomock.InOrder(
+		store.EXPECT().ListEntryChain(gomock.Any(), gomock.Eq(db.ListEntryChainParams{Limit: 3})).
+			Return([]db.Entry{legacy, account1[0], account1[1]}, nil),
+		store.EXPECT().ListEntryChain(gomock.Any(), gomock.Eq(db.ListEntryChainParams{AfterAccountID: 1, AfterID: 4, Limit: 3})).
+			Return(account2, nil),
+		store.EXPECT().ListEntryChain(gomock.Any(), gomock.Eq(db.ListEntryChainParams{AfterAccountID: 2, AfterID: 6, Limit: 3})).
+			Return([]db.Entry{}, nil),
+	)
+
+	verifier := NewEntryChainVerifier(store, "secret", 3)
+	report, err := verifier.Verify(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, report.Intact())
+	require.Equal(t, int64(6), report.EntriesChecked)
+	require.Equal(t, int64(1), report.UnchainedEntries)
+	require.Equal(t, []EntryChainBreak{{EntryID: 5, AccountID: 2, Problem: "entry was changed"}}, report.Breaks)
+}
+
+func TestEntryChainVerifierRehashedWithoutKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// entry 2 was edited and the whole chain hashed again, without the store's key
+	entries := chainEntries("guessed",
+		db.Entry{ID: 1, AccountID: 1, Amount: 10},
+		db.Entry{ID: 2, AccountID: 1, Amount: 2000},
+		db.Entry{ID: 3, AccountID: 1, Amount: 30},
+	)
+
+	gomock.InOrder(
+		store.EXPECT().ListEntryChain(gomock.Any(), gomock.Eq(db.ListEntryChainParams{Limit: 10})).
+			Return(entries, nil),
+	)
+
+	verifier := NewEntryChainVerifier(store, "secret", 10)
+	report, err := verifier.Verify(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, report.Intact())
+	require.Equal(t, []EntryChainBreak{
+		{EntryID: 1, AccountID: 1, Problem: "entry was changed"},
+		{EntryID: 2, AccountID: 1, Problem: "entry was changed"},
+		{EntryID: 3, AccountID: 1, Problem: "entry was changed"},
+	}, report.Breaks)
+}
+
+func TestChainProblem(t *testing.T) {
+	entries := chainEntries("secret",
+		db.Entry{ID: 1, AccountID: 1, Amount: 10},
+		db.Entry{ID: 2, AccountID: 1, Amount: 20},
+	)
+
+	testCases := []struct {
+		name     string
+		entry    db.Entry
+		prevHash string
+		chained  bool
+		ok       bool
+	}{
+		{
+			name:     "First",
+			entry:    entries[0],
+			prevHash: "",
+			ok:       true,
+		},
+		{
+			name:     "Next",
+			entry:    entries[1],
+			prevHash: entries[0].Hash,
+			chained:  true,
+			ok:       true,
+		},
+		{
+			name:  "Unchained",
+			entry: db.Entry{ID: 1, AccountID: 1, Amount: 10},
+			ok:    true,
+		},
+		{
+			name:     "PostedOutsideChain",
+			entry:    db.Entry{ID: 3, AccountID: 1, Amount: 10},
+			prevHash: entries[1].Hash,
+			chained:  true,
+		},
+		{
+			// the entry before was deleted
+			name:     "PreviousMissing",
+			entry:    entries[1],
+			prevHash: "",
+		},
+		{
+			name: "Changed",
+			entry: func() db.Entry {
+				entry := entries[1]
+				entry.CreatedAt = entry.CreatedAt.Add(-time.Hour)
+				return entry
+			}(),
+			prevHash: entries[0].Hash,
+			chained:  true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			problem := chainProblem("secret", tc.entry, tc.prevHash, tc.chained)
+			if tc.ok {
+				require.Empty(t, problem)
+			} else {
+				require.NotEmpty(t, problem)
+			}
+		})
+	}
+}
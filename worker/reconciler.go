@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+const defaultReconciliationBatchSize = 1000
+
+// Reconciler checks the ledger explains the balances: every account balance must equal the sum of
+// its entries, and every transfer must have exactly the entries its status calls for
+type Reconciler struct {
+	store      db.Store
+	signingKey string
+	batchSize  int32
+}
+
+// NewReconciler returns a reconciler that reads batchSize rows at a time, and signs the reports
+// it saves with signingKey
+func NewReconciler(store db.Store, signingKey string, batchSize int32) *Reconciler {
+	if batchSize <= 0 {
+		batchSize = defaultReconciliationBatchSize
+	}
+	return &Reconciler{
+		store:      store,
+		signingKey: signingKey,
+		batchSize:  batchSize,
+	}
+}
+
+// AccountDrift is an account whose balance the ledger doesn't explain
+type AccountDrift struct {
+	AccountID        int64 `json:"account_id"`
+	Balance          int64 `json:"balance"`
+	EntriesTotal     int64 `json:"entries_total"`
+	AvailableBalance int64 `json:"available_balance"`
+	HeldTotal        int64 `json:"held_total"`
+}
+
+// TransferMismatch is a transfer whose entries are missing or don't match it
+type TransferMismatch struct {
+	TransferID int64  `json:"transfer_id"`
+	Status     string `json:"status"`
+	EntryCount int64  `json:"entry_count"`
+	Problem    string `json:"problem"`
+}
+
+type ReconciliationReport struct {
+	StartedAt          time.Time          `json:"started_at"`
+	FinishedAt         time.Time          `json:"finished_at"`
+	AccountsChecked    int64              `json:"accounts_checked"`
+	TransfersChecked   int64              `json:"transfers_checked"`
+	AccountDrifts      []AccountDrift     `json:"account_drifts"`
+	TransferMismatches []TransferMismatch `json:"transfer_mismatches"`
+}
+
+// Balanced reports whether the run found nothing wrong
+func (report ReconciliationReport) Balanced() bool {
+	return len(report.AccountDrifts) == 0 && len(report.TransferMismatches) == 0
+}
+
+// Reconcile scans every account and then every transfer, in id order and in batches.
+// Each batch is read in one statement, so a transfer committing mid-scan is seen whole or not at all
+func (reconciler *Reconciler) Reconcile(ctx context.Context) (ReconciliationReport, error) {
+	report := ReconciliationReport{
+		StartedAt:          time.Now(),
+		AccountDrifts:      []AccountDrift{},
+		TransferMismatches: []TransferMismatch{},
+	}
+
+	var afterID int64
+	for {
+		accounts, err := reconciler.store.ListAccountLedgerTotals(ctx, db.ListAccountLedgerTotalsParams{
+			AfterID: afterID,
+			Limit:   reconciler.batchSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("cannot list account totals: %w", err)
+		}
+		for _, account := range accounts {
+			report.AccountsChecked++
+			if account.Balance != account.EntriesTotal || account.AvailableBalance != account.Balance-account.HeldTotal {
+				report.AccountDrifts = append(report.AccountDrifts, AccountDrift{
+					AccountID:        account.ID,
+					Balance:          account.Balance,
+					EntriesTotal:     account.EntriesTotal,
+					AvailableBalance: account.AvailableBalance,
+					HeldTotal:        account.HeldTotal,
+				})
+			}
+			afterID = account.ID
+		}
+		if len(accounts) < int(reconciler.batchSize) {
+			break
+		}
+	}
+
+	afterID = 0
+	for {
+		transfers, err := reconciler.store.ListTransferEntryTotals(ctx, db.ListTransferEntryTotalsParams{
+			AfterID: afterID,
+			Limit:   reconciler.batchSize,
+		})
+		if err != nil {
+			return report, fmt.Errorf("cannot list transfer entries: %w", err)
+		}
+		for _, transfer := range transfers {
+			report.TransfersChecked++
+			if problem := transferProblem(transfer); problem != "" {
+				report.TransferMismatches = append(report.TransferMismatches, TransferMismatch{
+					TransferID: transfer.ID,
+					Status:     transfer.Status,
+					EntryCount: transfer.EntryCount,
+					Problem:    problem,
+				})
+			}
+			afterID = transfer.ID
+		}
+		if len(transfers) < int(reconciler.batchSize) {
+			break
+		}
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// transferProblem describes what is wrong with a transfer's entries, or returns "" when nothing is.
+// A settled transfer has a debit of amount and a credit of to_amount, any other has no entries yet
+func transferProblem(transfer db.ListTransferEntryTotalsRow) string {
+	if transfer.Status != db.TransferSettled {
+		if transfer.EntryCount != 0 {
+			return "entries posted for a transfer that isn't settled"
+		}
+		return ""
+	}
+
+	switch {
+	case transfer.EntryCount < 2:
+		return "missing entries"
+	case transfer.EntryCount > 2:
+		return "extra entries"
+	case transfer.FromAccountID == transfer.ToAccountID:
+		// both entries are on the same account, so each total covers both
+		if transfer.FromEntriesTotal != transfer.ToAmount-transfer.Amount {
+			return "entries don't match the amounts"
+		}
+	case transfer.FromEntriesTotal != -transfer.Amount:
+		return "debit entry doesn't match the amount"
+	case transfer.ToEntriesTotal != transfer.ToAmount:
+		return "credit entry doesn't match the to amount"
+	}
+	return ""
+}
+
+// Save stores report with its signature, so it can be shown later not to have been edited
+func (reconciler *Reconciler) Save(ctx context.Context, report ReconciliationReport) (db.ReconciliationReport, error) {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return db.ReconciliationReport{}, err
+	}
+	return reconciler.store.CreateReconciliationReport(ctx, db.CreateReconciliationReportParams{
+		StartedAt:          report.StartedAt,
+		FinishedAt:         report.FinishedAt,
+		AccountsChecked:    report.AccountsChecked,
+		TransfersChecked:   report.TransfersChecked,
+		AccountDrifts:      int64(len(report.AccountDrifts)),
+		TransferMismatches: int64(len(report.TransferMismatches)),
+		Report:             body,
+		Signature:          reconciler.sign(body),
+	})
+}
+
+// Verify reports whether a saved report still matches its signature
+func (reconciler *Reconciler) Verify(saved db.ReconciliationReport) bool {
+	signature, err := hex.DecodeString(saved.Signature)
+	if err != nil {
+		return false
+	}
+	expected, _ := hex.DecodeString(reconciler.sign(saved.Report))
+	return hmac.Equal(signature, expected)
+}
+
+func (reconciler *Reconciler) sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(reconciler.signingKey))
+	mac.Write([]byte("reconciliation:"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
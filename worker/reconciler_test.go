@@ -0,0 +1,138 @@
+package worker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcilerReconcile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Eq(db.ListAccountLedgerTotalsParams{AfterID: 0, Limit: 2})).
+			Return([]db.ListAccountLedgerTotalsRow{
+				{ID: 1, Balance: 100, AvailableBalance: 100, EntriesTotal: 100},
+				{ID: 2, Balance: 90, AvailableBalance: 80, EntriesTotal: 90, HeldTotal: 10},
+			}, nil),
+		store.EXPECT().ListAccountLedgerTotals(gomock.Any(), gomock.Eq(db.ListAccountLedgerTotalsParams{AfterID: 2, Limit: 2})).
+			Return([]db.ListAccountLedgerTotalsRow{
+				// balance the entries don't explain
+				{ID: 3, Balance: 50, AvailableBalance: 50, EntriesTotal: 40},
+			}, nil),
+		store.EXPECT().ListTransferEntryTotals(gomock.Any(), gomock.Eq(db.ListTransferEntryTotalsParams{AfterID: 0, Limit: 2})).
+			Return([]db.ListTransferEntryTotalsRow{
+				{ID: 1, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferSettled, EntryCount: 2, FromEntriesTotal: -10, ToEntriesTotal: 10},
+				{ID: 2, FromAccountID: 2, ToAccountID: 1, Amount: 10, ToAmount: 10, Status: db.TransferAuthorized},
+			}, nil),
+		store.EXPECT().ListTransferEntryTotals(gomock.Any(), gomock.Eq(db.ListTransferEntryTotalsParams{AfterID: 2, Limit: 2})).
+			Return([]db.ListTransferEntryTotalsRow{
+				{ID: 3, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferSettled, EntryCount: 1, FromEntriesTotal: -10},
+				{ID: 4, FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 9, Status: db.TransferSettled, EntryCount: 2, FromEntriesTotal: -10, ToEntriesTotal: 10},
+			}, nil),
+		store.EXPECT().ListTransferEntryTotals(gomock.Any(), gomock.Eq(db.ListTransferEntryTotalsParams{AfterID: 4, Limit: 2})).
+			Return([]db.ListTransferEntryTotalsRow{}, nil),
+	)
+
+	reconciler := NewReconciler(store, "secret", 2)
+	report, err := reconciler.Reconcile(context.Background())
+	require.NoError(t, err)
+
+	require.False(t, report.Balanced())
+	require.Equal(t, int64(3), report.AccountsChecked)
+	require.Equal(t, int64(4), report.TransfersChecked)
+	require.Len(t, report.AccountDrifts, 1)
+	require.Equal(t, int64(3), report.AccountDrifts[0].AccountID)
+	require.Len(t, report.TransferMismatches, 2)
+	require.Equal(t, int64(3), report.TransferMismatches[0].TransferID)
+	require.Equal(t, "missing entries", report.TransferMismatches[0].Problem)
+	require.Equal(t, int64(4), report.TransferMismatches[1].TransferID)
+	require.False(t, report.FinishedAt.Before(report.StartedAt))
+}
+
+func TestTransferProblem(t *testing.T) {
+	testCases := []struct {
+		name     string
+		transfer db.ListTransferEntryTotalsRow
+		ok       bool
+	}{
+		{
+			name:     "Settled",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 9, Status: db.TransferSettled, EntryCount: 2, FromEntriesTotal: -10, ToEntriesTotal: 9},
+			ok:       true,
+		},
+		{
+			name:     "SelfTransfer",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 1, Amount: 10, ToAmount: 10, Status: db.TransferSettled, EntryCount: 2},
+			ok:       true,
+		},
+		{
+			name:     "Voided",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferVoided},
+			ok:       true,
+		},
+		{
+			name:     "EntriesOnHold",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferAuthorized, EntryCount: 1, FromEntriesTotal: -10},
+		},
+		{
+			name:     "ExtraEntries",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferSettled, EntryCount: 3, FromEntriesTotal: -10, ToEntriesTotal: 10},
+		},
+		{
+			name:     "WrongDebit",
+			transfer: db.ListTransferEntryTotalsRow{FromAccountID: 1, ToAccountID: 2, Amount: 10, ToAmount: 10, Status: db.TransferSettled, EntryCount: 2, FromEntriesTotal: -9, ToEntriesTotal: 10},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+		t.Run(tc.name, func(t *testing.T) {
+			problem := transferProblem(tc.transfer)
+			if tc.ok {
+				require.Empty(t, problem)
+			} else {
+				require.NotEmpty(t, problem)
+			}
+		})
+	}
+}
+
+func TestReconcilerSaveAndVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	report := ReconciliationReport{
+		AccountsChecked:    2,
+		AccountDrifts:      []AccountDrift{{AccountID: 1, Balance: 10, EntriesTotal: 5}},
+		TransferMismatches: []TransferMismatch{},
+	}
+
+	var saved db.ReconciliationReport
+	store.EXPECT().CreateReconciliationReport(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(_ context.Context, arg db.CreateReconciliationReportParams) (db.ReconciliationReport, error) {
+			require.Equal(t, int64(2), arg.AccountsChecked)
+			require.Equal(t, int64(1), arg.AccountDrifts)
+			require.Zero(t, arg.TransferMismatches)
+			saved = db.ReconciliationReport{ID: 1, Report: arg.Report, Signature: arg.Signature}
+			return saved, nil
+		})
+
+	reconciler := NewReconciler(store, "secret", 0)
+	_, err := reconciler.Save(context.Background(), report)
+	require.NoError(t, err)
+	require.True(t, reconciler.Verify(saved))
+
+	// another key, or an edited report, doesn't verify
+	require.False(t, NewReconciler(store, "other", 0).Verify(saved))
+	tampered := saved
+	tampered.Report = []byte(`{"accounts_checked":2}`)
+	require.False(t, reconciler.Verify(tampered))
+}
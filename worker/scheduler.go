@@ -0,0 +1,110 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+// Scheduler periodically executes scheduled transfers and standing order occurrences that have come due,
+// and the pending items of uploaded payment batches.
+// Several schedulers can run against the same database, each due row is claimed by one of them
+type Scheduler struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewScheduler(store db.Store, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run executes due transfers and occurrences on every tick until ctx is cancelled
+func (scheduler *Scheduler) Run(ctx context.Context) {
+	// a zero interval disables the scheduler
+	if scheduler.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			scheduler.ExecuteDue(ctx)
+		}
+	}
+}
+
+// ExecuteDue executes scheduled transfers, standing order occurrences and payment batch items
+// until none are due or ctx is cancelled, and returns how many it ran.
+// Cancelling ctx stops it between transfers, the one being executed is finished first
+func (scheduler *Scheduler) ExecuteDue(ctx context.Context) int {
+	return scheduler.executeScheduledTransfers(ctx) + scheduler.executeStandingOrders(ctx) + scheduler.executePaymentBatchItems(ctx)
+}
+
+func (scheduler *Scheduler) executeScheduledTransfers(ctx context.Context) int {
+	executed := 0
+	for ctx.Err() == nil {
+		result, err := scheduler.store.ExecuteScheduledTransferTx(context.WithoutCancel(ctx))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.ErrorContext(ctx, "cannot execute scheduled transfer", "error", err)
+			}
+			break
+		}
+		executed++
+		scheduled := result.ScheduledTransfer
+		if scheduled.Status == db.ScheduledTransferFailed {
+			slog.WarnContext(ctx, "scheduled transfer failed", "scheduled_transfer_id", scheduled.ID, "reason", scheduled.FailureReason.String)
+		}
+	}
+	return executed
+}
+
+func (scheduler *Scheduler) executeStandingOrders(ctx context.Context) int {
+	executed := 0
+	for ctx.Err() == nil {
+		result, err := scheduler.store.ExecuteStandingOrderTx(context.WithoutCancel(ctx))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.ErrorContext(ctx, "cannot execute standing order", "error", err)
+			}
+			break
+		}
+		executed++
+		execution := result.Execution
+		if execution.Status != db.ExecutionCompleted {
+			slog.WarnContext(ctx, "standing order occurrence not completed", "standing_order_id", execution.StandingOrderID,
+				"occurrence", execution.Occurrence, "status", execution.Status, "reason", execution.FailureReason.String)
+		}
+	}
+	return executed
+}
+
+func (scheduler *Scheduler) executePaymentBatchItems(ctx context.Context) int {
+	executed := 0
+	for ctx.Err() == nil {
+		result, err := scheduler.store.ExecutePaymentBatchItemTx(context.WithoutCancel(ctx))
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				slog.ErrorContext(ctx, "cannot execute payment batch item", "error", err)
+			}
+			break
+		}
+		executed++
+		item := result.Item
+		if item.Status == db.PaymentBatchItemFailed {
+			slog.WarnContext(ctx, "payment batch item failed", "batch_id", item.BatchID, "line", item.Line, "reason", item.FailureReason.String)
+		}
+	}
+	return executed
+}
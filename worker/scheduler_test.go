@@ -0,0 +1,82 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	db "github.com/joekings2k/gobank/db/sqlc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedulerExecuteDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	gomock.InOrder(
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Return(db.ExecuteScheduledTransferTxResult{
+			ScheduledTransfer: db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferCompleted},
+		}, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Return(db.ExecuteScheduledTransferTxResult{
+			ScheduledTransfer: db.ScheduledTransfer{ID: 2, Status: db.ScheduledTransferFailed},
+		}, nil),
+		store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrNoRows),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Return(db.ExecuteStandingOrderTxResult{
+			Execution: db.StandingOrderExecution{StandingOrderID: 1, Occurrence: 1, Status: db.ExecutionCompleted},
+		}, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Return(db.ExecuteStandingOrderTxResult{
+			Execution: db.StandingOrderExecution{StandingOrderID: 1, Occurrence: 2, Status: db.ExecutionSkipped},
+		}, nil),
+		store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Return(db.ExecuteStandingOrderTxResult{}, sql.ErrNoRows),
+		store.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Return(db.ExecutePaymentBatchItemTxResult{
+			Item: db.PaymentBatchItem{BatchID: 1, Line: 1, Status: db.PaymentBatchItemCompleted},
+		}, nil),
+		store.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Return(db.ExecutePaymentBatchItemTxResult{
+			Item: db.PaymentBatchItem{BatchID: 1, Line: 2, Status: db.PaymentBatchItemFailed},
+		}, nil),
+		store.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Return(db.ExecutePaymentBatchItemTxResult{}, sql.ErrNoRows),
+	)
+
+	scheduler := NewScheduler(store, 0)
+	require.Equal(t, 6, scheduler.ExecuteDue(context.Background()))
+}
+
+func TestSchedulerStopsOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	// an infrastructure error leaves the row pending for the next tick
+	store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).Return(db.ExecuteScheduledTransferTxResult{}, sql.ErrConnDone)
+	store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(1).Return(db.ExecuteStandingOrderTxResult{}, sql.ErrConnDone)
+	store.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Times(1).Return(db.ExecutePaymentBatchItemTxResult{}, sql.ErrConnDone)
+
+	scheduler := NewScheduler(store, 0)
+	require.Zero(t, scheduler.ExecuteDue(context.Background()))
+}
+
+func TestSchedulerFinishesTransferOnCancel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// shutting down mid-transfer lets the transfer commit, then nothing else is started
+	store.EXPECT().ExecuteScheduledTransferTx(gomock.Any()).Times(1).
+		DoAndReturn(func(txCtx context.Context) (db.ExecuteScheduledTransferTxResult, error) {
+			cancel()
+			require.NoError(t, txCtx.Err())
+			return db.ExecuteScheduledTransferTxResult{
+				ScheduledTransfer: db.ScheduledTransfer{ID: 1, Status: db.ScheduledTransferCompleted},
+			}, nil
+		})
+	store.EXPECT().ExecuteStandingOrderTx(gomock.Any()).Times(0)
+	store.EXPECT().ExecutePaymentBatchItemTx(gomock.Any()).Times(0)
+
+	scheduler := NewScheduler(store, 0)
+	require.Equal(t, 1, scheduler.ExecuteDue(ctx))
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+// snapshotGrace is how long after midnight a day is closed. Entries take the time their
+// transaction started, so a transfer begun just before midnight may commit just after
+const snapshotGrace = 5 * time.Minute
+
+// snapshotRecheckDays is how many closed days every tick closes again, so a snapshot taken before a late
+// entry committed is corrected, and the correction carries forward to the days after it
+const snapshotRecheckDays = 7
+
+// Snapshotter periodically closes the day, snapshotting every account's ledger balance at midnight UTC.
+// Closing a day is idempotent, and a day missed while nothing was running for longer than the recheck
+// window only leaves the nearest snapshot further back
+type Snapshotter struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewSnapshotter(store db.Store, interval time.Duration) *Snapshotter {
+	return &Snapshotter{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run closes the last finished day on every tick until ctx is cancelled. A snapshot under way when it is cancelled is finished first
+func (snapshotter *Snapshotter) Run(ctx context.Context) {
+	// a zero interval disables snapshots
+	if snapshotter.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(snapshotter.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			snapshotter.Snapshot(context.WithoutCancel(ctx), time.Now())
+		}
+	}
+}
+
+// Snapshot closes the last snapshotRecheckDays days that finished before now, oldest first since each
+// snapshot builds on the one before, and returns how many snapshots it wrote or corrected
+func (snapshotter *Snapshotter) Snapshot(ctx context.Context, now time.Time) int64 {
+	var written int64
+	last := lastClosedDay(now)
+	for day := last.AddDate(0, 0, 1-snapshotRecheckDays); !day.After(last); day = day.AddDate(0, 0, 1) {
+		count, err := snapshotter.store.CreateBalanceSnapshots(ctx, day)
+		if err != nil {
+			// later days would build on a day that wasn't closed, the next tick retries them all
+			slog.ErrorContext(ctx, "cannot snapshot balances", "day", day, "error", err)
+			return written
+		}
+		if count > 0 {
+			slog.InfoContext(ctx, "snapshotted balances", "day", day, "count", count)
+		}
+		written += count
+	}
+	return written
+}
+
+// lastClosedDay returns the last midnight UTC at least snapshotGrace before now
+func lastClosedDay(now time.Time) time.Time {
+	return now.Add(-snapshotGrace).UTC().Truncate(24 * time.Hour)
+}
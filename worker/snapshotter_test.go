@@ -0,0 +1,63 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	mockdb "github.com/joekings2k/gobank/db/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastClosedDay(t *testing.T) {
+	midnight := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	require.Equal(t, midnight, lastClosedDay(midnight.Add(time.Hour)))
+	require.Equal(t, midnight, lastClosedDay(midnight.Add(snapshotGrace)))
+	// within the grace period the previous day is still the last closed one
+	require.Equal(t, midnight.Add(-24*time.Hour), lastClosedDay(midnight.Add(time.Minute)))
+	// days are UTC, whatever zone now is in
+	lagos := time.FixedZone("WAT", 60*60)
+	require.Equal(t, midnight, lastClosedDay(time.Date(2024, 3, 10, 0, 30, 0, 0, lagos).Add(time.Hour)))
+}
+
+func TestSnapshotterSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	store := mockdb.NewMockStore(ctrl)
+
+	now := time.Date(2024, 3, 10, 13, 0, 0, 0, time.UTC)
+	day := time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+	// the recheck window, oldest first
+	days := make([]time.Time, snapshotRecheckDays)
+	for i := range days {
+		days[i] = day.AddDate(0, 0, i+1-snapshotRecheckDays)
+	}
+	require.Equal(t, day, days[len(days)-1])
+
+	var calls []*gomock.Call
+	// the first tick closes the last day
+	for _, d := range days[:len(days)-1] {
+		calls = append(calls, store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(d)).Return(int64(0), nil))
+	}
+	calls = append(calls, store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(day)).Return(int64(3), nil))
+	// an entry committed late into an earlier day corrects that day's snapshot and the ones after it
+	for i, d := range days {
+		var corrected int64
+		if i >= 4 {
+			corrected = 1
+		}
+		calls = append(calls, store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(d)).Return(corrected, nil))
+	}
+	// a failure stops the tick, later days would build on a day that wasn't closed
+	calls = append(calls, store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(days[0])).Return(int64(2), nil))
+	calls = append(calls, store.EXPECT().CreateBalanceSnapshots(gomock.Any(), gomock.Eq(days[1])).Return(int64(0), sql.ErrConnDone))
+	gomock.InOrder(calls...)
+
+	snapshotter := NewSnapshotter(store, 0)
+	require.Equal(t, int64(3), snapshotter.Snapshot(context.Background(), now))
+	require.Equal(t, int64(3), snapshotter.Snapshot(context.Background(), now))
+	require.Equal(t, int64(2), snapshotter.Snapshot(context.Background(), now))
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	db "github.com/joekings2k/gobank/db/sqlc"
+)
+
+// Sweeper periodically prunes rows that are no longer needed
+type Sweeper struct {
+	name     string
+	interval time.Duration
+	sweep    func(ctx context.Context) (int64, error)
+}
+
+// NewRevocationSweeper prunes revoked tokens that have expired anyway
+func NewRevocationSweeper(store db.Store, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		name:     "expired revoked tokens",
+		interval: interval,
+		sweep:    store.DeleteExpiredRevokedTokens,
+	}
+}
+
+// NewIdempotencyKeySweeper prunes idempotency keys past their replay window
+func NewIdempotencyKeySweeper(store db.Store, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		name:     "expired idempotency keys",
+		interval: interval,
+		sweep:    store.DeleteExpiredIdempotencyKeys,
+	}
+}
+
+// NewHoldSweeper expires authorized transfers nobody captured or voided in time, releasing their holds
+func NewHoldSweeper(store db.Store, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		name:     "expired transfer holds",
+		interval: interval,
+		sweep:    store.ExpireTransferHoldsTx,
+	}
+}
+
+// Run sweeps on every tick until ctx is cancelled. A sweep under way when it is cancelled is finished first
+func (sweeper *Sweeper) Run(ctx context.Context) {
+	// a zero interval disables sweeping
+	if sweeper.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(sweeper.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweeper.Sweep(context.WithoutCancel(ctx))
+		}
+	}
+}
+
+// Sweep prunes expired rows once
+func (sweeper *Sweeper) Sweep(ctx context.Context) {
+	deleted, err := sweeper.sweep(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "cannot prune", "rows", sweeper.name, "error", err)
+		return
+	}
+	if deleted > 0 {
+		slog.InfoContext(ctx, "pruned", "rows", sweeper.name, "count", deleted)
+	}
+}